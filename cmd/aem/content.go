@@ -1,15 +1,18 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/spf13/cobra"
 	"github.com/wttech/aemc/pkg"
+	"github.com/wttech/aemc/pkg/common/fmtx"
 	"github.com/wttech/aemc/pkg/common/pathx"
 	"github.com/wttech/aemc/pkg/common/timex"
 	"github.com/wttech/aemc/pkg/content"
 	"os"
-	"path/filepath"
+	"os/signal"
 	"strings"
+	"syscall"
 )
 
 func (c *CLI) contentCmd() *cobra.Command {
@@ -23,6 +26,8 @@ func (c *CLI) contentCmd() *cobra.Command {
 	cmd.AddCommand(c.contentPushCmd())
 	cmd.AddCommand(c.contentDownloadCmd())
 	cmd.AddCommand(c.contentCopyCmd())
+	cmd.AddCommand(c.contentWatchCmd())
+	cmd.AddCommand(c.contentLintCmd())
 	return cmd
 }
 
@@ -66,6 +71,64 @@ func (c *CLI) contentCleanCmd() *cobra.Command {
 	return cmd
 }
 
+func (c *CLI) contentLintCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "lint [dir]",
+		Aliases: []string{"lnt"},
+		Short:   "Check content against project policies",
+		Args:    cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var path string
+			if len(args) > 0 {
+				path = args[0]
+			} else {
+				dir, err := determineContentDir(cmd)
+				if err != nil {
+					c.Error(err)
+					return
+				}
+				file, err := determineContentFile(cmd)
+				if err != nil {
+					c.Error(err)
+					return
+				}
+				path = dir
+				if path == "" {
+					path = file
+				}
+			}
+			if path == "" {
+				c.Fail("content to lint should be specified as argument or using flag '--dir', '--file' or '--path'")
+				return
+			}
+			result, err := c.aem.ContentManager().Lint(path)
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			c.SetOutput("lint", result)
+			if sarifFile, _ := cmd.Flags().GetString("sarif-file"); sarifFile != "" {
+				if err = result.WriteSARIF(sarifFile); err != nil {
+					c.Error(err)
+					return
+				}
+				c.SetOutput("sarifFile", sarifFile)
+			}
+			if errors := result.Count(content.LintSeverityError); errors > 0 {
+				c.Fail(fmt.Sprintf("content lint found errors (%d)", errors))
+				return
+			}
+			c.Ok("content linted")
+		},
+	}
+	cmd.Flags().StringP("dir", "d", "", "JCR root path")
+	cmd.Flags().StringP("file", "f", "", "Local file path")
+	cmd.Flags().StringP("path", "p", "", "JCR root path or local file path")
+	cmd.MarkFlagsMutuallyExclusive("dir", "file", "path")
+	cmd.Flags().String("sarif-file", "", "File path for report in SARIF format")
+	return cmd
+}
+
 func (c *CLI) contentDownloadCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "download",
@@ -82,6 +145,14 @@ func (c *CLI) contentDownloadCmd() *cobra.Command {
 			filterRoots := determineFilterRoots(cmd)
 			filterFile, _ := cmd.Flags().GetString("filter-file")
 			clean, _ := cmd.Flags().GetBool("clean")
+			if err = contentTransformByFlags(cmd, c.aem.ContentManager()); err != nil {
+				c.Error(err)
+				return
+			}
+			if err = contentScrubByFlags(cmd, c.aem.ContentManager()); err != nil {
+				c.Error(err)
+				return
+			}
 			if err = c.aem.ContentManager().Download(instance, targetFile, clean, pkg.PackageCreateOpts{
 				PID:         targetPID,
 				FilterRoots: filterRoots,
@@ -90,6 +161,10 @@ func (c *CLI) contentDownloadCmd() *cobra.Command {
 				c.Error(err)
 				return
 			}
+			if err = c.contentScrubReport(cmd, c.aem.ContentManager()); err != nil {
+				c.Error(err)
+				return
+			}
 			c.SetOutput("file", targetFile)
 			c.Changed("content downloaded")
 		},
@@ -103,6 +178,8 @@ func (c *CLI) contentDownloadCmd() *cobra.Command {
 	cmd.MarkFlagsOneRequired("filter-roots", "filter-file")
 	cmd.MarkFlagsMutuallyExclusive("filter-roots", "filter-file")
 	cmd.Flags().BoolP("clean", "c", false, "Normalize content after downloading")
+	contentDefineTransformFlags(cmd)
+	contentDefineScrubFlags(cmd)
 	return cmd
 }
 
@@ -132,6 +209,11 @@ func (c *CLI) contentPullCmd() *cobra.Command {
 			filterRootExcludes := determineFilterRootExcludes(cmd)
 			clean, _ := cmd.Flags().GetBool("clean")
 			replace, _ := cmd.Flags().GetBool("replace")
+			contentSyncConflictByFlags(cmd, c.aem.ContentManager())
+			if err = contentTransformByFlags(cmd, c.aem.ContentManager()); err != nil {
+				c.Error(err)
+				return
+			}
 			if dir != "" {
 				if err = c.aem.ContentManager().PullDir(instance, dir, clean, replace, pkg.PackageCreateOpts{
 					PID:         fmt.Sprintf("aemc:content-pull:%s-SNAPSHOT", timex.FileTimestampForNow()),
@@ -166,6 +248,8 @@ func (c *CLI) contentPullCmd() *cobra.Command {
 	cmd.MarkFlagsMutuallyExclusive("filter-roots", "filter-file")
 	cmd.Flags().BoolP("clean", "c", false, "Normalize content after downloading")
 	cmd.Flags().BoolP("replace", "r", false, "Replace content after downloading")
+	contentDefineSyncConflictFlag(cmd)
+	contentDefineTransformFlags(cmd)
 	return cmd
 }
 
@@ -202,6 +286,11 @@ func (c *CLI) contentPushCmd() *cobra.Command {
 			filterRootExcludes := determineFilterRootExcludes(cmd)
 			clean, _ := cmd.Flags().GetBool("clean")
 			filterMode, _ := cmd.Flags().GetString("filter-mode")
+			contentSyncConflictByFlags(cmd, c.aem.ContentManager())
+			if err = contentTransformByFlags(cmd, c.aem.ContentManager()); err != nil {
+				c.Error(err)
+				return
+			}
 			if err = c.aem.ContentManager().Push(instances, clean, pkg.PackageCreateOpts{
 				PID:                fmt.Sprintf("aemc:content-push:%s-SNAPSHOT", timex.FileTimestampForNow()),
 				FilterRoots:        filterRoots,
@@ -227,6 +316,8 @@ func (c *CLI) contentPushCmd() *cobra.Command {
 	cmd.MarkFlagsMutuallyExclusive("dir", "file", "path")
 	cmd.Flags().BoolP("clean", "c", false, "Normalize content while uploading")
 	cmd.Flags().StringP("filter-mode", "m", "", "Override default filter mode")
+	contentDefineSyncConflictFlag(cmd)
+	contentDefineTransformFlags(cmd)
 	return cmd
 }
 
@@ -249,6 +340,14 @@ func (c *CLI) contentCopyCmd() *cobra.Command {
 			filterRoots := determineFilterRoots(cmd)
 			filterFile, _ := cmd.Flags().GetString("filter-file")
 			clean, _ := cmd.Flags().GetBool("clean")
+			if err = contentTransformByFlags(cmd, c.aem.ContentManager()); err != nil {
+				c.Error(err)
+				return
+			}
+			if err = contentScrubByFlags(cmd, c.aem.ContentManager()); err != nil {
+				c.Error(err)
+				return
+			}
 			if err = c.aem.ContentManager().Copy(instance, targetInstances, clean, pkg.PackageCreateOpts{
 				PID:         fmt.Sprintf("aemc:content-copy:%s-SNAPSHOT", timex.FileTimestampForNow()),
 				FilterRoots: filterRoots,
@@ -257,6 +356,10 @@ func (c *CLI) contentCopyCmd() *cobra.Command {
 				c.Error(err)
 				return
 			}
+			if err = c.contentScrubReport(cmd, c.aem.ContentManager()); err != nil {
+				c.Error(err)
+				return
+			}
 			c.Changed("content copied")
 		},
 	}
@@ -269,9 +372,112 @@ func (c *CLI) contentCopyCmd() *cobra.Command {
 	cmd.MarkFlagsOneRequired("filter-roots", "filter-file")
 	cmd.MarkFlagsMutuallyExclusive("filter-roots", "filter-file")
 	cmd.Flags().BoolP("clean", "c", false, "Normalize content while copying")
+	contentDefineTransformFlags(cmd)
+	contentDefineScrubFlags(cmd)
 	return cmd
 }
 
+func (c *CLI) contentWatchCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "watch",
+		Aliases: []string{"w"},
+		Short:   "Watch JCR root directory then push changes to running instances (optionally pull remote changes back)",
+		Run: func(cmd *cobra.Command, args []string) {
+			instances, err := c.aem.InstanceManager().Some()
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			dir, err := determineContentDir(cmd)
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			opts := c.aem.ContentManager().WatchOpts()
+			opts.Dir = dir
+			opts.Clean, _ = cmd.Flags().GetBool("clean")
+			if cmd.Flags().Changed("debounce") {
+				opts.Debounce, _ = cmd.Flags().GetDuration("debounce")
+			}
+			if cmd.Flags().Changed("pull-interval") {
+				opts.PullInterval, _ = cmd.Flags().GetDuration("pull-interval")
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			if err = c.aem.ContentManager().Watch(ctx, instances, opts); err != nil {
+				c.Error(err)
+				return
+			}
+			c.SetOutput("dir", dir)
+			c.Ok("content watching stopped")
+		},
+	}
+	cmd.Flags().StringP("dir", "d", "", "JCR root path")
+	_ = cmd.MarkFlagRequired("dir")
+	cmd.Flags().BoolP("clean", "c", false, "Normalize content while uploading and downloading")
+	cmd.Flags().Duration("debounce", 0, "Time to wait for more file changes before pushing them")
+	cmd.Flags().Duration("pull-interval", 0, "Interval of pulling remote changes back (0 means disabled)")
+	return cmd
+}
+
+func contentDefineSyncConflictFlag(cmd *cobra.Command) {
+	cmd.Flags().String("conflict", "", fmt.Sprintf("Strategy for files changed both locally and remotely (%s)", strings.Join(content.SyncConflictStrategies(), "|")))
+}
+
+func contentSyncConflictByFlags(cmd *cobra.Command, contentManager *pkg.ContentManager) {
+	if cmd.Flags().Changed("conflict") {
+		contentManager.SyncConflict, _ = cmd.Flags().GetString("conflict")
+	}
+}
+
+func contentDefineTransformFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("transform", false, "Transform content using rules from configuration")
+	cmd.Flags().String("transform-file", "", "Transform content using rules from YAML file")
+	cmd.MarkFlagsMutuallyExclusive("transform", "transform-file")
+}
+
+func contentTransformByFlags(cmd *cobra.Command, contentManager *pkg.ContentManager) error {
+	if transformFile, _ := cmd.Flags().GetString("transform-file"); transformFile != "" {
+		return contentManager.TransformByFile(transformFile)
+	}
+	if transform, _ := cmd.Flags().GetBool("transform"); transform {
+		return contentManager.TransformByConfig()
+	}
+	return nil
+}
+
+func contentDefineScrubFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("scrub", false, "Scrub personal data using profile from configuration")
+	cmd.Flags().String("scrub-file", "", "Scrub personal data using profile from YAML file")
+	cmd.MarkFlagsMutuallyExclusive("scrub", "scrub-file")
+	cmd.Flags().String("scrub-report", "", "File path for report of scrubbed properties and nodes (YAML or JSON)")
+}
+
+func contentScrubByFlags(cmd *cobra.Command, contentManager *pkg.ContentManager) error {
+	if scrubFile, _ := cmd.Flags().GetString("scrub-file"); scrubFile != "" {
+		return contentManager.ScrubByFile(scrubFile)
+	}
+	if scrub, _ := cmd.Flags().GetBool("scrub"); scrub {
+		return contentManager.ScrubByConfig()
+	}
+	return nil
+}
+
+func (c *CLI) contentScrubReport(cmd *cobra.Command, contentManager *pkg.ContentManager) error {
+	if contentManager.Scrubber == nil {
+		return nil
+	}
+	report := contentManager.Scrubber.Report
+	c.SetOutput("scrubbed", report)
+	if reportFile, _ := cmd.Flags().GetString("scrub-report"); reportFile != "" {
+		if err := fmtx.MarshalToFile(reportFile, report); err != nil {
+			return fmt.Errorf("cannot write content scrub report to file '%s': %w", reportFile, err)
+		}
+		c.SetOutput("scrubReport", reportFile)
+	}
+	return nil
+}
+
 func determineContentTargetInstances(cmd *cobra.Command, instanceManager *pkg.InstanceManager) ([]pkg.Instance, error) {
 	var instances []pkg.Instance
 	urls, _ := cmd.Flags().GetStringSlice("instance-target-url")
@@ -357,23 +563,5 @@ func determineFilterRoots(cmd *cobra.Command) []string {
 
 func determineFilterRootExcludes(cmd *cobra.Command) []string {
 	file, _ := determineContentFile(cmd)
-	if file == "" || !strings.HasSuffix(file, content.JCRContentFile) || content.IsPageContentFile(file) {
-		return nil
-	}
-
-	dir := filepath.Dir(file)
-	entries, err := os.ReadDir(dir)
-	if err != nil {
-		return nil
-	}
-
-	var filterRootExcludes []string
-	for _, entry := range entries {
-		if entry.Name() != content.JCRContentFile {
-			jcrPath := pkg.DetermineFilterRoot(filepath.Join(dir, entry.Name()))
-			excludePattern := fmt.Sprintf("%s(/.*)?", jcrPath)
-			filterRootExcludes = append(filterRootExcludes, excludePattern)
-		}
-	}
-	return filterRootExcludes
+	return pkg.DetermineFilterRootExcludes(file)
 }
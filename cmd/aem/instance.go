@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"github.com/spf13/cobra"
+	"github.com/wttech/aemc/pkg"
 	"github.com/wttech/aemc/pkg/common/intsx"
 )
 
@@ -22,6 +23,7 @@ func (c *CLI) instanceCmd() *cobra.Command {
 	cmd.AddCommand(c.instanceDeleteCmd())
 	cmd.AddCommand(c.instanceListCmd())
 	cmd.AddCommand(c.instanceAwaitCmd())
+	cmd.AddCommand(c.instanceDiagnoseCmd())
 	cmd.AddCommand(c.instanceBackupCmd())
 	cmd.AddCommand(c.instanceImportCmd())
 	cmd.AddCommand(c.instanceUpgradeCmd())
@@ -330,6 +332,9 @@ func (c *CLI) instanceAwaitCmd() *cobra.Command {
 			manager := c.aem.InstanceManager()
 			manager.CheckOpts.DoneNever = doneNever
 			manager.CheckOpts.DoneThreshold = doneThreshold
+			if cmd.Flags().Changed("diagnose") {
+				manager.DiagnoseOpts.OnAwaitTimeout, _ = cmd.Flags().GetBool("diagnose")
+			}
 			if err := manager.Await(instances); err != nil {
 				c.Error(err)
 				return
@@ -340,7 +345,49 @@ func (c *CLI) instanceAwaitCmd() *cobra.Command {
 	}
 	cmd.Flags().Int("done-threshold", c.config.Values().GetInt("instance.check.done_threshold"), "Number of successful checks indicating done")
 	cmd.Flags().Bool("done-never", false, "Repeat checks endlessly")
+	cmd.Flags().Bool("diagnose", false, "Collect diagnosis archive when awaiting times out")
+
+	return cmd
+}
 
+func (c *CLI) instanceDiagnoseCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "diagnose",
+		Aliases: []string{"diag"},
+		Short:   "Collects troubleshooting data of AEM instance(s) into archive",
+		Run: func(cmd *cobra.Command, args []string) {
+			instances, err := c.aem.InstanceManager().Some()
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			manager := c.aem.InstanceManager()
+			if cmd.Flags().Changed("dir") {
+				manager.DiagnoseOpts.Dir, _ = cmd.Flags().GetString("dir")
+			}
+			if cmd.Flags().Changed("log-lines") {
+				manager.DiagnoseOpts.LogLines, _ = cmd.Flags().GetInt("log-lines")
+			}
+			diagnosed, err := pkg.InstanceProcess(c.aem, instances, func(instance pkg.Instance) (map[string]any, error) {
+				diagnosis, err := manager.Diagnose(instance)
+				if err != nil {
+					return nil, err
+				}
+				return map[string]any{
+					"diagnosis":    diagnosis,
+					OutputInstance: instance,
+				}, nil
+			})
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			c.SetOutput("diagnosed", diagnosed)
+			c.Changed("instance(s) diagnosed")
+		},
+	}
+	cmd.Flags().String("dir", "", "Directory for diagnosis archives (default taken from config)")
+	cmd.Flags().Int("log-lines", 0, "Number of recent error log lines collected (default taken from config)")
 	return cmd
 }
 
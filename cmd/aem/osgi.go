@@ -2,12 +2,16 @@ package main
 
 import (
 	"fmt"
+	"github.com/samber/lo"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/wttech/aemc/pkg"
+	"github.com/wttech/aemc/pkg/common/filex"
 	"github.com/wttech/aemc/pkg/common/httpx"
 	"github.com/wttech/aemc/pkg/common/mapsx"
 	"github.com/wttech/aemc/pkg/common/pathx"
+	"github.com/wttech/aemc/pkg/maven"
+	"github.com/wttech/aemc/pkg/osgi"
 	"strings"
 )
 
@@ -19,6 +23,7 @@ func (c *CLI) osgiCmd() *cobra.Command {
 	cmd.AddCommand(c.osgiBundleCmd())
 	cmd.AddCommand(c.osgiComponentCmd())
 	cmd.AddCommand(c.osgiConfigCmd())
+	cmd.AddCommand(c.osgiEventCmd())
 
 	cmd.AddCommand(c.osgiRestartCmd())
 	return cmd
@@ -37,6 +42,8 @@ func (c *CLI) osgiBundleCmd() *cobra.Command {
 	cmd.AddCommand(c.osgiBundleStartCmd())
 	cmd.AddCommand(c.osgiBundleStopCmd())
 	cmd.AddCommand(c.osgiBundleRestartCmd())
+	cmd.AddCommand(c.osgiBundleDiagnoseCmd())
+	cmd.AddCommand(c.osgiBundleCheckCmd())
 	return cmd
 }
 
@@ -45,6 +52,10 @@ func (c *CLI) osgiBundleInstall() *cobra.Command {
 		Use:   "install",
 		Short: "Install OSGi bundle(s)",
 		Run: func(cmd *cobra.Command, args []string) {
+			if cmd.Flags().Changed("dir") {
+				c.osgiBundleInstallDir(cmd)
+				return
+			}
 			path, err := c.osgiBundlePathByFlags(cmd)
 			if err != nil {
 				c.Error(err)
@@ -95,14 +106,98 @@ func (c *CLI) osgiBundleInstall() *cobra.Command {
 		},
 	}
 	osgiBundleDefineFileAndUrlFlags(cmd)
+	cmd.Flags().String("dir", "", "Directory with JARs to be installed at once (single packages refresh, started in dependency order)")
+	cmd.MarkFlagsMutuallyExclusive("dir", "file", "url", "artifact")
 	cmd.Flags().BoolP("force", "f", false, "Install even when already installed")
 	return cmd
 }
 
+func (c *CLI) osgiBundleInstallDir(cmd *cobra.Command) {
+	dir, _ := cmd.Flags().GetString("dir")
+	if !pathx.Exists(dir) {
+		c.Fail(fmt.Sprintf("bundle dir does not exist '%s'", dir))
+		return
+	}
+	instances, err := c.aem.InstanceManager().Some()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	force, _ := cmd.Flags().GetBool("force")
+	installed, err := pkg.InstanceProcess(c.aem, instances, func(instance pkg.Instance) (map[string]any, error) {
+		batch, err := instance.OSGI().BundleManager().InstallDir(dir, force)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{
+			OutputChanged:  batch.Changed(),
+			"batch":        batch,
+			OutputInstance: instance,
+		}, nil
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if err := c.aem.InstanceManager().AwaitBundlesStable(InstancesChanged(installed)); err != nil {
+		c.Error(err)
+		return
+	}
+	c.SetOutput("installed", installed)
+	if mapsx.SomeHas(installed, OutputChanged, true) {
+		c.Changed("bundles installed")
+	} else {
+		c.Ok("bundles already installed")
+	}
+}
+
+func (c *CLI) osgiBundleCheckCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Check if OSGi bundle could be resolved before installing it",
+		Run: func(cmd *cobra.Command, args []string) {
+			path, err := c.osgiBundlePathByFlags(cmd)
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			instances, err := c.aem.InstanceManager().Some()
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			checked, err := pkg.InstanceProcess(c.aem, instances, func(instance pkg.Instance) (map[string]any, error) {
+				check, err := instance.OSGI().BundleManager().Check(path)
+				if err != nil {
+					return nil, err
+				}
+				return map[string]any{
+					"problematic": len(check.Problems()) > 0,
+					"check":       check,
+					"instance":    instance,
+				}, nil
+			})
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			c.SetOutput("checked", checked)
+			if mapsx.SomeHas(checked, "problematic", true) {
+				c.Fail("bundle check found problems")
+			} else {
+				c.Ok("bundle check passed")
+			}
+		},
+	}
+	osgiBundleDefineFileAndUrlFlags(cmd)
+	return cmd
+}
+
 func osgiBundleDefineFileAndUrlFlags(cmd *cobra.Command) {
 	cmd.Flags().String("file", "", "Local JAR path")
 	cmd.Flags().String("url", "", "URL to JAR file")
-	cmd.MarkFlagsMutuallyExclusive("file", "url")
+	cmd.Flags().String("artifact", "", "Maven artifact coordinates (groupId:artifactId:version[:classifier])")
+	cmd.MarkFlagsMutuallyExclusive("file", "url", "artifact")
 }
 
 func (c *CLI) osgiBundlePathByFlags(cmd *cobra.Command) (string, error) {
@@ -123,6 +218,17 @@ func (c *CLI) osgiBundlePathByFlags(cmd *cobra.Command) (string, error) {
 		}
 		return path, nil
 	}
+	artifact, _ := cmd.Flags().GetString("artifact")
+	if len(artifact) > 0 {
+		coords, err := maven.ParseArtifact(artifact)
+		if err != nil {
+			return "", err
+		}
+		if coords.Extension != "jar" {
+			return "", fmt.Errorf("bundle artifact does not point to JAR file but it should '%s'", artifact)
+		}
+		return c.aem.ArtifactManager().Resolve(artifact)
+	}
 	file, _ := cmd.Flags().GetString("file")
 	if len(file) > 0 {
 		fileGlobbed, err := pathx.GlobSome(file)
@@ -131,7 +237,7 @@ func (c *CLI) osgiBundlePathByFlags(cmd *cobra.Command) (string, error) {
 		}
 		return fileGlobbed, nil
 	}
-	return "", fmt.Errorf("flag 'file' or 'url' are required")
+	return "", fmt.Errorf("flag 'file', 'url' or 'artifact' are required")
 }
 
 func (c *CLI) osgiBundleUninstall() *cobra.Command {
@@ -198,6 +304,30 @@ func (c *CLI) osgiBundleListCmd() *cobra.Command {
 	return cmd
 }
 
+func (c *CLI) osgiBundleDiagnoseCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diagnose",
+		Short: "Explain why OSGi bundles are not active",
+		Run: func(cmd *cobra.Command, args []string) {
+			instance, err := c.aem.InstanceManager().One()
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			symbolicName, _ := cmd.Flags().GetString("symbolic-name")
+			report, err := instance.OSGI().BundleManager().Diagnose(symbolicName)
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			c.SetOutput("diagnosis", report)
+			c.Ok(fmt.Sprintf("bundles diagnosed (%d unstable)", len(report.Unstable())))
+		},
+	}
+	cmd.Flags().String("symbolic-name", "", "Symbolic Name (all unstable bundles if not specified)")
+	return cmd
+}
+
 func (c *CLI) osgiBundleReadCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "read",
@@ -394,6 +524,7 @@ func (c *CLI) osgiComponentCmd() *cobra.Command {
 	cmd.AddCommand(c.osgiComponentEnableCmd())
 	cmd.AddCommand(c.osgiComponentDisableCmd())
 	cmd.AddCommand(c.osgiComponentReenableCmd())
+	cmd.AddCommand(c.osgiComponentGraphCmd())
 	return cmd
 }
 
@@ -420,6 +551,63 @@ func (c *CLI) osgiComponentListCmd() *cobra.Command {
 	return cmd
 }
 
+func (c *CLI) osgiComponentGraphCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "graph",
+		Short: "Export OSGi component reference graph",
+		Run: func(cmd *cobra.Command, args []string) {
+			instance, err := c.aem.InstanceManager().One()
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			patterns, _ := cmd.Flags().GetStringSlice("pid-pattern")
+			unsatisfiedOnly, _ := cmd.Flags().GetBool("unsatisfied")
+			targetFile, _ := cmd.Flags().GetString("target-file")
+			format, _ := cmd.Flags().GetString("format")
+			if format == "" && targetFile != "" {
+				format = map[string]string{"dot": osgi.ComponentGraphFormatDot, "gv": osgi.ComponentGraphFormatDot, "mmd": osgi.ComponentGraphFormatMermaid}[pathx.Ext(targetFile)]
+				format = lo.CoalesceOrEmpty(format, osgi.ComponentGraphFormatJSON)
+			}
+			if format != "" && !lo.Contains(osgi.ComponentGraphFormats(), format) {
+				c.Fail(fmt.Sprintf("unsupported graph format '%s' (supported: %s)", format, strings.Join(osgi.ComponentGraphFormats(), ", ")))
+				return
+			}
+			graph, err := instance.OSGI().ComponentManager().Graph(patterns, unsatisfiedOnly)
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			if format == "" {
+				c.SetOutput("graph", graph)
+				c.Ok("component graph exported")
+				return
+			}
+			rendered, err := graph.Render(format)
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			if targetFile != "" {
+				if err = filex.WriteString(targetFile, rendered); err != nil {
+					c.Error(err)
+					return
+				}
+				c.SetOutput("file", targetFile)
+				c.SetOutput("unsatisfied", graph.Unsatisfied())
+			} else {
+				c.SetOutput("graph", rendered)
+			}
+			c.Ok("component graph exported")
+		},
+	}
+	cmd.Flags().StringSlice("pid-pattern", []string{}, "Component PID patterns (e.g. 'com.acme.*')")
+	cmd.Flags().Bool("unsatisfied", false, "Graph unsatisfied components only")
+	cmd.Flags().StringP("format", "f", "", "Graph format (dot|mermaid|json); by default determined by target file extension")
+	cmd.Flags().StringP("target-file", "t", "", "Target file path (by default graph is printed as command output)")
+	return cmd
+}
+
 func (c *CLI) osgiComponentReadCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "read",
@@ -583,6 +771,9 @@ func (c *CLI) osgiConfigCmd() *cobra.Command {
 	cmd.AddCommand(c.osgiConfigRead())
 	cmd.AddCommand(c.osgiConfigSave())
 	cmd.AddCommand(c.osgiConfigDelete())
+	cmd.AddCommand(c.osgiConfigExport())
+	cmd.AddCommand(c.osgiConfigDrift())
+	cmd.AddCommand(c.osgiConfigValidate())
 	return cmd
 }
 
@@ -677,6 +868,118 @@ func (c *CLI) osgiConfigSave() *cobra.Command {
 	return cmd
 }
 
+func (c *CLI) osgiConfigExport() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export OSGi configurations to '.cfg.json' files",
+		Run: func(cmd *cobra.Command, args []string) {
+			instance, err := c.aem.InstanceManager().One()
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			pidPatterns, _ := cmd.Flags().GetStringSlice("pid-pattern")
+			dir, _ := cmd.Flags().GetString("dir")
+			defaultsSkipped, _ := cmd.Flags().GetBool("defaults-skipped")
+			exported, err := instance.OSGI().ConfigManager().Export(pidPatterns, dir, defaultsSkipped)
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			c.SetOutput("exported", exported)
+			if len(exported.Changed()) > 0 {
+				c.Changed(fmt.Sprintf("configs exported (%d)", len(exported.Changed())))
+			} else {
+				c.Ok("configs already exported (up-to-date)")
+			}
+		},
+	}
+	cmd.Flags().StringSlice("pid-pattern", []string{}, "PID patterns (e.g. 'com.acme.*')")
+	_ = cmd.MarkFlagRequired("pid-pattern")
+	cmd.Flags().String("dir", "", "Target directory (e.g. 'ui.config/src/main/content/jcr_root/apps/acme/osgiconfig/config.author')")
+	_ = cmd.MarkFlagRequired("dir")
+	cmd.Flags().Bool("defaults-skipped", false, "Skip properties not set explicitly (metatype default values)")
+	return cmd
+}
+
+func (c *CLI) osgiConfigDrift() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "drift",
+		Short: "Compare OSGi configurations with '.cfg.json' files applicable for instance run modes",
+		Run: func(cmd *cobra.Command, args []string) {
+			instances, err := c.aem.InstanceManager().Some()
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			dir, _ := cmd.Flags().GetString("dir")
+			checked, err := pkg.InstanceProcess(c.aem, instances, func(instance pkg.Instance) (map[string]any, error) {
+				report, err := instance.OSGI().ConfigManager().Drift(dir)
+				if err != nil {
+					return nil, err
+				}
+				return map[string]any{
+					"drifted":  len(report.Drifted()) > 0,
+					"report":   report,
+					"instance": instance,
+				}, nil
+			})
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			c.SetOutput("checked", checked)
+			if mapsx.SomeHas(checked, "drifted", true) {
+				c.Fail("configs drifted from files")
+			} else {
+				c.Ok("configs not drifted from files")
+			}
+		},
+	}
+	cmd.Flags().String("dir", "", "Directory with config files (e.g. 'ui.config')")
+	_ = cmd.MarkFlagRequired("dir")
+	return cmd
+}
+
+func (c *CLI) osgiConfigValidate() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Validate '.cfg.json' files against component metatypes available on instance",
+		Run: func(cmd *cobra.Command, args []string) {
+			instances, err := c.aem.InstanceManager().Some()
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			dir, _ := cmd.Flags().GetString("dir")
+			validated, err := pkg.InstanceProcess(c.aem, instances, func(instance pkg.Instance) (map[string]any, error) {
+				report, err := instance.OSGI().ConfigManager().ValidateDir(dir)
+				if err != nil {
+					return nil, err
+				}
+				return map[string]any{
+					"invalid":  len(report.Invalid()) > 0,
+					"report":   report,
+					"instance": instance,
+				}, nil
+			})
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			c.SetOutput("validated", validated)
+			if mapsx.SomeHas(validated, "invalid", true) {
+				c.Fail("configs invalid")
+			} else {
+				c.Ok("configs valid")
+			}
+		},
+	}
+	cmd.Flags().String("dir", "", "Directory with config files (e.g. 'ui.config')")
+	_ = cmd.MarkFlagRequired("dir")
+	return cmd
+}
+
 func (c *CLI) osgiConfigDelete() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "delete",
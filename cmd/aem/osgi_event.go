@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/wttech/aemc/pkg/osgi"
+)
+
+func (c *CLI) osgiEventCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "event",
+		Aliases: []string{"evt"},
+		Short:   "Inspect OSGi events",
+	}
+	cmd.AddCommand(c.osgiEventList())
+	cmd.AddCommand(c.osgiEventWatch())
+	return cmd
+}
+
+func (c *CLI) osgiEventList() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Short:   "List OSGi events",
+		Aliases: []string{"ls"},
+		Run: func(cmd *cobra.Command, args []string) {
+			instance, err := c.aem.InstanceManager().One()
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			detailsIgnored, _ := cmd.Flags().GetBool("details-ignored")
+			events, err := instance.OSGI().EventManager().Find(osgiEventFilterByFlags(cmd), detailsIgnored)
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			c.SetOutput("events", events)
+			c.Ok(fmt.Sprintf("events listed (%d)", len(events.List)))
+		},
+	}
+	osgiEventDefineFlags(cmd)
+	cmd.Flags().Duration("until", 0, "Include only events received earlier than given time before now")
+	return cmd
+}
+
+func (c *CLI) osgiEventWatch() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "watch",
+		Short:   "Watch OSGi events (print only new ones)",
+		Aliases: []string{"w", "tail"},
+		Run: func(cmd *cobra.Command, args []string) {
+			instance, err := c.aem.InstanceManager().One()
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			eventManager := instance.OSGI().EventManager()
+			if cmd.Flags().Changed("interval") {
+				eventManager.WatchInterval, _ = cmd.Flags().GetDuration("interval")
+				if eventManager.WatchInterval <= 0 {
+					c.Fail(fmt.Sprintf("events watch interval '%s' should be positive", eventManager.WatchInterval))
+					return
+				}
+			}
+			detailsIgnored, _ := cmd.Flags().GetBool("details-ignored")
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			count := 0
+			err = eventManager.Watch(ctx, osgiEventFilterByFlags(cmd), detailsIgnored, func(e osgi.Event) {
+				count++
+				log.Infof("%s > event '%s' at %s: %s (bundle '%s')", instance.IDColor(), e.Topic, instance.Time(e.Received).Format(time.DateTime), e.Details(), e.Bundle())
+			})
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			c.SetOutput("count", count)
+			c.Ok("events watching stopped")
+		},
+	}
+	osgiEventDefineFlags(cmd)
+	cmd.Flags().Duration("interval", 0, "Interval of polling events (default taken from config)")
+	return cmd
+}
+
+func osgiEventDefineFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSlice("topic", []string{}, "Topic pattern(s) (e.g. 'org/osgi/framework/ServiceEvent/*')")
+	cmd.Flags().StringSlice("bundle", []string{}, "Bundle symbolic name pattern(s)")
+	cmd.Flags().StringSlice("service", []string{}, "Service (object class) pattern(s)")
+	cmd.Flags().Duration("since", 0, "Include only events received within given time before now (e.g. '10m')")
+	cmd.Flags().Bool("details-ignored", false, "Skip events with details ignored by event stability check")
+}
+
+func osgiEventFilterByFlags(cmd *cobra.Command) osgi.EventFilter {
+	filter := osgi.EventFilter{}
+	filter.Topics, _ = cmd.Flags().GetStringSlice("topic")
+	filter.Bundles, _ = cmd.Flags().GetStringSlice("bundle")
+	filter.Services, _ = cmd.Flags().GetStringSlice("service")
+	now := time.Now()
+	if since, _ := cmd.Flags().GetDuration("since"); since > 0 {
+		filter.Since = now.Add(-since)
+	}
+	if cmd.Flags().Lookup("until") == nil {
+		return filter
+	}
+	if until, _ := cmd.Flags().GetDuration("until"); until > 0 {
+		filter.Until = now.Add(-until)
+	}
+	return filter
+}
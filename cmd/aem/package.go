@@ -2,12 +2,16 @@ package main
 
 import (
 	"fmt"
+	"github.com/samber/lo"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/wttech/aemc/pkg"
 	"github.com/wttech/aemc/pkg/common/httpx"
 	"github.com/wttech/aemc/pkg/common/mapsx"
 	"github.com/wttech/aemc/pkg/common/pathx"
+	"github.com/wttech/aemc/pkg/maven"
+	pkgx "github.com/wttech/aemc/pkg/pkg"
+	"github.com/wttech/aemc/pkg/repo"
 	"strings"
 )
 
@@ -30,6 +34,7 @@ func (c *CLI) pkgCmd() *cobra.Command {
 	cmd.AddCommand(c.pkgBuildCmd())
 	cmd.AddCommand(c.pkgFindCmd())
 	cmd.AddCommand(c.pkgCopyCmd())
+	cmd.AddCommand(c.pkgSyncCmd())
 	return cmd
 }
 
@@ -344,13 +349,17 @@ func (c *CLI) pkgDeleteCmd() *cobra.Command {
 func (c *CLI) pkgPurgeCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "purge",
-		Short: "Purge package (uninstall and delete)",
+		Short: "Purge package (uninstall and delete) or packages matching purge policy",
 		Run: func(cmd *cobra.Command, args []string) {
 			instances, err := c.aem.InstanceManager().Some()
 			if err != nil {
 				c.Error(err)
 				return
 			}
+			if policy, _ := cmd.Flags().GetBool("policy"); policy {
+				c.pkgPurgePolicy(cmd, instances)
+				return
+			}
 			purged, err := pkg.InstanceProcess(c.aem, instances, func(instance pkg.Instance) (map[string]any, error) {
 				p, err := pkgByFlags(cmd, instance)
 				if err != nil {
@@ -400,9 +409,64 @@ func (c *CLI) pkgPurgeCmd() *cobra.Command {
 		},
 	}
 	pkgDefineFlags(cmd)
+	cmd.Flags().Bool("policy", false, "Purge packages matching purge policy instead of particular one")
+	cmd.Flags().StringSliceP("pattern", "p", []string{}, "Package ID (group:name:version) patterns considered by purge policy")
+	cmd.Flags().StringSlice("protected", []string{}, "Package ID (group:name:version) patterns never purged by policy")
+	cmd.Flags().Int("keep-last", 0, "Number of latest versions kept per package group and name (newest one is always kept)")
+	cmd.Flags().Int("older-than-days", 0, "Purge only packages not touched for given number of days")
+	cmd.Flags().Bool("dry-run", false, "Only list packages to be purged by policy")
+	cmd.MarkFlagsMutuallyExclusive("pid", "file", "path", "policy")
 	return cmd
 }
 
+func (c *CLI) pkgPurgePolicy(cmd *cobra.Command, instances []pkg.Instance) {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	purged, err := pkg.InstanceProcess(c.aem, instances, func(instance pkg.Instance) (map[string]any, error) {
+		opts := instance.PackageManager().PurgeOpts()
+		opts.Patterns, _ = cmd.Flags().GetStringSlice("pattern")
+		if cmd.Flags().Changed("protected") {
+			opts.ProtectedPatterns, _ = cmd.Flags().GetStringSlice("protected")
+		}
+		if cmd.Flags().Changed("keep-last") {
+			opts.KeepLast, _ = cmd.Flags().GetInt("keep-last")
+		}
+		if cmd.Flags().Changed("older-than-days") {
+			opts.OlderThanDays, _ = cmd.Flags().GetInt("older-than-days")
+		}
+		var items []pkg.PackagePurgeItem
+		var err error
+		if dryRun {
+			items, err = instance.PackageManager().PurgePlan(opts)
+		} else {
+			items, err = instance.PackageManager().Purge(opts)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{
+			OutputChanged: !dryRun && len(items) > 0,
+			"packages":    items,
+			"instance":    instance,
+		}, nil
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if err := c.aem.InstanceManager().AwaitStarted(InstancesChanged(purged)); err != nil {
+		c.Error(err)
+		return
+	}
+	c.SetOutput("purged", purged)
+	if dryRun {
+		c.Ok("packages to be purged listed (dry run)")
+	} else if mapsx.SomeHas(purged, OutputChanged, true) {
+		c.Changed("packages purged")
+	} else {
+		c.Ok("no packages to purge")
+	}
+}
+
 func (c *CLI) pkgBuildCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "build",
@@ -493,7 +557,8 @@ func pkgPIDByFlags(cmd *cobra.Command, instance pkg.Instance) (*pkg.Package, err
 func pkgDefineFileAndUrlFlags(cmd *cobra.Command) {
 	cmd.Flags().String("file", "", "Local ZIP path")
 	cmd.Flags().String("url", "", "URL to ZIP file")
-	cmd.MarkFlagsMutuallyExclusive("file", "url")
+	cmd.Flags().String("artifact", "", "Maven artifact coordinates (groupId:artifactId:version[:classifier]@zip)")
+	cmd.MarkFlagsMutuallyExclusive("file", "url", "artifact")
 }
 
 func (c *CLI) pkgPathByFlags(cmd *cobra.Command) (string, error) {
@@ -513,6 +578,17 @@ func (c *CLI) pkgPathByFlags(cmd *cobra.Command) (string, error) {
 		}
 		return path, nil
 	}
+	artifact, _ := cmd.Flags().GetString("artifact")
+	if len(artifact) > 0 {
+		coords, err := maven.ParseArtifact(artifact)
+		if err != nil {
+			return "", err
+		}
+		if coords.Extension != "zip" {
+			return "", fmt.Errorf("package artifact does not point to ZIP file but it should '%s' (use '@zip' suffix)", artifact)
+		}
+		return c.aem.ArtifactManager().Resolve(artifact)
+	}
 	file, _ := cmd.Flags().GetString("file")
 	if len(file) > 0 {
 		fileGlobbed, err := pathx.GlobSome(file)
@@ -521,7 +597,7 @@ func (c *CLI) pkgPathByFlags(cmd *cobra.Command) (string, error) {
 		}
 		return fileGlobbed, nil
 	}
-	return "", fmt.Errorf("flag 'file' or 'url' are required")
+	return "", fmt.Errorf("flag 'file', 'url' or 'artifact' are required")
 }
 
 func (c *CLI) pkgCreateCmd() *cobra.Command {
@@ -541,19 +617,31 @@ func (c *CLI) pkgCreateCmd() *cobra.Command {
 			}
 			filterRoots, _ := cmd.Flags().GetStringSlice("filter-roots")
 			filterFile, _ := cmd.Flags().GetString("filter-file")
+			filterMode, _ := cmd.Flags().GetString("filter-mode")
+			if filterMode != "" && !lo.Contains(pkgx.FilterModes(), filterMode) {
+				c.Fail(fmt.Sprintf("filter mode '%s' is not one of '%s'", filterMode, strings.Join(pkgx.FilterModes(), "|")))
+				return
+			}
+			filterQuery, _ := cmd.Flags().GetString("filter-query")
+			filterQueryLanguage, _ := cmd.Flags().GetString("filter-query-language")
+			filterPathsFile, _ := cmd.Flags().GetString("filter-paths-file")
+			filterDescendants, _ := cmd.Flags().GetBool("filter-descendants")
+			opts := pkg.PackageCreateOpts{
+				FilterRoots:         filterRoots,
+				FilterFile:          filterFile,
+				FilterMode:          filterMode,
+				FilterQuery:         filterQuery,
+				FilterQueryLanguage: filterQueryLanguage,
+				FilterPathsFile:     filterPathsFile,
+				FilterDescendants:   filterDescendants,
+			}
 			force, _ := cmd.Flags().GetBool("force")
 			changed := false
 			if force {
-				err = p.Create(pkg.PackageCreateOpts{
-					FilterRoots: filterRoots,
-					FilterFile:  filterFile,
-				})
+				err = p.Create(opts)
 				changed = true
 			} else {
-				changed, err = p.CreateWithChanged(pkg.PackageCreateOpts{
-					FilterRoots: filterRoots,
-					FilterFile:  filterFile,
-				})
+				changed, err = p.CreateWithChanged(opts)
 			}
 			if err != nil {
 				c.Error(err)
@@ -575,7 +663,13 @@ func (c *CLI) pkgCreateCmd() *cobra.Command {
 	cmd.Flags().String("pid", "", "ID (group:name:version)'")
 	cmd.Flags().StringSliceP("filter-roots", "k", []string{}, "Vault filter root paths")
 	cmd.Flags().StringP("filter-file", "l", "", "Vault filter file path")
-	cmd.MarkFlagsMutuallyExclusive("filter-roots", "filter-file")
+	cmd.Flags().StringP("filter-query", "q", "", "Query finding nodes to be included as exact filters (QueryBuilder 'key=value' predicates separated by '&' or JCR-SQL2/XPath statement)")
+	cmd.Flags().String("filter-query-language", repo.QueryLanguageQueryBuilder, "Query language (querybuilder|JCR-SQL2|xpath); JCR-SQL2 and XPath require CRXDE")
+	cmd.Flags().String("filter-paths-file", "", "Text file with node paths (one per line) to be included as exact filters")
+	cmd.Flags().Bool("filter-descendants", false, "Include all descendants of queried/listed nodes (by default only node and its 'jcr:content' are included which suits pages)")
+	cmd.MarkFlagsMutuallyExclusive("filter-roots", "filter-file", "filter-query")
+	cmd.MarkFlagsMutuallyExclusive("filter-roots", "filter-file", "filter-paths-file")
+	cmd.Flags().StringP("filter-mode", "m", "", fmt.Sprintf("Filter mode (%s)", strings.Join(pkgx.FilterModes(), "|")))
 	cmd.Flags().BoolP("force", "f", false, "Create even when already created")
 	_ = cmd.MarkFlagRequired("pid")
 	return cmd
@@ -719,6 +813,87 @@ func (c *CLI) pkgCopyCmd() *cobra.Command {
 	return cmd
 }
 
+func (c *CLI) pkgSyncCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Synchronize packages from source instance",
+		Run: func(cmd *cobra.Command, args []string) {
+			sourceInstance, err := determineSourceInstance(cmd, c.aem.InstanceManager())
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			instances, err := c.aem.InstanceManager().Some()
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			targetInstances := lo.Filter(instances, func(i pkg.Instance, _ int) bool {
+				return i.ID() != sourceInstance.ID() && strings.TrimSuffix(i.HTTP().BaseURL(), "/") != strings.TrimSuffix(sourceInstance.HTTP().BaseURL(), "/")
+			})
+			if len(targetInstances) == 0 {
+				c.Error(fmt.Errorf("no target instances to sync packages from instance '%s'", sourceInstance.ID()))
+				return
+			}
+			patterns, _ := cmd.Flags().GetStringSlice("pattern")
+			install, _ := cmd.Flags().GetBool("install")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			opts := pkg.PackageSyncOpts{Patterns: patterns, Install: install}
+			synced, err := pkg.InstanceProcess(c.aem, targetInstances, func(instance pkg.Instance) (map[string]any, error) {
+				var items []pkg.PackageSyncItem
+				var err error
+				if dryRun {
+					items, err = instance.PackageManager().SyncPlan(sourceInstance, opts)
+				} else {
+					items, err = instance.PackageManager().Sync(sourceInstance, opts)
+				}
+				if err != nil {
+					return nil, err
+				}
+				return map[string]any{
+					OutputChanged: !dryRun && len(items) > 0,
+					"packages":    items,
+					"instance":    instance,
+				}, nil
+			})
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			if err := c.aem.InstanceManager().AwaitStarted(InstancesChanged(synced)); err != nil {
+				c.Error(err)
+				return
+			}
+			c.SetOutput("sourceInstance", sourceInstance)
+			c.SetOutput("synced", synced)
+			if dryRun {
+				c.Ok("packages to be synced listed (dry run)")
+			} else if mapsx.SomeHas(synced, OutputChanged, true) {
+				c.Changed("packages synced")
+			} else {
+				c.Ok("packages already synced (up-to-date)")
+			}
+		},
+	}
+	cmd.Flags().StringP("source-instance", "s", "", "Source instance ID or URL")
+	_ = cmd.MarkFlagRequired("source-instance")
+	cmd.Flags().StringSliceP("pattern", "p", []string{}, "Package ID (group:name:version) patterns")
+	cmd.Flags().Bool("install", false, "Install packages after copying")
+	cmd.Flags().Bool("dry-run", false, "Only list packages to be synced")
+	return cmd
+}
+
+func determineSourceInstance(cmd *cobra.Command, instanceManager *pkg.InstanceManager) (*pkg.Instance, error) {
+	value, _ := cmd.Flags().GetString("source-instance")
+	if value == "" {
+		return nil, fmt.Errorf("missing 'source-instance'")
+	}
+	if strings.Contains(value, "://") {
+		return instanceManager.NewByIDAndURL("remote_adhoc_source", value)
+	}
+	return instanceManager.NewByID(value), nil
+}
+
 func determineTargetInstance(cmd *cobra.Command, instanceManager *pkg.InstanceManager) (*pkg.Instance, error) {
 	var instance *pkg.Instance
 	url, _ := cmd.Flags().GetString("instance-target-url")
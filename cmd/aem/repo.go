@@ -2,9 +2,15 @@ package main
 
 import (
 	"fmt"
+	"github.com/samber/lo"
 	"github.com/spf13/cobra"
 	"github.com/wttech/aemc/pkg"
+	"github.com/wttech/aemc/pkg/common/fmtx"
 	"github.com/wttech/aemc/pkg/common/mapsx"
+	"github.com/wttech/aemc/pkg/common/pathx"
+	"github.com/wttech/aemc/pkg/repo"
+	"regexp"
+	"strings"
 )
 
 func (c *CLI) repoCmd() *cobra.Command {
@@ -14,6 +20,11 @@ func (c *CLI) repoCmd() *cobra.Command {
 		Aliases: []string{"repo"},
 	}
 	cmd.AddCommand(c.repoNodeCmd())
+	cmd.AddCommand(c.repoExportCmd())
+	cmd.AddCommand(c.repoImportCmd())
+	cmd.AddCommand(c.repoQueryCmd())
+	cmd.AddCommand(c.repoBulkCmd())
+	cmd.AddCommand(c.repoFileCmd())
 
 	return cmd
 }
@@ -300,6 +311,402 @@ func (c *CLI) repoNodeDownloadCmd() *cobra.Command {
 	return cmd
 }
 
+func (c *CLI) repoFileCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "file",
+		Short: "Transfer binary files (nt:file nodes and DAM assets)",
+	}
+	cmd.AddCommand(c.repoFileUploadCmd())
+	cmd.AddCommand(c.repoNodeDownloadCmd())
+
+	return cmd
+}
+
+func (c *CLI) repoFileUploadCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "upload",
+		Short:   "Upload file or directory",
+		Aliases: []string{"up"},
+		Run: func(cmd *cobra.Command, args []string) {
+			instances, err := c.aem.InstanceManager().Some()
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			localPath, _ := cmd.Flags().GetString("local")
+			path, _ := cmd.Flags().GetString("path")
+			force, _ := cmd.Flags().GetBool("force")
+			dir, err := pathx.IsDirStrict(localPath)
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			uploaded, err := pkg.InstanceProcess(c.aem, instances, func(instance pkg.Instance) (map[string]any, error) {
+				var files *repo.FileUploadList
+				if dir {
+					files, err = instance.Repo().UploadDir(localPath, path, force)
+				} else {
+					var file *repo.FileUpload
+					file, err = instance.Repo().UploadFile(localPath, path, force)
+					if file != nil {
+						files = &repo.FileUploadList{Files: []repo.FileUpload{*file}}
+					}
+				}
+				if err != nil {
+					return nil, err
+				}
+				return map[string]any{
+					OutputChanged: len(files.Changed()) > 0,
+					"files":       files,
+					"instance":    instance,
+				}, nil
+			})
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			c.SetOutput("uploaded", uploaded)
+			if mapsx.SomeHas(uploaded, OutputChanged, true) {
+				c.Changed("files uploaded")
+			} else {
+				c.Ok("files not uploaded (up-to-date)")
+			}
+		},
+	}
+	cmd.Flags().StringP("local", "l", "", "Local file or directory")
+	_ = cmd.MarkFlagRequired("local")
+	cmd.Flags().String("path", "", "Target node path (e.g. '/content/dam/site/doc.pdf' or '/content/dam/site' for directory)")
+	_ = cmd.MarkFlagRequired("path")
+	cmd.Flags().BoolP("force", "f", false, "Upload even when file is unchanged")
+	return cmd
+}
+
+func (c *CLI) repoExportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "export",
+		Short:   "Export node tree to JSON or YAML (binary properties are not supported)",
+		Aliases: []string{"exp"},
+		Run: func(cmd *cobra.Command, args []string) {
+			instance, err := c.aem.InstanceManager().One()
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			path, _ := cmd.Flags().GetString("path")
+			depth, _ := cmd.Flags().GetInt("depth")
+			targetFile, _ := cmd.Flags().GetString("target-file")
+			format, _ := cmd.Flags().GetString("format")
+			format = strings.ReplaceAll(format, "yaml", fmtx.YML)
+			if format == "" {
+				format = lo.Ternary(targetFile != "" && lo.Contains([]string{"yml", "yaml"}, pathx.Ext(targetFile)), fmtx.YML, fmtx.JSON)
+			}
+			if !lo.Contains([]string{fmtx.JSON, fmtx.YML}, format) {
+				c.Fail(fmt.Sprintf("unsupported export format '%s' (supported: %s, %s)", format, fmtx.JSON, fmtx.YML))
+				return
+			}
+			node, err := instance.Repo().Export(path, depth)
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			if targetFile != "" {
+				if err = fmtx.MarshalToFileInFormat(format, targetFile, node); err != nil {
+					c.Error(err)
+					return
+				}
+				c.SetOutput("file", targetFile)
+				c.SetOutput("nodes", node.Count())
+			} else {
+				c.SetOutput("tree", node)
+			}
+			c.Ok("node tree exported")
+		},
+	}
+	repoNodeDefineFlags(cmd)
+	cmd.Flags().IntP("depth", "d", -1, "Depth of exported tree (-1 means unlimited)")
+	cmd.Flags().StringP("format", "f", "", "Format of exported tree (json|yaml); by default determined by target file extension")
+	cmd.Flags().StringP("target-file", "t", "", "Target file path (by default tree is printed as command output)")
+	return cmd
+}
+
+func (c *CLI) repoImportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "import",
+		Short:   "Import node tree from JSON or YAML",
+		Aliases: []string{"imp"},
+		Run: func(cmd *cobra.Command, args []string) {
+			instances, err := c.aem.InstanceManager().Some()
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			path, _ := cmd.Flags().GetString("path")
+			sourceFile, _ := cmd.Flags().GetString("source-file")
+			replace, _ := cmd.Flags().GetBool("replace")
+			node, err := repo.ReadNodeFile(sourceFile)
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			imported, err := pkg.InstanceProcess(c.aem, instances, func(instance pkg.Instance) (map[string]any, error) {
+				if err := instance.Repo().Import(path, node, replace); err != nil {
+					return nil, err
+				}
+				return map[string]any{
+					OutputChanged: true,
+					"path":        path,
+					"nodes":       node.Count(),
+					"instance":    instance,
+				}, nil
+			})
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			c.SetOutput("imported", imported)
+			c.Changed("node tree imported")
+		},
+	}
+	repoNodeDefineFlags(cmd)
+	cmd.Flags().StringP("source-file", "s", "", "Source file path (JSON or YAML)")
+	_ = cmd.MarkFlagRequired("source-file")
+	cmd.Flags().BoolP("replace", "r", false, "Replace existing nodes and properties")
+	return cmd
+}
+
+func (c *CLI) repoQueryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "query",
+		Short:   "Find nodes using JCR-SQL2, XPath or QueryBuilder",
+		Aliases: []string{"find"},
+		Run: func(cmd *cobra.Command, args []string) {
+			instance, err := c.aem.InstanceManager().One()
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			query := repoQueryByFlags(cmd)
+			if explain, _ := cmd.Flags().GetBool("explain"); explain {
+				explanation, err := instance.Repo().QueryExplain(query.Language, query.Statement)
+				if err != nil {
+					c.Error(err)
+					return
+				}
+				c.SetOutput("explanation", explanation)
+				c.Ok("query explained")
+				return
+			}
+			hits, err := instance.Repo().Query(query)
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			c.SetOutput("query", hits)
+			c.Ok(fmt.Sprintf("nodes found (%d)", len(hits.Hits)))
+		},
+	}
+	repoQueryDefineFlags(cmd)
+	cmd.MarkFlagsOneRequired("sql2", "xpath", "querybuilder")
+	cmd.Flags().Bool("explain", false, "Explain query plan (e.g. index used) instead of executing it")
+	return cmd
+}
+
+func repoQueryDefineFlags(cmd *cobra.Command) {
+	cmd.Flags().String("sql2", "", "JCR-SQL2 statement (requires CRXDE)")
+	cmd.Flags().String("xpath", "", "XPath statement (requires CRXDE)")
+	cmd.Flags().StringArray("querybuilder", []string{}, "QueryBuilder predicates (key=value)")
+	cmd.MarkFlagsMutuallyExclusive("sql2", "xpath", "querybuilder")
+	cmd.Flags().StringSlice("property", []string{}, "Properties to be read from found nodes (also relative like 'jcr:content/jcr:title')")
+	cmd.Flags().Int("offset", 0, "Number of results to skip")
+	cmd.Flags().Int("limit", 0, "Maximum number of results (0 means unlimited)")
+}
+
+func repoQueryByFlags(cmd *cobra.Command) repo.Query {
+	result := repo.Query{}
+	if sql2, _ := cmd.Flags().GetString("sql2"); sql2 != "" {
+		result.Language, result.Statement = repo.QueryLanguageSQL2, sql2
+	} else if xpath, _ := cmd.Flags().GetString("xpath"); xpath != "" {
+		result.Language, result.Statement = repo.QueryLanguageXPath, xpath
+	} else {
+		predicates, _ := cmd.Flags().GetStringArray("querybuilder")
+		result.Language, result.Statement = repo.QueryLanguageQueryBuilder, strings.Join(predicates, "\n")
+	}
+	result.Properties, _ = cmd.Flags().GetStringSlice("property")
+	result.Offset, _ = cmd.Flags().GetInt("offset")
+	result.Limit, _ = cmd.Flags().GetInt("limit")
+	return result
+}
+
+func (c *CLI) repoBulkCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bulk",
+		Short: "Apply operation to many nodes found by query or listed in file",
+	}
+	cmd.AddCommand(c.repoBulkSaveCmd())
+	cmd.AddCommand(c.repoBulkPropDeleteCmd())
+	cmd.AddCommand(c.repoBulkMixinAddCmd())
+	cmd.AddCommand(c.repoBulkDeleteCmd())
+	cmd.AddCommand(c.repoBulkMoveCmd())
+
+	return cmd
+}
+
+func (c *CLI) repoBulkSaveCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "save",
+		Short:   "Create or update properties of nodes",
+		Aliases: []string{"update"},
+		Run: func(cmd *cobra.Command, args []string) {
+			var props map[string]any
+			if err := c.ReadInput(&props); err != nil {
+				c.Fail(fmt.Sprintf("cannot save nodes as input props cannot be parsed: %s", err))
+				return
+			}
+			c.repoBulkRun(cmd, repo.Bulk{Operation: repo.BulkOperationSave, Properties: props})
+		},
+	}
+	repoBulkDefineFlags(cmd)
+	return cmd
+}
+
+func (c *CLI) repoBulkPropDeleteCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "prop-delete",
+		Short: "Delete properties of nodes",
+		Run: func(cmd *cobra.Command, args []string) {
+			names, _ := cmd.Flags().GetStringSlice("name")
+			c.repoBulkRun(cmd, repo.Bulk{Operation: repo.BulkOperationPropDelete, PropNames: names})
+		},
+	}
+	repoBulkDefineFlags(cmd)
+	cmd.Flags().StringSlice("name", []string{}, "Property names")
+	_ = cmd.MarkFlagRequired("name")
+	return cmd
+}
+
+func (c *CLI) repoBulkMixinAddCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mixin-add",
+		Short: "Add mixin types to nodes",
+		Run: func(cmd *cobra.Command, args []string) {
+			mixins, _ := cmd.Flags().GetStringSlice("mixin")
+			c.repoBulkRun(cmd, repo.Bulk{Operation: repo.BulkOperationMixinAdd, Mixins: mixins})
+		},
+	}
+	repoBulkDefineFlags(cmd)
+	cmd.Flags().StringSlice("mixin", []string{}, "Mixin types (e.g. 'mix:versionable')")
+	_ = cmd.MarkFlagRequired("mixin")
+	return cmd
+}
+
+func (c *CLI) repoBulkDeleteCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "delete",
+		Short:   "Delete nodes",
+		Aliases: []string{"del", "rm", "remove"},
+		Run: func(cmd *cobra.Command, args []string) {
+			c.repoBulkRun(cmd, repo.Bulk{Operation: repo.BulkOperationDelete})
+		},
+	}
+	repoBulkDefineFlags(cmd)
+	return cmd
+}
+
+func (c *CLI) repoBulkMoveCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "move",
+		Short:   "Move nodes to paths determined by pattern",
+		Aliases: []string{"mv", "rename"},
+		Run: func(cmd *cobra.Command, args []string) {
+			pattern, _ := cmd.Flags().GetString("pattern")
+			patternRegex, err := regexp.Compile(pattern)
+			if err != nil {
+				c.Fail(fmt.Sprintf("cannot move nodes as pattern '%s' is invalid: %s", pattern, err))
+				return
+			}
+			replacement, _ := cmd.Flags().GetString("replacement")
+			replace, _ := cmd.Flags().GetBool("replace")
+			c.repoBulkRun(cmd, repo.Bulk{Operation: repo.BulkOperationMove, MovePattern: patternRegex, MoveReplacement: replacement, MoveReplace: replace})
+		},
+	}
+	repoBulkDefineFlags(cmd)
+	cmd.Flags().String("pattern", "", "Regular expression matching source node path (e.g. '^/content/site/old/(.*)$')")
+	_ = cmd.MarkFlagRequired("pattern")
+	cmd.Flags().String("replacement", "", "Target node path with pattern groups (e.g. '/content/site/new/$1')")
+	_ = cmd.MarkFlagRequired("replacement")
+	cmd.Flags().BoolP("replace", "r", false, "Replace target nodes if they already exist")
+	return cmd
+}
+
+func repoBulkDefineFlags(cmd *cobra.Command) {
+	repoQueryDefineFlags(cmd)
+	cmd.Flags().String("paths-file", "", "File with node paths (one per line)")
+	cmd.MarkFlagsOneRequired("sql2", "xpath", "querybuilder", "paths-file")
+	cmd.MarkFlagsMutuallyExclusive("sql2", "xpath", "querybuilder", "paths-file")
+	cmd.Flags().Int("parallelism", 0, "Number of nodes processed at once (0 means configured value)")
+	cmd.Flags().Int("batch-size", 0, "Number of nodes processed in one batch (0 means configured value)")
+	cmd.Flags().Bool("dry-run", false, "Only preview nodes to be changed")
+}
+
+func (c *CLI) repoBulkRun(cmd *cobra.Command, bulk repo.Bulk) {
+	instances, err := c.aem.InstanceManager().Some()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	bulk.DryRun, _ = cmd.Flags().GetBool("dry-run")
+	processed, err := pkg.InstanceProcess(c.aem, instances, func(instance pkg.Instance) (map[string]any, error) {
+		paths, err := repoBulkPathsByFlags(cmd, instance)
+		if err != nil {
+			return nil, err
+		}
+		instanceBulk := bulk
+		instanceBulk.Parallelism, _ = cmd.Flags().GetInt("parallelism")
+		if instanceBulk.Parallelism <= 0 {
+			instanceBulk.Parallelism = instance.Repo().BulkParallelism
+		}
+		instanceBulk.BatchSize, _ = cmd.Flags().GetInt("batch-size")
+		if instanceBulk.BatchSize <= 0 {
+			instanceBulk.BatchSize = instance.Repo().BulkBatchSize
+		}
+		result, err := instance.Repo().Bulk(paths, instanceBulk)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{
+			OutputChanged: !bulk.DryRun && len(result.Changed()) > 0,
+			"result":      result,
+			"instance":    instance,
+		}, nil
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.SetOutput("processed", processed)
+	failed := lo.SumBy(processed, func(data map[string]any) int { return len(data["result"].(*repo.BulkResult).Failed()) })
+	if failed > 0 {
+		c.Fail(fmt.Sprintf("bulk operation '%s' failed for nodes (%d)", bulk.Operation, failed))
+	} else if bulk.DryRun {
+		c.Ok(fmt.Sprintf("bulk operation '%s' previewed", bulk.Operation))
+	} else if mapsx.SomeHas(processed, OutputChanged, true) {
+		c.Changed(fmt.Sprintf("bulk operation '%s' applied", bulk.Operation))
+	} else {
+		c.Ok(fmt.Sprintf("bulk operation '%s' not needed (up-to-date)", bulk.Operation))
+	}
+}
+
+func repoBulkPathsByFlags(cmd *cobra.Command, instance pkg.Instance) ([]string, error) {
+	if pathsFile, _ := cmd.Flags().GetString("paths-file"); pathsFile != "" {
+		return repo.ReadPathsFile(pathsFile)
+	}
+	hits, err := instance.Repo().Query(repoQueryByFlags(cmd))
+	if err != nil {
+		return nil, err
+	}
+	return hits.Paths(), nil
+}
+
 func repoNodeDefineFlags(cmd *cobra.Command) {
 	cmd.Flags().String("path", "", "Path")
 	_ = cmd.MarkFlagRequired("path")
@@ -29,6 +29,7 @@ func (c *CLI) rootCmd() *cobra.Command {
 	cmd.AddCommand(c.repoCmd())
 	cmd.AddCommand(c.replCmd())
 	cmd.AddCommand(c.cryptoCmd())
+	cmd.AddCommand(c.secretCmd())
 	cmd.AddCommand(c.sslCmd())
 	cmd.AddCommand(c.gtsCmd())
 	cmd.AddCommand(c.fileCmd())
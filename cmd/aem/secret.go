@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/wttech/aemc/pkg/secret"
+)
+
+func (c *CLI) secretCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "secret",
+		Short: "Manages secrets resolved in placeholders",
+	}
+	cmd.AddCommand(c.secretSetCmd())
+	cmd.AddCommand(c.secretCheckCmd())
+	return cmd
+}
+
+func (c *CLI) secretSetCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "set",
+		Aliases: []string{"save"},
+		Short:   "Store secret in encrypted file. Value is read from input.",
+		Run: func(cmd *cobra.Command, args []string) {
+			cv := c.config.Values()
+			file := cv.GetString("secret.encrypted_file.path")
+			if file == "" {
+				c.Fail("cannot set secret as encrypted file path is not configured ('secret.encrypted_file.path')")
+				return
+			}
+			provider := &secret.EncryptedFileProvider{File: file, KeyVar: cv.GetString("secret.encrypted_file.key_var")}
+			name, _ := cmd.Flags().GetString("name")
+			var value string
+			if err := c.ReadInput(&value); err != nil {
+				c.Fail(fmt.Sprintf("error reading secret value from input: %s", err))
+				return
+			}
+			if value == "" {
+				c.Fail("cannot set secret as value read from input is empty")
+				return
+			}
+			changed, err := provider.Set(name, value)
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			c.SetOutput("name", name)
+			c.SetOutput("file", file)
+			if changed {
+				c.Changed("secret set")
+			} else {
+				c.Ok("secret already set (up-to-date)")
+			}
+		},
+	}
+	cmd.Flags().String("name", "", "Secret name")
+	_ = cmd.MarkFlagRequired("name")
+	return cmd
+}
+
+func (c *CLI) secretCheckCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Check if secrets are resolvable by providers (values are not printed)",
+		Run: func(cmd *cobra.Command, args []string) {
+			resolver := secret.NewResolverByConfig(c.config)
+			names, _ := cmd.Flags().GetStringSlice("name")
+			found := map[string]any{}
+			var missing []string
+			for _, name := range names {
+				provider, err := resolver.Find(name)
+				if err != nil {
+					c.Error(err)
+					return
+				}
+				if provider == nil {
+					found[name] = ""
+					missing = append(missing, name)
+				} else {
+					found[name] = provider.Name()
+				}
+			}
+			c.SetOutput("providers", found)
+			if len(missing) > 0 {
+				c.Fail(fmt.Sprintf("secrets cannot be found: %s", strings.Join(missing, ", ")))
+				return
+			}
+			c.Ok("secrets found")
+		},
+	}
+	cmd.Flags().StringSlice("name", []string{}, "Secret name(s)")
+	_ = cmd.MarkFlagRequired("name")
+	return cmd
+}
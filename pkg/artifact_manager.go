@@ -0,0 +1,161 @@
+package pkg
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cast"
+	"github.com/wttech/aemc/pkg/common/filex"
+	"github.com/wttech/aemc/pkg/common/httpx"
+	"github.com/wttech/aemc/pkg/common/pathx"
+	"github.com/wttech/aemc/pkg/maven"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ArtifactManager resolves Maven artifacts (AEM packages, OSGi bundles) against local and remote repositories
+type ArtifactManager struct {
+	aem *AEM
+
+	LocalRepoDir string
+	Repositories []ArtifactRepository
+}
+
+type ArtifactRepository struct {
+	URL      string `yaml:"url" json:"url"`
+	User     string `yaml:"user" json:"user"`
+	Password string `yaml:"password" json:"password"`
+	Token    string `yaml:"token" json:"token"`
+}
+
+func NewArtifactManager(aem *AEM) *ArtifactManager {
+	cv := aem.config.Values()
+
+	return &ArtifactManager{
+		aem: aem,
+
+		LocalRepoDir: determineLocalRepoDir(cv.GetString("maven.local_repo_dir")),
+		Repositories: determineArtifactRepositories(cv.Get("maven.repositories")),
+	}
+}
+
+func determineLocalRepoDir(dir string) string {
+	if dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".m2", "repository")
+}
+
+func determineArtifactRepositories(values any) []ArtifactRepository {
+	var result []ArtifactRepository
+	for _, value := range cast.ToSlice(values) {
+		props := cast.ToStringMapString(value)
+		result = append(result, ArtifactRepository{
+			URL:      strings.TrimSuffix(props["url"], "/"),
+			User:     props["user"],
+			Password: props["password"],
+			Token:    props["token"],
+		})
+	}
+	return result
+}
+
+// Resolve finds artifact file in local repository or downloads it to the cache from the first remote repository having it
+func (am *ArtifactManager) Resolve(coords string) (string, error) {
+	artifact, err := maven.ParseArtifact(coords)
+	if err != nil {
+		return "", err
+	}
+	if am.LocalRepoDir != "" {
+		localFile := filepath.Join(am.LocalRepoDir, artifact.Path())
+		if pathx.Exists(localFile) {
+			log.Infof("resolved artifact '%s' from local repository '%s'", artifact, localFile)
+			return localFile, nil
+		}
+	}
+	cacheFile := filepath.Join(am.cacheDir(), artifact.Path())
+	if !artifact.IsSnapshot() && pathx.Exists(cacheFile) {
+		log.Debugf("resolved artifact '%s' from cache '%s'", artifact, cacheFile)
+		return cacheFile, nil
+	}
+	if len(am.Repositories) == 0 {
+		return "", fmt.Errorf("cannot resolve artifact '%s' as no remote repositories are configured", artifact)
+	}
+	var errs []string
+	for _, repo := range am.Repositories {
+		if err := am.download(repo, *artifact, cacheFile); err != nil {
+			log.Debugf("cannot resolve artifact '%s' from repository '%s': %s", artifact, repo.URL, err)
+			errs = append(errs, err.Error())
+			continue
+		}
+		return cacheFile, nil
+	}
+	return "", fmt.Errorf("cannot resolve artifact '%s' from any repository: %s", artifact, strings.Join(errs, "; "))
+}
+
+// download fetches artifact from remote repository; snapshots are re-downloaded only when a newer build is published
+func (am *ArtifactManager) download(repo ArtifactRepository, artifact maven.Artifact, cacheFile string) error {
+	version := artifact.Version
+	if artifact.IsSnapshot() {
+		snapshotVersion, err := am.snapshotVersion(repo, artifact)
+		if err != nil {
+			return err
+		}
+		versionFile := cacheFile + ".version"
+		if pathx.Exists(cacheFile) && pathx.Exists(versionFile) {
+			cachedVersion, err := filex.ReadString(versionFile)
+			if err == nil && cachedVersion == snapshotVersion {
+				log.Debugf("resolved artifact '%s' (%s) from cache '%s'", artifact, snapshotVersion, cacheFile)
+				return nil
+			}
+		}
+		if err := am.downloadFile(repo, artifact.DirPath()+"/"+artifact.FileName(snapshotVersion), cacheFile); err != nil {
+			return err
+		}
+		if err := filex.WriteString(versionFile, snapshotVersion); err != nil {
+			return err
+		}
+		version = snapshotVersion
+	} else if err := am.downloadFile(repo, artifact.Path(), cacheFile); err != nil {
+		return err
+	}
+	log.Infof("resolved artifact '%s' (%s) from repository '%s'", artifact, version, repo.URL)
+	return nil
+}
+
+func (am *ArtifactManager) snapshotVersion(repo ArtifactRepository, artifact maven.Artifact) (string, error) {
+	metadataFile := pathx.RandomFileName(am.aem.baseOpts.TmpDir, "maven_metadata", ".xml")
+	defer func() { _ = pathx.DeleteIfExists(metadataFile) }()
+	if err := am.downloadFile(repo, artifact.MetadataPath(), metadataFile); err != nil {
+		return "", err
+	}
+	file, err := os.Open(metadataFile)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = file.Close() }()
+	metadata, err := maven.ReadMetadata(file)
+	if err != nil {
+		return "", err
+	}
+	return metadata.SnapshotVersion(artifact)
+}
+
+func (am *ArtifactManager) downloadFile(repo ArtifactRepository, path string, file string) error {
+	return httpx.DownloadWithOpts(httpx.DownloadOpts{
+		URL:               repo.URL + "/" + path,
+		File:              file,
+		Override:          true,
+		AuthBasicUser:     repo.User,
+		AuthBasicPassword: repo.Password,
+		AuthToken:         repo.Token,
+	})
+}
+
+func (am *ArtifactManager) cacheDir() string {
+	return filepath.Join(am.aem.baseOpts.CacheDir, "artifact")
+}
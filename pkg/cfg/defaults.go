@@ -49,6 +49,23 @@ func (c *Config) setDefaults() {
 
 	v.SetDefault("vendor.vault.download_url", "https://repo1.maven.org/maven2/org/apache/jackrabbit/vault/vault-cli/3.8.2/vault-cli-3.8.2-bin.tar.gz")
 
+	v.SetDefault("maven.local_repo_dir", "")
+	v.SetDefault("maven.repositories", []any{
+		map[string]any{"url": "https://repo1.maven.org/maven2"},
+	})
+
+	v.SetDefault("secret.enabled", false)
+	v.SetDefault("secret.env.enabled", true)
+	v.SetDefault("secret.env.prefix", "AEM_SECRET_")
+	v.SetDefault("secret.file.dir", "")
+	v.SetDefault("secret.encrypted_file.path", "")
+	v.SetDefault("secret.encrypted_file.key_var", "AEM_SECRET_KEY")
+	v.SetDefault("secret.vault.address", "")
+	v.SetDefault("secret.vault.mount", "secret")
+	v.SetDefault("secret.vault.path", "aem")
+	v.SetDefault("secret.vault.token_var", "VAULT_TOKEN")
+	v.SetDefault("secret.vault.ca_file", "")
+
 	v.SetDefault("instance.processing_mode", instance.ProcessingAuto)
 
 	v.SetDefault("instance.http.timeout", time.Minute*10)
@@ -56,6 +73,10 @@ func (c *Config) setDefaults() {
 	v.SetDefault("instance.http.disable_warn", true)
 	v.SetDefault("instance.http.ignore_ssl_errors", true)
 
+	v.SetDefault("instance.diagnose.dir", common.VarDir+"/diagnose")
+	v.SetDefault("instance.diagnose.log_lines", 1000)
+	v.SetDefault("instance.diagnose.on_await_timeout", false)
+
 	v.SetDefault("instance.check.skip", false)
 	v.SetDefault("instance.check.warmup", time.Second*1)
 	v.SetDefault("instance.check.interval", time.Second*6)
@@ -103,6 +124,11 @@ func (c *Config) setDefaults() {
 	v.SetDefault("instance.status.timeout", time.Millisecond*500)
 
 	v.SetDefault("instance.package.upload_optimized", true)
+	v.SetDefault("instance.package.upload_verify", pkg.UploadVerifyNone)
+
+	v.SetDefault("instance.package.transfer.retry.attempts", 3)
+	v.SetDefault("instance.package.transfer.retry.delay", time.Second*5)
+	v.SetDefault("instance.package.transfer.bandwidth_limit", "")
 
 	v.SetDefault("instance.package.install_recursive", true)
 	v.SetDefault("instance.package.install_save_threshold", 1024)
@@ -120,16 +146,33 @@ func (c *Config) setDefaults() {
 
 	v.SetDefault("instance.package.toggled_workflows", []string{})
 
+	v.SetDefault("instance.package.purge.keep_last", 3)
+	v.SetDefault("instance.package.purge.older_than_days", 0)
+	v.SetDefault("instance.package.purge.protected_patterns", []string{"adobe/*", "day/*", "com.adobe*", "com.day*"})
+
 	v.SetDefault("instance.repo.property_change_ignored", []string{"jcr:created", "cq:lastModified", "transportPassword"})
+	v.SetDefault("instance.repo.export.properties_skipped", []string{"jcr:created", "jcr:createdBy", "jcr:uuid", "jcr:baseVersion", "jcr:predecessors", "jcr:versionHistory", "jcr:isCheckedOut", "rep:*"})
+	v.SetDefault("instance.repo.export.nodes_skipped", []string{"rep:policy", "rep:repoPolicy"})
+	v.SetDefault("instance.repo.bulk.parallelism", 4)
+	v.SetDefault("instance.repo.bulk.batch_size", 100)
+	v.SetDefault("instance.repo.file.upload.skipping", true)
+	v.SetDefault("instance.repo.file.upload.dam_direct", true)
+	v.SetDefault("instance.repo.file.upload.part_timeout", time.Minute*10)
 
 	v.SetDefault("instance.osgi.shutdown_delay", time.Second*3)
 	v.SetDefault("instance.osgi.bundle.install.start", true)
 	v.SetDefault("instance.osgi.bundle.install.start_level", 20)
 	v.SetDefault("instance.osgi.bundle.install.refresh_packages", true)
+	v.SetDefault("instance.osgi.bundle.install.check", "none")
 
 	v.SetDefault("instance.osgi.bundle.snapshot_install_skipping", true)
 	v.SetDefault("instance.osgi.bundle.snapshot_ignored", false)
 	v.SetDefault("instance.osgi.bundle.snapshot_patterns", []string{"**/*-SNAPSHOT.jar"})
+	v.SetDefault("instance.osgi.bundle.diagnose.parallelism", 8)
+	v.SetDefault("instance.osgi.component.graph.parallelism", 8)
+	v.SetDefault("instance.osgi.config.secret_protect", false)
+	v.SetDefault("instance.osgi.config.save.validate", "warn")
+	v.SetDefault("instance.osgi.event.watch.interval", time.Second*2)
 
 	v.SetDefault("instance.oak.index.await_not_reindexed_timeout", time.Minute*60)
 
@@ -200,4 +243,64 @@ func (c *Config) setDefaults() {
 		},
 	})
 	v.SetDefault("content.clean.namespaces_skipped", true)
+
+	v.SetDefault("content.lint.properties_forbidden", []any{
+		map[string]any{
+			"patterns":       []string{"jcr:uuid"},
+			"excluded_paths": []string{"**/home/users/*", "**/home/groups/*"},
+		},
+	})
+	v.SetDefault("content.lint.values_forbidden", []any{
+		map[string]any{
+			"patterns": []string{"*"},
+			"regex":    "https?://author[\\w.-]*(:\\d+)?(/|$)",
+			"message":  "absolute author URL",
+		},
+	})
+	v.SetDefault("content.lint.page_properties_required", []string{"jcr:title"})
+	v.SetDefault("content.lint.super_types_checked", true)
+	v.SetDefault("content.lint.super_types_ignored", []string{"core/*", "wcm/*", "granite/*", "cq/*", "dam/*", "foundation/*", "sling/*", "/libs/*"})
+	v.SetDefault("content.lint.binary_size_max", "10MB")
+
+	v.SetDefault("content.sync.state_enabled", false)
+	v.SetDefault("content.sync.conflict", "fail")
+
+	v.SetDefault("content.watch.debounce", time.Second)
+	v.SetDefault("content.watch.pull_interval", time.Duration(0))
+	v.SetDefault("content.watch.files_ignored", []string{"**/.vlt", "**/.vlt*.tmp", "**/*.tmp", "**/*.swp", "**/*~", "**/.DS_Store"})
+
+	v.SetDefault("content.transform.path_mappings", []any{})
+	v.SetDefault("content.transform.property_replacements", []any{})
+	v.SetDefault("content.transform.resource_type_renames", []any{})
+	v.SetDefault("content.transform.node_types_dropped", []string{})
+
+	v.SetDefault("content.scrub.salt", "")
+	v.SetDefault("content.scrub.rules", []any{
+		map[string]any{
+			"patterns":       []string{"givenName", "familyName"},
+			"included_paths": []string{"/home/users/*"},
+			"strategy":       "fake",
+			"fake":           "name",
+		},
+		map[string]any{
+			"patterns":       []string{"email"},
+			"included_paths": []string{"/home/users/*"},
+			"strategy":       "fake",
+			"fake":           "email",
+		},
+		map[string]any{
+			"patterns":       []string{"phoneNumber", "mobile"},
+			"included_paths": []string{"/home/users/*"},
+			"strategy":       "blank",
+		},
+		map[string]any{
+			"patterns":       []string{"street", "postalCode", "city", "aboutMe"},
+			"included_paths": []string{"/home/users/*"},
+			"strategy":       "drop",
+		},
+		map[string]any{
+			"included_paths": []string{"/home/users/*/profile/photos"},
+			"strategy":       "drop",
+		},
+	})
 }
@@ -1,6 +1,7 @@
 package filex
 
 import (
+	"bufio"
 	"crypto/md5"
 	"fmt"
 	"github.com/codingsince1985/checksum"
@@ -10,6 +11,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 func Write(path string, data []byte) error {
@@ -51,6 +53,31 @@ func ReadString(path string) (string, error) {
 	return string(bytes), nil
 }
 
+// ReadTail reads last lines of file (e.g. recent log entries) without loading whole file into memory
+func ReadTail(path string, lines int) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("cannot open file '%s': %w", path, err)
+	}
+	defer f.Close()
+	var result []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		result = append(result, scanner.Text())
+		if len(result) > lines {
+			result = result[1:]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("cannot read file '%s': %w", path, err)
+	}
+	if len(result) == 0 {
+		return "", nil
+	}
+	return strings.Join(result, "\n") + "\n", nil
+}
+
 func AppendString(path string, text string) error {
 	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
@@ -0,0 +1,28 @@
+package filex_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wttech/aemc/pkg/common/filex"
+)
+
+func TestReadTail(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	file := filepath.Join(t.TempDir(), "error.log")
+	a.NoError(filex.WriteString(file, "line1\nline2\nline3\nline4\n"))
+
+	tail, err := filex.ReadTail(file, 2)
+	a.NoError(err)
+	a.Equal("line3\nline4\n", tail)
+
+	tail, err = filex.ReadTail(file, 10)
+	a.NoError(err)
+	a.Equal("line1\nline2\nline3\nline4\n", tail)
+
+	_, err = filex.ReadTail(filepath.Join(t.TempDir(), "missing.log"), 2)
+	a.Error(err)
+}
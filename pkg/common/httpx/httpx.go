@@ -6,6 +6,7 @@ import (
 	"github.com/cheggaaa/pb/v3"
 	"github.com/fatih/color"
 	"github.com/go-resty/resty/v2"
+	"github.com/wttech/aemc/pkg/common/iox"
 	"github.com/wttech/aemc/pkg/common/pathx"
 	"github.com/wttech/aemc/pkg/common/stringsx"
 	"io"
@@ -18,6 +19,16 @@ func FileNameFromURL(url string) string {
 	return stringsx.BeforeLast(stringsx.AfterLast(url, "/"), "?")
 }
 
+// StatusError describes unexpected HTTP response status (allows distinguishing client and server errors)
+type StatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return e.Status
+}
+
 type DownloadOpts struct {
 	Client            *resty.Client
 	URL               string
@@ -26,6 +37,7 @@ type DownloadOpts struct {
 	AuthToken         string
 	AuthBasicUser     string
 	AuthBasicPassword string
+	BandwidthLimit    uint64
 }
 
 func downloadClient(opts DownloadOpts) *resty.Client {
@@ -69,19 +81,20 @@ func DownloadWithOpts(opts DownloadOpts) error {
 	}
 	defer res.RawBody().Close()
 	if res.StatusCode() != http.StatusOK {
-		return fmt.Errorf("cannot download from URL '%s' to file '%s': %s", opts.URL, opts.File, res.Status())
+		return fmt.Errorf("cannot download from URL '%s' to file '%s': %w", opts.URL, opts.File, &StatusError{StatusCode: res.StatusCode(), Status: res.Status()})
 	}
 	fhTmp, err := os.Create(fileTmp)
 	if err != nil {
 		return fmt.Errorf("cannot download from URL '%s' as file '%s' cannot be written", opts.URL, opts.File)
 	}
+	body := iox.NewRateLimitedReader(res.RawBody(), opts.BandwidthLimit)
 	if color.NoColor {
-		if _, err := io.Copy(fhTmp, res.RawBody()); err != nil {
+		if _, err := io.Copy(fhTmp, body); err != nil {
 			return fmt.Errorf("cannot download from URL '%s' to file '%s': %w", opts.URL, opts.File, err)
 		}
 	} else {
 		bar := pb.Full.Start64(res.RawResponse.ContentLength)
-		if _, err := io.Copy(bar.NewProxyWriter(fhTmp), res.RawBody()); err != nil {
+		if _, err := io.Copy(bar.NewProxyWriter(fhTmp), body); err != nil {
 			return fmt.Errorf("cannot download from URL '%s' to file '%s': %w", opts.URL, opts.File, err)
 		}
 		bar.Finish()
@@ -0,0 +1,39 @@
+package iox
+
+import (
+	"io"
+	"time"
+)
+
+// RateLimitedReader throttles reading to the specified number of bytes per second
+type RateLimitedReader struct {
+	reader  io.Reader
+	limit   uint64
+	read    uint64
+	started time.Time
+}
+
+// NewRateLimitedReader wraps reader with a bandwidth limit (zero means no limit)
+func NewRateLimitedReader(reader io.Reader, bytesPerSecond uint64) io.Reader {
+	if bytesPerSecond == 0 {
+		return reader
+	}
+	return &RateLimitedReader{reader: reader, limit: bytesPerSecond}
+}
+
+func (r *RateLimitedReader) Read(p []byte) (int, error) {
+	if r.started.IsZero() {
+		r.started = time.Now()
+	}
+	if uint64(len(p)) > r.limit {
+		p = p[:r.limit]
+	}
+	n, err := r.reader.Read(p)
+	r.read += uint64(n)
+	expected := time.Duration(float64(r.read) / float64(r.limit) * float64(time.Second))
+	elapsed := time.Since(r.started)
+	if expected > elapsed {
+		time.Sleep(expected - elapsed)
+	}
+	return n, err
+}
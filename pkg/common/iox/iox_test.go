@@ -0,0 +1,30 @@
+package iox_test
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/wttech/aemc/pkg/common/iox"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestRateLimitedReader(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	data := bytes.Repeat([]byte("a"), 3000)
+	started := time.Now()
+	read, err := io.ReadAll(iox.NewRateLimitedReader(bytes.NewReader(data), 10000))
+
+	a.NoError(err)
+	a.Equal(data, read)
+	a.GreaterOrEqual(time.Since(started), 250*time.Millisecond)
+}
+
+func TestRateLimitedReaderUnlimited(t *testing.T) {
+	t.Parallel()
+
+	source := bytes.NewReader([]byte("a"))
+	assert.Same(t, source, iox.NewRateLimitedReader(source, 0))
+}
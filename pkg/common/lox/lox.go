@@ -15,7 +15,13 @@ func Map[I any, R any](parallel bool, iterable []I, callback func(instance I) (R
 }
 
 func ParallelMap[I any, R any](iterable []I, callback func(iteratee I) (R, error)) ([]R, error) {
+	return ParallelMapLimit(-1, iterable, callback)
+}
+
+// ParallelMapLimit works like ParallelMap but runs at most limit callbacks at once (negative means no limit)
+func ParallelMapLimit[I any, R any](limit int, iterable []I, callback func(iteratee I) (R, error)) ([]R, error) {
 	g, _ := errgroup.WithContext(context.Background())
+	g.SetLimit(limit)
 	results := make([]R, len(iterable))
 	for i, iteratee := range iterable {
 		i, iteratee := i, iteratee
@@ -2,6 +2,7 @@ package tplx
 
 import (
 	"bytes"
+	"encoding/xml"
 	"fmt"
 	"github.com/Masterminds/sprig"
 	"github.com/wttech/aemc/pkg/common/filex"
@@ -26,6 +27,11 @@ func init() {
 		defer recovery()
 		return pathx.Canonical(strings.Join(pathSegments, "/"))
 	}
+	funcMap["xmlEscape"] = func(value string) string {
+		var sb strings.Builder
+		_ = xml.EscapeText(&sb, []byte(value))
+		return sb.String()
+	}
 }
 
 func recovery() {
@@ -0,0 +1,15 @@
+package tplx_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/wttech/aemc/pkg/common/tplx"
+	"testing"
+)
+
+func TestXmlEscape(t *testing.T) {
+	t.Parallel()
+
+	result, err := tplx.RenderString(`<filter root="[[xmlEscape .]]"/>`, `/content/a&b/"c"<d>`)
+	assert.NoError(t, err)
+	assert.Equal(t, `<filter root="/content/a&amp;b/&#34;c&#34;&lt;d&gt;"/>`, result)
+}
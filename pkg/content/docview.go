@@ -0,0 +1,349 @@
+package content
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	DocViewHeader   = "<?xml version=\"1.0\" encoding=\"UTF-8\"?>"
+	DocViewRootNode = "jcr:root"
+	DocViewIndent   = "    "
+	DocViewXmlns    = "xmlns"
+)
+
+// DocView is a FileVault document view of a JCR node tree (e.g. '.content.xml' file)
+type DocView struct {
+	Root *DocViewNode
+}
+
+type DocViewNode struct {
+	Name       string
+	Namespaces []DocViewNamespace
+	Properties []DocViewProperty
+	Children   []*DocViewNode
+}
+
+type DocViewNamespace struct {
+	Prefix string
+	URI    string
+}
+
+type DocViewProperty struct {
+	Name  string
+	Value string
+}
+
+// ReadDocView parses file only when it is a document view; other XML files (e.g. with text or comments) are reported as errors
+func ReadDocView(path string) (*DocView, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read document view file '%s': %w", path, err)
+	}
+	docView, err := ParseDocView(data)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse document view file '%s': %w", path, err)
+	}
+	return docView, nil
+}
+
+func ParseDocView(data []byte) (*DocView, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var stack []*DocViewNode
+	var root *DocViewNode
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &DocViewNode{Name: qualifiedName(t.Name)}
+			for _, attr := range t.Attr {
+				if attr.Name.Space == DocViewXmlns {
+					node.Namespaces = append(node.Namespaces, DocViewNamespace{Prefix: attr.Name.Local, URI: attr.Value})
+				} else {
+					node.Properties = append(node.Properties, DocViewProperty{Name: qualifiedName(attr.Name), Value: attr.Value})
+				}
+			}
+			if len(stack) == 0 {
+				if root != nil {
+					return nil, fmt.Errorf("multiple root elements")
+				}
+				if node.Name != DocViewRootNode {
+					return nil, fmt.Errorf("root element is '%s' but should be '%s'", node.Name, DocViewRootNode)
+				}
+				root = node
+			} else {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, node)
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			if len(stack) == 0 || stack[len(stack)-1].Name != qualifiedName(t.Name) {
+				return nil, fmt.Errorf("unexpected end element '%s'", qualifiedName(t.Name))
+			}
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(bytes.TrimSpace(t)) > 0 {
+				return nil, fmt.Errorf("unsupported text content '%s'", strings.TrimSpace(string(t)))
+			}
+		case xml.Comment:
+			return nil, fmt.Errorf("unsupported comment")
+		case xml.Directive:
+			return nil, fmt.Errorf("unsupported directive")
+		}
+	}
+	if root == nil {
+		return nil, fmt.Errorf("no root element")
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("unclosed element '%s'", stack[len(stack)-1].Name)
+	}
+	return &DocView{Root: root}, nil
+}
+
+func qualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+// Write saves document view using the same canonical serialization as FileVault
+func (d *DocView) Write(path string) error {
+	if err := os.WriteFile(path, d.Bytes(), 0644); err != nil {
+		return fmt.Errorf("cannot write document view file '%s': %w", path, err)
+	}
+	return nil
+}
+
+func (d *DocView) Bytes() []byte {
+	var b bytes.Buffer
+	b.WriteString(DocViewHeader)
+	b.WriteString("\n")
+	writeDocViewNode(&b, d.Root, 0)
+	return b.Bytes()
+}
+
+func (d *DocView) String() string {
+	return string(d.Bytes())
+}
+
+func writeDocViewNode(b *bytes.Buffer, node *DocViewNode, depth int) {
+	indent := strings.Repeat(DocViewIndent, depth)
+	b.WriteString(indent + "<" + node.Name)
+	for _, ns := range node.Namespaces {
+		b.WriteString(" " + DocViewXmlns + ":" + ns.Prefix + "=\"" + escapeDocViewValue(ns.URI) + "\"")
+	}
+	properties := node.SortedProperties()
+	if len(node.Namespaces) == 0 && len(properties) == 1 {
+		b.WriteString(" " + properties[0].Name + "=\"" + escapeDocViewValue(properties[0].Value) + "\"")
+	} else {
+		for _, prop := range properties {
+			b.WriteString("\n" + indent + DocViewIndent + prop.Name + "=\"" + escapeDocViewValue(prop.Value) + "\"")
+		}
+	}
+	if len(node.Children) == 0 {
+		b.WriteString("/>\n")
+		return
+	}
+	b.WriteString(">\n")
+	for _, child := range node.Children {
+		writeDocViewNode(b, child, depth+1)
+	}
+	b.WriteString(indent + "</" + node.Name + ">\n")
+}
+
+// SortedProperties orders qualified property names first, then unqualified ones, each group alphabetically (case-insensitive)
+func (n *DocViewNode) SortedProperties() []DocViewProperty {
+	result := append([]DocViewProperty{}, n.Properties...)
+	sort.SliceStable(result, func(i, j int) bool {
+		qi := strings.Contains(result[i].Name, ":")
+		qj := strings.Contains(result[j].Name, ":")
+		if qi != qj {
+			return qi
+		}
+		ni, nj := strings.ToLower(result[i].Name), strings.ToLower(result[j].Name)
+		if ni != nj {
+			return ni < nj
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+func (n *DocViewNode) Property(name string) (string, bool) {
+	for _, prop := range n.Properties {
+		if prop.Name == name {
+			return prop.Value, true
+		}
+	}
+	return "", false
+}
+
+func (n *DocViewNode) SetProperty(name string, value string) {
+	for i, prop := range n.Properties {
+		if prop.Name == name {
+			n.Properties[i].Value = value
+			return
+		}
+	}
+	n.Properties = append(n.Properties, DocViewProperty{Name: name, Value: value})
+}
+
+func (n *DocViewNode) RemoveProperty(name string) {
+	n.RemoveProperties(func(prop DocViewProperty) bool { return prop.Name == name })
+}
+
+func (n *DocViewNode) RemoveProperties(predicate func(prop DocViewProperty) bool) {
+	var result []DocViewProperty
+	for _, prop := range n.Properties {
+		if !predicate(prop) {
+			result = append(result, prop)
+		}
+	}
+	n.Properties = result
+}
+
+// Walk visits node and its descendants (depth-first)
+func (n *DocViewNode) Walk(visitor func(node *DocViewNode)) {
+	visitor(n)
+	for _, child := range n.Children {
+		child.Walk(visitor)
+	}
+}
+
+// UsesPrefix checks if namespace prefix is used by any node name, property name or value
+func (n *DocViewNode) UsesPrefix(prefix string) bool {
+	used := false
+	n.Walk(func(node *DocViewNode) {
+		if used {
+			return
+		}
+		if strings.HasPrefix(node.Name, prefix+":") {
+			used = true
+			return
+		}
+		for _, prop := range node.Properties {
+			if strings.HasPrefix(prop.Name, prefix+":") || strings.Contains(prop.Value, prefix+":") {
+				used = true
+				return
+			}
+		}
+	})
+	return used
+}
+
+var docViewValueEscaper = strings.NewReplacer(
+	"&", "&amp;",
+	"<", "&lt;",
+	"\"", "&quot;",
+	"\r", "&#xd;",
+	"\n", "&#xa;",
+	"\t", "&#x9;",
+)
+
+func escapeDocViewValue(value string) string {
+	return docViewValueEscaper.Replace(value)
+}
+
+// DocViewValue is a parsed property value with optional type hint (e.g. '{Long}') and multi-value brackets
+type DocViewValue struct {
+	Type     string
+	Multiple bool
+	Values   []string
+}
+
+// ParseDocViewValue parses property value as serialized by FileVault (backslash escapes commas in multi-values and leading brackets in single values)
+func ParseDocViewValue(value string) DocViewValue {
+	result := DocViewValue{}
+	if strings.HasPrefix(value, "{") {
+		if end := strings.Index(value, "}"); end > 1 && isDocViewTypeName(value[1:end]) {
+			result.Type = value[1:end]
+			value = value[end+1:]
+		}
+	}
+	if strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]") {
+		result.Multiple = true
+		result.Values = []string{}
+		inner := value[1 : len(value)-1]
+		if inner == "" {
+			return result
+		}
+		var item strings.Builder
+		for i := 0; i < len(inner); i++ {
+			switch {
+			case inner[i] == '\\' && i+1 < len(inner):
+				i++
+				item.WriteByte(inner[i])
+			case inner[i] == ',':
+				result.Values = append(result.Values, item.String())
+				item.Reset()
+			default:
+				item.WriteByte(inner[i])
+			}
+		}
+		result.Values = append(result.Values, item.String())
+		return result
+	}
+	result.Values = []string{unescapeDocViewSingleValue(value)}
+	return result
+}
+
+func isDocViewTypeName(name string) bool {
+	for _, r := range name {
+		if !(r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z') {
+			return false
+		}
+	}
+	return true
+}
+
+func unescapeDocViewSingleValue(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' && i+1 < len(value) {
+			i++
+		}
+		b.WriteByte(value[i])
+	}
+	return b.String()
+}
+
+// String serializes value back the same way as FileVault does
+func (v DocViewValue) String() string {
+	var b strings.Builder
+	if v.Type != "" {
+		b.WriteString("{" + v.Type + "}")
+	}
+	if v.Multiple {
+		b.WriteString("[")
+		for i, item := range v.Values {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			b.WriteString(docViewMultiValueEscaper.Replace(item))
+		}
+		b.WriteString("]")
+		return b.String()
+	}
+	single := ""
+	if len(v.Values) > 0 {
+		single = strings.ReplaceAll(v.Values[0], "\\", "\\\\")
+	}
+	if strings.HasPrefix(single, "[") || v.Type == "" && strings.HasPrefix(single, "{") {
+		single = "\\" + single
+	}
+	b.WriteString(single)
+	return b.String()
+}
+
+var docViewMultiValueEscaper = strings.NewReplacer("\\", "\\\\", ",", "\\,")
@@ -0,0 +1,120 @@
+package content_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/wttech/aemc/pkg/content"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const docViewCanonical = `<?xml version="1.0" encoding="UTF-8"?>
+<jcr:root xmlns:sling="http://sling.apache.org/jcr/sling/1.0" xmlns:cq="http://www.day.com/jcr/cq/1.0" xmlns:jcr="http://www.jcp.org/jcr/1.0" xmlns:rep="internal"
+    jcr:mixinTypes="[rep:AccessControllable,mix:versionable]"
+    jcr:primaryType="cq:Page">
+    <jcr:content
+        cq:lastModified="{Date}2024-01-01T00:00:00.000+01:00"
+        jcr:description="&lt;p>Say &quot;hi&quot;&lt;/p>&#xd;&#xa;second line"
+        jcr:primaryType="cq:PageContent"
+        jcr:title="Home"
+        sling:resourceType="mysite/components/page"
+        allowedWidths="[320,480]"
+        allowUpload="{Boolean}false">
+        <cq:responsive jcr:primaryType="nt:unstructured"/>
+    </jcr:content>
+</jcr:root>
+`
+
+func TestParseDocViewCanonical(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	docView, err := content.ParseDocView([]byte(docViewCanonical))
+	a.NoError(err)
+	a.Equal(docViewCanonical, docView.String())
+
+	description, _ := docView.Root.Children[0].Property("jcr:description")
+	a.Equal("<p>Say \"hi\"</p>\r\nsecond line", description)
+}
+
+func TestParseDocViewNormalizes(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	docView, err := content.ParseDocView([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<jcr:root xmlns:jcr="http://www.jcp.org/jcr/1.0" xmlns:sling="http://sling.apache.org/jcr/sling/1.0" title="A &quot;quoted&quot;
+ value" jcr:primaryType="nt:unstructured"><item sling:resourceType="x" jcr:primaryType="nt:unstructured"></item></jcr:root>`))
+	a.NoError(err)
+	a.Equal(`<?xml version="1.0" encoding="UTF-8"?>
+<jcr:root xmlns:jcr="http://www.jcp.org/jcr/1.0" xmlns:sling="http://sling.apache.org/jcr/sling/1.0"
+    jcr:primaryType="nt:unstructured"
+    title="A &quot;quoted&quot;&#xa; value">
+    <item
+        jcr:primaryType="nt:unstructured"
+        sling:resourceType="x"/>
+</jcr:root>
+`, docView.String())
+}
+
+func TestParseDocViewUnsupported(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	_, err := content.ParseDocView([]byte(`<workspaceFilter version="1.0"><filter root="/content"/></workspaceFilter>`))
+	a.Error(err)
+	_, err = content.ParseDocView([]byte(`<jcr:root xmlns:jcr="http://www.jcp.org/jcr/1.0"><!-- note --></jcr:root>`))
+	a.Error(err)
+	_, err = content.ParseDocView([]byte(`<jcr:root xmlns:jcr="http://www.jcp.org/jcr/1.0">text</jcr:root>`))
+	a.Error(err)
+	_, err = content.ParseDocView([]byte(`<jcr:root xmlns:jcr="http://www.jcp.org/jcr/1.0">`))
+	a.Error(err)
+}
+
+func TestEditorClean(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	file := filepath.Join(t.TempDir(), "jcr_root", "content", "site", ".content.xml")
+	a.NoError(os.MkdirAll(filepath.Dir(file), 0755))
+	a.NoError(os.WriteFile(file, []byte(docViewCanonical), 0644))
+
+	editor := content.Editor{
+		PropertiesSkipped: []content.PathRule{{Patterns: []string{"cq:lastModified", "jcr:description"}}},
+		MixinTypesSkipped: []content.PathRule{{Patterns: []string{"rep:AccessControllable"}}},
+		NamespacesSkipped: true,
+	}
+	a.NoError(editor.Clean(file))
+
+	cleaned, err := os.ReadFile(file)
+	a.NoError(err)
+	a.Equal(`<?xml version="1.0" encoding="UTF-8"?>
+<jcr:root xmlns:sling="http://sling.apache.org/jcr/sling/1.0" xmlns:cq="http://www.day.com/jcr/cq/1.0" xmlns:jcr="http://www.jcp.org/jcr/1.0"
+    jcr:mixinTypes="[mix:versionable]"
+    jcr:primaryType="cq:Page">
+    <jcr:content
+        jcr:primaryType="cq:PageContent"
+        jcr:title="Home"
+        sling:resourceType="mysite/components/page"
+        allowedWidths="[320,480]"
+        allowUpload="{Boolean}false">
+        <cq:responsive jcr:primaryType="nt:unstructured"/>
+    </jcr:content>
+</jcr:root>
+`, string(cleaned))
+	a.True(content.IsPageContentFile(file))
+}
+
+func TestParseDocViewValue(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	a.Equal(content.DocViewValue{Values: []string{"Home"}}, content.ParseDocViewValue("Home"))
+	a.Equal(content.DocViewValue{Type: "Long", Values: []string{"42"}}, content.ParseDocViewValue("{Long}42"))
+	a.Equal(content.DocViewValue{Multiple: true, Values: []string{"a,b", "c\\d"}}, content.ParseDocViewValue(`[a\,b,c\\d]`))
+	a.Equal(content.DocViewValue{Type: "Date", Multiple: true, Values: []string{}}, content.ParseDocViewValue("{Date}[]"))
+	a.Equal(content.DocViewValue{Values: []string{"[not multi]"}}, content.ParseDocViewValue(`\[not multi]`))
+
+	for _, value := range []string{"Home", "{Long}42", `[a\,b,c\\d]`, "{Date}[]", `\[not multi]`, `\{not typed}`, `{String}{x}`} {
+		a.Equal(value, content.ParseDocViewValue(value).String())
+	}
+}
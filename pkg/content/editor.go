@@ -1,7 +1,6 @@
 package content
 
 import (
-	"bufio"
 	"github.com/samber/lo"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cast"
@@ -20,22 +19,17 @@ const (
 	JCRContentFile           = ".content.xml"
 	XmlFileSuffix            = ".xml"
 	JCRMixinTypesProp        = "jcr:mixinTypes"
+	JCRPrimaryTypeProp       = "jcr:primaryType"
 	JCRRootPrefix            = "<jcr:root"
 	JCRContentNode           = "jcr:content"
-	PropPattern              = "^\\s*([^ =]+)=\"([^\"]+)\"(.*)$"
-	NamespacePattern         = "^\\w+:(\\w+)=\"[^\"]+\"$"
 	FileWithNamespacePattern = "[\\\\/]_([a-zA-Z0-9]+)_[^\\\\/]+([\\\\/]\\.content)?\\.xml$"
 )
 
 var (
-	propPatternRegex              *regexp.Regexp
-	namespacePatternRegex         *regexp.Regexp
 	fileWithNamespacePatternRegex *regexp.Regexp
 )
 
 func init() {
-	propPatternRegex = regexp.MustCompile(PropPattern)
-	namespacePatternRegex = regexp.MustCompile(NamespacePattern)
 	fileWithNamespacePatternRegex = regexp.MustCompile(FileWithNamespacePattern)
 }
 
@@ -113,40 +107,56 @@ func (c Editor) cleanDotContentFile(path string) error {
 	if !strings.HasSuffix(path, XmlFileSuffix) {
 		return nil
 	}
-
-	log.Infof("cleaning dot content file '%s'", path)
-	inputLines, err := readLines(path)
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return err
 	}
-	outputLines := c.filterLines(path, inputLines)
-	return writeLines(path, outputLines)
+	docView, err := ParseDocView(data)
+	if err != nil {
+		log.Debugf("skipping cleaning file '%s' as it is not a document view: %s", path, err)
+		return nil
+	}
+	log.Infof("cleaning dot content file '%s'", path)
+	c.cleanDocView(path, docView)
+	return docView.Write(path)
 }
 
-func (c Editor) filterLines(path string, lines []string) []string {
-	var result []string
-	for _, line := range lines {
-		flag, processedLine := c.lineProcess(path, line)
-		if flag {
-			result[len(result)-1] += processedLine
+func (c Editor) cleanDocView(path string, docView *DocView) {
+	docView.Root.Walk(func(node *DocViewNode) {
+		c.cleanProperties(path, node)
+	})
+	c.cleanNamespaces(path, docView)
+}
+
+func (c Editor) cleanProperties(path string, node *DocViewNode) {
+	if mixins, ok := node.Property(JCRMixinTypesProp); ok {
+		if normalized := c.normalizeMixins(path, mixins); normalized == "" {
+			node.RemoveProperty(JCRMixinTypesProp)
 		} else {
-			result = append(result, processedLine)
+			node.SetProperty(JCRMixinTypesProp, normalized)
 		}
-		if len(result) > 2 && strings.HasSuffix(processedLine, ">") &&
-			!strings.HasPrefix(result[len(result)-2], JCRRootPrefix) &&
-			strings.HasPrefix(strings.TrimSpace(result[len(result)-2]), "<") &&
-			!strings.HasSuffix(result[len(result)-2], ">") &&
-			!strings.HasPrefix(strings.TrimSpace(result[len(result)-1]), "<") {
-			result[len(result)-2] += " " + strings.TrimSpace(result[len(result)-1])
-			result = result[:len(result)-1]
+	}
+	node.RemoveProperties(func(prop DocViewProperty) bool {
+		return prop.Name != JCRMixinTypesProp && matchAnyRule(prop.Name, path, c.PropertiesSkipped)
+	})
+}
+
+func (c Editor) normalizeMixins(path string, propValue string) string {
+	var resultValues []string
+	for _, value := range strings.Split(strings.Trim(propValue, "[]"), ",") {
+		if value != "" && !matchAnyRule(value, path, c.MixinTypesSkipped) {
+			resultValues = append(resultValues, value)
 		}
 	}
-	return c.cleanNamespaces(path, result)
+	if len(resultValues) == 0 {
+		return ""
+	}
+	return "[" + strings.Join(resultValues, ",") + "]"
 }
 
-func (c Editor) cleanNamespaces(path string, lines []string) []string {
+func (c Editor) cleanNamespaces(path string, docView *DocView) {
 	if !c.NamespacesSkipped {
-		return lines
+		return
 	}
 
 	var fileNamespace string
@@ -155,56 +165,11 @@ func (c Editor) cleanNamespaces(path string, lines []string) []string {
 		fileNamespace = groups[1]
 	}
 
-	var result []string
-	for _, line := range lines {
-		if strings.HasPrefix(line, JCRRootPrefix) {
-			var rootResult []string
-			for _, part := range strings.Split(line, " ") {
-				groups = namespacePatternRegex.FindStringSubmatch(part)
-				if groups == nil {
-					rootResult = append(rootResult, part)
-				} else {
-					if lo.SomeBy(lines, func(line string) bool {
-						return strings.Contains(line, groups[1]+":") || groups[1] == fileNamespace
-					}) {
-						rootResult = append(rootResult, part)
-					}
-				}
-			}
-			result = append(result, strings.Join(rootResult, " "))
-		} else {
-			result = append(result, line)
-		}
-	}
-	return result
-}
-
-func (c Editor) lineProcess(path string, line string) (bool, string) {
-	groups := propPatternRegex.FindStringSubmatch(line)
-	if strings.TrimSpace(line) == "" {
-		return true, ""
-	} else if groups == nil {
-		return false, line
-	} else if groups[1] == JCRMixinTypesProp {
-		return c.normalizeMixins(path, line, groups[2], groups[3])
-	} else if matchAnyRule(groups[1], path, c.PropertiesSkipped) {
-		return true, groups[3]
-	}
-	return false, line
-}
-
-func (c Editor) normalizeMixins(path string, line string, propValue string, lineSuffix string) (bool, string) {
-	normalizedValue := strings.Trim(propValue, "[]")
-	var resultValues []string
-	for _, value := range strings.Split(normalizedValue, ",") {
-		if !matchAnyRule(value, path, c.MixinTypesSkipped) {
-			resultValues = append(resultValues, value)
-		}
-	}
-	if len(resultValues) == 0 {
-		return true, lineSuffix
-	}
-	return false, strings.ReplaceAll(line, normalizedValue, strings.Join(resultValues, ","))
+	docView.Root.Walk(func(node *DocViewNode) {
+		node.Namespaces = lo.Filter(node.Namespaces, func(ns DocViewNamespace, _ int) bool {
+			return ns.Prefix == fileNamespace || docView.Root.UsesPrefix(ns.Prefix)
+		})
+	})
 }
 
 func (c Editor) flattenFiles(root string) error {
@@ -290,42 +255,6 @@ func matchString(value string, patterns []string) bool {
 	return stringsx.MatchSome(value, patterns)
 }
 
-func readLines(path string) ([]string, error) {
-	file, err := os.Open(path)
-	if err != nil {
-		return nil, err
-	}
-	defer func() { _ = file.Close() }()
-
-	var lines []string
-	scanner := bufio.NewScanner(file)
-	fileStat, err := file.Stat()
-	if err != nil {
-		return nil, err
-	}
-	if fileStat.Size() > bufio.MaxScanTokenSize {
-		size := fileStat.Size()
-		buffer := make([]byte, size)
-		scanner.Buffer(buffer, int(size))
-	}
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
-	}
-	return lines, scanner.Err()
-}
-
-func writeLines(path string, lines []string) error {
-	file, err := os.Create(path)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = file.Close() }()
-
-	content := strings.Join(lines, "\n") + "\n"
-	_, err = file.WriteString(content)
-	return err
-}
-
 type PathRule struct {
 	Patterns      []string
 	ExcludedPaths []string
@@ -358,12 +287,16 @@ func IsPageContentFile(path string) bool {
 		return false
 	}
 
-	lines, err := readLines(path)
+	docView, err := ReadDocView(path)
 	if err != nil {
 		return false
 	}
 
-	return lo.SomeBy(lines, func(line string) bool {
-		return strings.Contains(line, "cq:PageContent")
+	pageContent := false
+	docView.Root.Walk(func(node *DocViewNode) {
+		if primaryType, _ := node.Property(JCRPrimaryTypeProp); primaryType == "cq:PageContent" {
+			pageContent = true
+		}
 	})
+	return pageContent
 }
@@ -0,0 +1,362 @@
+package content
+
+import (
+	"fmt"
+	"github.com/dustin/go-humanize"
+	"github.com/samber/lo"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cast"
+	"github.com/wttech/aemc/pkg/cfg"
+	"github.com/wttech/aemc/pkg/common/fmtx"
+	"github.com/wttech/aemc/pkg/common/pathx"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	LintSeverityError   = "error"
+	LintSeverityWarning = "warning"
+
+	LintRulePropertyForbidden    = "property-forbidden"
+	LintRuleValueForbidden       = "value-forbidden"
+	LintRulePagePropertyRequired = "page-property-required"
+	LintRuleSuperTypeMissing     = "super-type-missing"
+	LintRuleBinaryOversized      = "binary-oversized"
+)
+
+func LintSeverities() []string {
+	return []string{LintSeverityError, LintSeverityWarning}
+}
+
+// Linter statically checks FileVault content against project policies
+type Linter struct {
+	PropertiesForbidden    []LintPropertyRule
+	ValuesForbidden        []LintValueRule
+	PagePropertiesRequired []string
+	SuperTypesChecked      bool
+	SuperTypesIgnored      []string
+	BinarySizeMax          uint64
+}
+
+type LintPropertyRule struct {
+	PathRule
+	Severity string
+}
+
+type LintValueRule struct {
+	LintPropertyRule
+	Regex   *regexp.Regexp
+	Message string
+}
+
+type LintIssue struct {
+	Rule     string `yaml:"rule" json:"rule"`
+	Severity string `yaml:"severity" json:"severity"`
+	File     string `yaml:"file" json:"file"`
+	Path     string `yaml:"path,omitempty" json:"path,omitempty"`
+	Property string `yaml:"property,omitempty" json:"property,omitempty"`
+	Message  string `yaml:"message" json:"message"`
+}
+
+type LintResult struct {
+	Files  int         `yaml:"files" json:"files"`
+	Issues []LintIssue `yaml:"issues" json:"issues"`
+}
+
+func (r LintResult) Count(severity string) int {
+	return lo.CountBy(r.Issues, func(issue LintIssue) bool { return issue.Severity == severity })
+}
+
+func (r LintResult) MarshalText() string {
+	var sb strings.Builder
+	for _, issue := range r.Issues {
+		sb.WriteString(fmt.Sprintf("%s: %s [%s] %s\n", issue.File, issue.Severity, issue.Rule, issue.Message))
+	}
+	sb.WriteString(fmt.Sprintf("files: %d, errors: %d, warnings: %d\n", r.Files, r.Count(LintSeverityError), r.Count(LintSeverityWarning)))
+	return sb.String()
+}
+
+func NewLinter(config *cfg.Config) (*Linter, error) {
+	cv := config.Values()
+
+	result := &Linter{
+		PagePropertiesRequired: cv.GetStringSlice("content.lint.page_properties_required"),
+		SuperTypesChecked:      cv.GetBool("content.lint.super_types_checked"),
+		SuperTypesIgnored:      cv.GetStringSlice("content.lint.super_types_ignored"),
+	}
+	if sizeMax := cv.GetString("content.lint.binary_size_max"); sizeMax != "" {
+		size, err := humanize.ParseBytes(sizeMax)
+		if err != nil {
+			return nil, fmt.Errorf("content lint binary size max '%s' is invalid: %w", sizeMax, err)
+		}
+		result.BinarySizeMax = size
+	}
+	for _, value := range cast.ToSlice(cv.Get("content.lint.properties_forbidden")) {
+		rule, err := determineLintPropertyRule(value)
+		if err != nil {
+			return nil, err
+		}
+		result.PropertiesForbidden = append(result.PropertiesForbidden, rule)
+	}
+	for _, value := range cast.ToSlice(cv.Get("content.lint.values_forbidden")) {
+		rule, err := determineLintPropertyRule(value)
+		if err != nil {
+			return nil, err
+		}
+		regex := cast.ToString(cast.ToStringMap(value)["regex"])
+		compiled, err := regexp.Compile(regex)
+		if err != nil {
+			return nil, fmt.Errorf("content lint value regex '%s' is invalid: %w", regex, err)
+		}
+		result.ValuesForbidden = append(result.ValuesForbidden, LintValueRule{
+			LintPropertyRule: rule,
+			Regex:            compiled,
+			Message:          cast.ToString(cast.ToStringMap(value)["message"]),
+		})
+	}
+	return result, nil
+}
+
+func determineLintPropertyRule(value any) (LintPropertyRule, error) {
+	severity := cast.ToString(cast.ToStringMap(value)["severity"])
+	if severity == "" {
+		severity = LintSeverityError
+	} else if !lo.Contains(LintSeverities(), severity) {
+		return LintPropertyRule{}, fmt.Errorf("content lint severity '%s' is not supported (supported: %s)", severity, strings.Join(LintSeverities(), ", "))
+	}
+	return LintPropertyRule{
+		PathRule: PathRule{
+			Patterns:      determineStringSlice(value, "patterns"),
+			ExcludedPaths: determineStringSlice(value, "excluded_paths"),
+			IncludedPaths: determineStringSlice(value, "included_paths"),
+		},
+		Severity: severity,
+	}, nil
+}
+
+// Lint checks file or all files in directory located under JCR root
+func (l *Linter) Lint(path string) (*LintResult, error) {
+	if !pathx.Exists(path) {
+		return nil, fmt.Errorf("cannot lint content as path does not exist '%s'", path)
+	}
+	if !strings.Contains(path, JCRRoot) {
+		return nil, fmt.Errorf("cannot lint content as path '%s' is not under '%s'", path, JCRRoot)
+	}
+	root := JCRRootDir(path)
+	log.Infof("linting content '%s'", path)
+	result := &LintResult{Issues: []LintIssue{}}
+	if err := filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		result.Files++
+		return l.lintFile(root, file, result)
+	}); err != nil {
+		return nil, fmt.Errorf("cannot lint content '%s': %w", path, err)
+	}
+	sort.SliceStable(result.Issues, func(i, j int) bool { return result.Issues[i].File < result.Issues[j].File })
+	log.Infof("linted content '%s' (files: %d, errors: %d, warnings: %d)", path, result.Files, result.Count(LintSeverityError), result.Count(LintSeverityWarning))
+	return result, nil
+}
+
+func (l *Linter) lintFile(root string, file string, result *LintResult) error {
+	if !strings.HasSuffix(file, XmlFileSuffix) {
+		return l.lintBinary(file, result)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	docView, err := ParseDocView(data)
+	if err != nil {
+		log.Debugf("skipping linting file '%s' as it is not a document view: %s", file, err)
+		return nil
+	}
+	l.LintDocView(root, file, docView, result)
+	return nil
+}
+
+func (l *Linter) lintBinary(file string, result *LintResult) error {
+	if l.BinarySizeMax == 0 {
+		return nil
+	}
+	binary, err := isBinaryFile(file)
+	if err != nil {
+		return err
+	}
+	if !binary {
+		return nil
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	if uint64(info.Size()) > l.BinarySizeMax {
+		result.Issues = append(result.Issues, LintIssue{
+			Rule:     LintRuleBinaryOversized,
+			Severity: LintSeverityError,
+			File:     file,
+			Message:  fmt.Sprintf("binary size %s exceeds %s", humanize.Bytes(uint64(info.Size())), humanize.Bytes(l.BinarySizeMax)),
+		})
+	}
+	return nil
+}
+
+// isBinaryFile tells if file is an asset rendition or has non-textual MIME type (determined by extension or content) so that e.g. scripts and styles are not treated as binaries
+func isBinaryFile(file string) (bool, error) {
+	if strings.Contains(pathx.Normalize(file), "/_jcr_content/renditions/") {
+		return true, nil
+	}
+	if mimeType := mime.TypeByExtension(filepath.Ext(file)); mimeType != "" {
+		return !isTextMimeType(mimeType), nil
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return false, err
+	}
+	return !isTextMimeType(http.DetectContentType(head[:n])), nil
+}
+
+func isTextMimeType(mimeType string) bool {
+	mimeType, _, _ = strings.Cut(mimeType, ";")
+	return strings.HasPrefix(mimeType, "text/") || lo.Contains([]string{"application/javascript", "application/json", "application/xml", "image/svg+xml"}, strings.TrimSpace(mimeType))
+}
+
+// LintDocView checks nodes of document view file located under JCR root directory
+func (l *Linter) LintDocView(root string, file string, docView *DocView, result *LintResult) {
+	nodePath := DocViewNodePath(root, file)
+	l.lintPage(file, nodePath, docView.Root, result)
+	l.lintNode(root, file, nodePath, docView.Root, result)
+}
+
+func (l *Linter) lintNode(root string, file string, nodePath string, node *DocViewNode, result *LintResult) {
+	for _, prop := range node.Properties {
+		if rule, ok := lo.Find(l.PropertiesForbidden, func(rule LintPropertyRule) bool { return matchRule(prop.Name, file, rule.PathRule) }); ok {
+			result.Issues = append(result.Issues, LintIssue{
+				Rule:     LintRulePropertyForbidden,
+				Severity: rule.Severity,
+				File:     file,
+				Path:     nodePath,
+				Property: prop.Name,
+				Message:  fmt.Sprintf("property '%s' of node '%s' is forbidden", prop.Name, nodePath),
+			})
+		}
+		for _, rule := range l.ValuesForbidden {
+			if matchRule(prop.Name, file, rule.PathRule) && rule.Regex.MatchString(prop.Value) {
+				message := rule.Message
+				if message == "" {
+					message = fmt.Sprintf("value matches '%s'", rule.Regex)
+				}
+				result.Issues = append(result.Issues, LintIssue{
+					Rule:     LintRuleValueForbidden,
+					Severity: rule.Severity,
+					File:     file,
+					Path:     nodePath,
+					Property: prop.Name,
+					Message:  fmt.Sprintf("property '%s' of node '%s' has forbidden value: %s", prop.Name, nodePath, message),
+				})
+			}
+		}
+		if prop.Name == SlingResourceSuperTypeProp && l.SuperTypesChecked && !l.superTypeExists(root, prop.Value) {
+			result.Issues = append(result.Issues, LintIssue{
+				Rule:     LintRuleSuperTypeMissing,
+				Severity: LintSeverityWarning,
+				File:     file,
+				Path:     nodePath,
+				Property: prop.Name,
+				Message:  fmt.Sprintf("resource super type '%s' of node '%s' does not exist in repository tree", prop.Value, nodePath),
+			})
+		}
+	}
+	for _, child := range node.Children {
+		l.lintNode(root, file, strings.TrimSuffix(nodePath, "/")+"/"+child.Name, child, result)
+	}
+}
+
+func (l *Linter) lintPage(file string, nodePath string, node *DocViewNode, result *LintResult) {
+	if primaryType, _ := node.Property(JCRPrimaryTypeProp); primaryType != "cq:Page" || len(l.PagePropertiesRequired) == 0 {
+		return
+	}
+	pageContent, ok := lo.Find(node.Children, func(child *DocViewNode) bool { return child.Name == JCRContentNode })
+	if !ok {
+		return
+	}
+	for _, name := range l.PagePropertiesRequired {
+		if _, found := pageContent.Property(name); !found {
+			result.Issues = append(result.Issues, LintIssue{
+				Rule:     LintRulePagePropertyRequired,
+				Severity: LintSeverityWarning,
+				File:     file,
+				Path:     nodePath,
+				Property: name,
+				Message:  fmt.Sprintf("page '%s' has no property '%s'", nodePath, name),
+			})
+		}
+	}
+}
+
+// superTypeExists looks up resource type under '/apps' and '/libs' of local JCR root (relative ones) or under given path (absolute ones)
+func (l *Linter) superTypeExists(root string, superType string) bool {
+	if superType == "" || matchString(superType, l.SuperTypesIgnored) {
+		return true
+	}
+	var paths []string
+	if strings.HasPrefix(superType, "/") {
+		paths = []string{superType}
+	} else {
+		paths = []string{"/apps/" + superType, "/libs/" + superType}
+	}
+	return lo.SomeBy(paths, func(path string) bool {
+		file := filepath.Join(root, PlatformPath(path))
+		return pathx.Exists(file) || pathx.Exists(file+XmlFileSuffix)
+	})
+}
+
+// WriteSARIF saves result in Static Analysis Results Interchange Format (e.g. to be displayed by code scanning tools)
+func (r LintResult) WriteSARIF(file string) error {
+	rules := lo.Uniq(lo.Map(r.Issues, func(issue LintIssue, _ int) string { return issue.Rule }))
+	sort.Strings(rules)
+	results := lo.Map(r.Issues, func(issue LintIssue, _ int) map[string]any {
+		return map[string]any{
+			"ruleId":  issue.Rule,
+			"level":   issue.Severity,
+			"message": map[string]any{"text": issue.Message},
+			"locations": []any{map[string]any{
+				"physicalLocation": map[string]any{
+					"artifactLocation": map[string]any{"uri": filepath.ToSlash(issue.File)},
+				},
+			}},
+		}
+	})
+	sarif := map[string]any{
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"version": "2.1.0",
+		"runs": []any{map[string]any{
+			"tool": map[string]any{"driver": map[string]any{
+				"name":  "aemc",
+				"rules": lo.Map(rules, func(rule string, _ int) map[string]any { return map[string]any{"id": rule} }),
+			}},
+			"results": results,
+		}},
+	}
+	if err := fmtx.MarshalToFileInFormat(fmtx.JSON, file, sarif); err != nil {
+		return fmt.Errorf("cannot write content lint SARIF report to file '%s': %w", file, err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package content_test
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/wttech/aemc/pkg/content"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestLinterLint(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	root := filepath.Join(t.TempDir(), content.JCRRoot)
+	writeTestFile(t, filepath.Join(root, "apps", "site", "components", "base", ".content.xml"), `<?xml version="1.0" encoding="UTF-8"?>
+<jcr:root xmlns:jcr="http://www.jcp.org/jcr/1.0" jcr:primaryType="cq:Component"/>
+`)
+	writeTestFile(t, filepath.Join(root, "apps", "site", "components", "teaser", ".content.xml"), `<?xml version="1.0" encoding="UTF-8"?>
+<jcr:root xmlns:sling="http://sling.apache.org/jcr/sling/1.0" xmlns:jcr="http://www.jcp.org/jcr/1.0"
+    jcr:primaryType="cq:Component"
+    sling:resourceSuperType="site/components/base"/>
+`)
+	writeTestFile(t, filepath.Join(root, "apps", "site", "components", "title", ".content.xml"), `<?xml version="1.0" encoding="UTF-8"?>
+<jcr:root xmlns:sling="http://sling.apache.org/jcr/sling/1.0" xmlns:jcr="http://www.jcp.org/jcr/1.0"
+    jcr:primaryType="cq:Component"
+    sling:resourceSuperType="site/components/missing"/>
+`)
+	writeTestFile(t, filepath.Join(root, "content", "site", "en", ".content.xml"), `<?xml version="1.0" encoding="UTF-8"?>
+<jcr:root xmlns:cq="http://www.day.com/jcr/cq/1.0" xmlns:jcr="http://www.jcp.org/jcr/1.0"
+    jcr:primaryType="cq:Page">
+    <jcr:content
+        jcr:primaryType="cq:PageContent"
+        jcr:uuid="123"
+        link="http://author.company.com:4502/content/site/de.html"/>
+</jcr:root>
+`)
+	writeTestFile(t, filepath.Join(root, "content", "dam", "site", "big.png", "_jcr_content", "renditions", "original"), strings.Repeat("x", 2048))
+	writeTestFile(t, filepath.Join(root, "apps", "site", "clientlibs", "site.js"), strings.Repeat("x", 2048))
+
+	linter := &content.Linter{
+		PropertiesForbidden:    []content.LintPropertyRule{{PathRule: content.PathRule{Patterns: []string{"jcr:uuid"}}, Severity: content.LintSeverityError}},
+		ValuesForbidden:        []content.LintValueRule{{LintPropertyRule: content.LintPropertyRule{PathRule: content.PathRule{Patterns: []string{"*"}}, Severity: content.LintSeverityError}, Regex: regexp.MustCompile(`https?://author`), Message: "absolute author URL"}},
+		PagePropertiesRequired: []string{"jcr:title"},
+		SuperTypesChecked:      true,
+		BinarySizeMax:          1024,
+	}
+	result, err := linter.Lint(root)
+	a.NoError(err)
+	a.Equal(6, result.Files)
+	a.Equal(3, result.Count(content.LintSeverityError))
+	a.Equal(2, result.Count(content.LintSeverityWarning))
+
+	rules := map[string]string{}
+	for _, issue := range result.Issues {
+		rules[issue.Rule] = issue.Path
+	}
+	a.Equal(map[string]string{
+		content.LintRuleSuperTypeMissing:     "/apps/site/components/title",
+		content.LintRulePagePropertyRequired: "/content/site/en",
+		content.LintRulePropertyForbidden:    "/content/site/en/jcr:content",
+		content.LintRuleValueForbidden:       "/content/site/en/jcr:content",
+		content.LintRuleBinaryOversized:      "",
+	}, rules)
+
+	sarifFile := filepath.Join(t.TempDir(), "lint.sarif")
+	a.NoError(result.WriteSARIF(sarifFile))
+	data, err := os.ReadFile(sarifFile)
+	a.NoError(err)
+	var sarif map[string]any
+	a.NoError(json.Unmarshal(data, &sarif))
+	a.Equal("2.1.0", sarif["version"])
+	a.Len(sarif["runs"].([]any)[0].(map[string]any)["results"], 5)
+}
@@ -0,0 +1,295 @@
+package content
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/samber/lo"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cast"
+	"github.com/wttech/aemc/pkg/cfg"
+	"github.com/wttech/aemc/pkg/common/fmtx"
+	"github.com/wttech/aemc/pkg/common/pathx"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	ScrubHash  = "hash"
+	ScrubFake  = "fake"
+	ScrubBlank = "blank"
+	ScrubDrop  = "drop"
+
+	ScrubFakeName  = "name"
+	ScrubFakeEmail = "email"
+	ScrubFakePhone = "phone"
+	ScrubFakeText  = "text"
+)
+
+func ScrubStrategies() []string {
+	return []string{ScrubHash, ScrubFake, ScrubBlank, ScrubDrop}
+}
+
+func ScrubFakes() []string {
+	return []string{ScrubFakeName, ScrubFakeEmail, ScrubFakePhone, ScrubFakeText}
+}
+
+var (
+	scrubFakeFirstNames = []string{"Alex", "Sam", "Robin", "Jordan", "Taylor", "Casey", "Morgan", "Jamie", "Charlie", "Avery"}
+	scrubFakeLastNames  = []string{"Smith", "Jones", "Brown", "Miller", "Davis", "Wilson", "Moore", "Clark", "Lewis", "Walker"}
+)
+
+// Scrubber anonymises personal data in unpacked package content (e.g. before copying production content to lower environments)
+type Scrubber struct {
+	Rules []ScrubRule
+	Salt  string
+
+	Report ScrubReport
+}
+
+// ScrubRule matches properties by name patterns on nodes matching path patterns; rule without property patterns matches whole nodes (only 'drop' strategy is supported then)
+type ScrubRule struct {
+	PathRule
+	Strategy string
+	Fake     string
+}
+
+type ScrubReport struct {
+	Items []ScrubReportItem `yaml:"items" json:"items"`
+}
+
+type ScrubReportItem struct {
+	Path     string `yaml:"path" json:"path"`
+	Property string `yaml:"property,omitempty" json:"property,omitempty"`
+	Strategy string `yaml:"strategy" json:"strategy"`
+}
+
+func (r ScrubReport) MarshalText() string {
+	var sb strings.Builder
+	for _, item := range r.Items {
+		if item.Property == "" {
+			sb.WriteString(fmt.Sprintf("%s (%s)\n", item.Path, item.Strategy))
+		} else {
+			sb.WriteString(fmt.Sprintf("%s/%s (%s)\n", item.Path, item.Property, item.Strategy))
+		}
+	}
+	return sb.String()
+}
+
+func NewScrubber(config *cfg.Config) (*Scrubber, error) {
+	return newScrubber(config.Values().Get("content.scrub"))
+}
+
+// ReadScrubber reads profile from YAML file having the same structure as 'content.scrub' config section
+func ReadScrubber(file string) (*Scrubber, error) {
+	values := map[string]any{}
+	if err := fmtx.UnmarshalFileInFormat(fmtx.YML, file, &values); err != nil {
+		return nil, fmt.Errorf("cannot read content scrub profile from file '%s': %w", file, err)
+	}
+	result, err := newScrubber(values)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read content scrub profile from file '%s': %w", file, err)
+	}
+	return result, nil
+}
+
+func newScrubber(values any) (*Scrubber, error) {
+	config := cast.ToStringMap(values)
+	result := &Scrubber{Salt: cast.ToString(config["salt"])}
+	for _, value := range cast.ToSlice(config["rules"]) {
+		ruleConfig := cast.ToStringMap(value)
+		rule := ScrubRule{
+			PathRule: PathRule{
+				Patterns:      determineStringSlice(value, "patterns"),
+				ExcludedPaths: determineStringSlice(value, "excluded_paths"),
+				IncludedPaths: determineStringSlice(value, "included_paths"),
+			},
+			Strategy: cast.ToString(ruleConfig["strategy"]),
+			Fake:     cast.ToString(ruleConfig["fake"]),
+		}
+		if !lo.Contains(ScrubStrategies(), rule.Strategy) {
+			return nil, fmt.Errorf("scrub strategy '%s' is not supported (supported: %s)", rule.Strategy, strings.Join(ScrubStrategies(), ", "))
+		}
+		if rule.Strategy == ScrubFake {
+			if rule.Fake == "" {
+				rule.Fake = ScrubFakeText
+			} else if !lo.Contains(ScrubFakes(), rule.Fake) {
+				return nil, fmt.Errorf("scrub fake '%s' is not supported (supported: %s)", rule.Fake, strings.Join(ScrubFakes(), ", "))
+			}
+		}
+		if len(rule.Patterns) == 0 && (rule.Strategy != ScrubDrop || len(rule.IncludedPaths) == 0) {
+			return nil, fmt.Errorf("scrub rule without property patterns should use strategy '%s' and included paths", ScrubDrop)
+		}
+		if (rule.Strategy == ScrubHash || rule.Strategy == ScrubFake) && result.Salt == "" {
+			return nil, fmt.Errorf("scrub strategy '%s' requires salt to be set (otherwise scrubbed values could be reversed by hashing guessed ones)", rule.Strategy)
+		}
+		result.Rules = append(result.Rules, rule)
+	}
+	return result, nil
+}
+
+func (s *Scrubber) Empty() bool {
+	return len(s.Rules) == 0
+}
+
+// Scrub anonymises package content unpacked into directory (containing 'jcr_root')
+func (s *Scrubber) Scrub(dir string) error {
+	root := filepath.Join(dir, JCRRoot)
+	if !pathx.Exists(root) {
+		return nil
+	}
+	log.Infof("scrubbing content in directory '%s'", dir)
+	count := len(s.Report.Items)
+	if err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path == root {
+				return nil
+			}
+			nodePath := DocViewNodePath(root, filepath.Join(path, JCRContentFile))
+			if s.nodeDropped(nodePath) {
+				log.Infof("dropping content directory '%s'", path)
+				s.report(nodePath, "", ScrubDrop)
+				if err := os.RemoveAll(path); err != nil {
+					return err
+				}
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, XmlFileSuffix) {
+			return nil
+		}
+		return s.scrubFile(root, path)
+	}); err != nil {
+		return fmt.Errorf("cannot scrub content in directory '%s': %w", dir, err)
+	}
+	log.Infof("scrubbed content in directory '%s' (changes: %d)", dir, len(s.Report.Items)-count)
+	return nil
+}
+
+func (s *Scrubber) scrubFile(root string, file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	docView, err := ParseDocView(data)
+	if err != nil {
+		log.Debugf("skipping scrubbing file '%s' as it is not a document view: %s", file, err)
+		return nil
+	}
+	nodePath := DocViewNodePath(root, file)
+	if filepath.Base(file) != JCRContentFile && s.nodeDropped(nodePath) {
+		log.Infof("dropping content file '%s'", file)
+		s.report(nodePath, "", ScrubDrop)
+		return os.Remove(file)
+	}
+	if !s.ScrubDocView(docView, nodePath) {
+		return nil
+	}
+	log.Infof("scrubbing content file '%s'", file)
+	return docView.Write(file)
+}
+
+// ScrubDocView anonymises nodes of document view stored under JCR path; returns true if anything changed
+func (s *Scrubber) ScrubDocView(docView *DocView, jcrPath string) bool {
+	count := len(s.Report.Items)
+	s.scrubNode(docView.Root, jcrPath)
+	return len(s.Report.Items) != count
+}
+
+func (s *Scrubber) scrubNode(node *DocViewNode, nodePath string) {
+	var properties []DocViewProperty
+	for _, prop := range node.Properties {
+		rule, ok := s.propertyRule(prop.Name, nodePath)
+		if !ok {
+			properties = append(properties, prop)
+			continue
+		}
+		s.report(nodePath, prop.Name, rule.Strategy)
+		if rule.Strategy == ScrubDrop {
+			continue
+		}
+		properties = append(properties, DocViewProperty{Name: prop.Name, Value: s.scrubValue(prop.Value, rule)})
+	}
+	node.Properties = properties
+
+	var children []*DocViewNode
+	for _, child := range node.Children {
+		childPath := strings.TrimSuffix(nodePath, "/") + "/" + child.Name
+		if s.nodeDropped(childPath) {
+			s.report(childPath, "", ScrubDrop)
+			continue
+		}
+		s.scrubNode(child, childPath)
+		children = append(children, child)
+	}
+	node.Children = children
+}
+
+func (s *Scrubber) propertyRule(name string, nodePath string) (ScrubRule, bool) {
+	return lo.Find(s.Rules, func(rule ScrubRule) bool {
+		return len(rule.Patterns) > 0 && matchRule(name, nodePath, rule.PathRule)
+	})
+}
+
+func (s *Scrubber) nodeDropped(nodePath string) bool {
+	return lo.SomeBy(s.Rules, func(rule ScrubRule) bool {
+		return len(rule.Patterns) == 0 && rule.Strategy == ScrubDrop && matchString(nodePath, rule.IncludedPaths) && !matchString(nodePath, rule.ExcludedPaths)
+	})
+}
+
+func (s *Scrubber) report(path string, property string, strategy string) {
+	s.Report.Items = append(s.Report.Items, ScrubReportItem{Path: path, Property: property, Strategy: strategy})
+}
+
+// scrubValue keeps value type and multi-value brackets; values of non-string types are blanked (type is kept) as they cannot be faked or hashed
+func (s *Scrubber) scrubValue(value string, rule ScrubRule) string {
+	parsed := ParseDocViewValue(value)
+	if parsed.Type != "" && parsed.Type != "String" {
+		if parsed.Multiple {
+			parsed.Values = []string{}
+		} else {
+			parsed.Values = []string{""}
+		}
+		return parsed.String()
+	}
+	for i, item := range parsed.Values {
+		parsed.Values[i] = s.scrubString(item, rule)
+	}
+	return parsed.String()
+}
+
+// scrubString is deterministic so that the same values are scrubbed the same way (references between nodes are preserved)
+func (s *Scrubber) scrubString(value string, rule ScrubRule) string {
+	if value == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(s.Salt + value))
+	hash := hex.EncodeToString(sum[:])
+	switch rule.Strategy {
+	case ScrubHash:
+		return hash[:16]
+	case ScrubFake:
+		seed, _ := strconv.ParseUint(hash[:8], 16, 64)
+		first := scrubFakeFirstNames[seed%uint64(len(scrubFakeFirstNames))]
+		last := scrubFakeLastNames[(seed/10)%uint64(len(scrubFakeLastNames))]
+		switch rule.Fake {
+		case ScrubFakeName:
+			return first + " " + last
+		case ScrubFakeEmail:
+			return strings.ToLower(first+"."+last) + "." + hash[:6] + "@example.com"
+		case ScrubFakePhone:
+			return fmt.Sprintf("+1-555-01%02d", seed%100)
+		default:
+			return "scrubbed-" + hash[:8]
+		}
+	default:
+		return ""
+	}
+}
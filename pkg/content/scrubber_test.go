@@ -0,0 +1,115 @@
+package content_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/wttech/aemc/pkg/content"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestScrubberScrub(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	dir := t.TempDir()
+	userDir := filepath.Join(dir, content.JCRRoot, "home", "users", "a", "abc")
+	writeTestFile(t, filepath.Join(userDir, "profile", ".content.xml"), `<?xml version="1.0" encoding="UTF-8"?>
+<jcr:root xmlns:jcr="http://www.jcp.org/jcr/1.0"
+    jcr:primaryType="nt:unstructured"
+    email="john.doe@company.com"
+    familyName="Doe"
+    givenName="John"
+    phoneNumber="{String}123456"
+    street="Main Street">
+    <private
+        jcr:primaryType="nt:unstructured"
+        secret="x"/>
+</jcr:root>
+`)
+	writeTestFile(t, filepath.Join(userDir, "profile", "photos", "primary", "image.png"), "binary")
+
+	scrubber := &content.Scrubber{Rules: []content.ScrubRule{
+		{PathRule: content.PathRule{Patterns: []string{"givenName"}, IncludedPaths: []string{"/home/users/*"}}, Strategy: content.ScrubFake, Fake: content.ScrubFakeName},
+		{PathRule: content.PathRule{Patterns: []string{"email"}, IncludedPaths: []string{"/home/users/*"}}, Strategy: content.ScrubHash},
+		{PathRule: content.PathRule{Patterns: []string{"phoneNumber"}, IncludedPaths: []string{"/home/users/*"}}, Strategy: content.ScrubBlank},
+		{PathRule: content.PathRule{Patterns: []string{"street"}, IncludedPaths: []string{"/home/users/*"}}, Strategy: content.ScrubDrop},
+		{PathRule: content.PathRule{IncludedPaths: []string{"/home/users/*/profile/photos", "/home/users/*/private"}}, Strategy: content.ScrubDrop},
+	}}
+	a.NoError(scrubber.Scrub(dir))
+
+	a.NoDirExists(filepath.Join(userDir, "profile", "photos"))
+	docView, err := content.ReadDocView(filepath.Join(userDir, "profile", ".content.xml"))
+	a.NoError(err)
+	a.Empty(docView.Root.Children)
+
+	givenName, _ := docView.Root.Property("givenName")
+	a.NotEqual("John", givenName)
+	a.Regexp(`^\w+ \w+$`, givenName)
+	email, _ := docView.Root.Property("email")
+	a.Regexp(`^[0-9a-f]{16}$`, email)
+	phoneNumber, _ := docView.Root.Property("phoneNumber")
+	a.Equal("{String}", phoneNumber)
+	_, streetFound := docView.Root.Property("street")
+	a.False(streetFound)
+	familyName, _ := docView.Root.Property("familyName")
+	a.Equal("Doe", familyName)
+
+	a.Equal(`/home/users/a/abc/profile/email (hash)
+/home/users/a/abc/profile/givenName (fake)
+/home/users/a/abc/profile/phoneNumber (blank)
+/home/users/a/abc/profile/street (drop)
+/home/users/a/abc/profile/private (drop)
+/home/users/a/abc/profile/photos (drop)
+`, scrubber.Report.MarshalText())
+}
+
+func TestScrubberDeterministic(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	scrubber := &content.Scrubber{Salt: "s", Rules: []content.ScrubRule{
+		{PathRule: content.PathRule{Patterns: []string{"email"}}, Strategy: content.ScrubFake, Fake: content.ScrubFakeEmail},
+	}}
+	scrub := func(value string) string {
+		docView := &content.DocView{Root: &content.DocViewNode{Name: content.DocViewRootNode, Properties: []content.DocViewProperty{{Name: "email", Value: value}}}}
+		a.True(scrubber.ScrubDocView(docView, "/home/users/x"))
+		result, _ := docView.Root.Property("email")
+		return result
+	}
+	a.Equal(scrub("a@company.com"), scrub("a@company.com"))
+	a.NotEqual(scrub("a@company.com"), scrub("b@company.com"))
+	a.Regexp(`@example\.com]$`, scrub("[a@company.com,b@company.com]"))
+}
+
+func TestScrubberValueTypes(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	scrubber := &content.Scrubber{Salt: "s", Rules: []content.ScrubRule{
+		{PathRule: content.PathRule{Patterns: []string{"*"}}, Strategy: content.ScrubHash},
+	}}
+	scrub := func(value string) string {
+		docView := &content.DocView{Root: &content.DocViewNode{Name: content.DocViewRootNode, Properties: []content.DocViewProperty{{Name: "value", Value: value}}}}
+		a.True(scrubber.ScrubDocView(docView, "/home/users/x"))
+		result, _ := docView.Root.Property("value")
+		return result
+	}
+	a.Regexp(`^\[[0-9a-f]{16},[0-9a-f]{16}]$`, scrub(`[Doe\, John,Smith\, Jane]`))
+	a.Equal("{Long}", scrub("{Long}42"))
+	a.Equal("{Date}[]", scrub("{Date}[2024-01-01T00:00:00.000+01:00]"))
+}
+
+func TestReadScrubberRequiresSalt(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	file := filepath.Join(t.TempDir(), "scrub.yml")
+	a.NoError(os.WriteFile(file, []byte("rules:\n  - patterns: [email]\n    strategy: hash\n"), 0644))
+	_, err := content.ReadScrubber(file)
+	a.ErrorContains(err, "requires salt")
+
+	a.NoError(os.WriteFile(file, []byte("salt: s\nrules:\n  - patterns: [email]\n    strategy: hash\n"), 0644))
+	_, err = content.ReadScrubber(file)
+	a.NoError(err)
+}
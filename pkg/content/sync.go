@@ -0,0 +1,162 @@
+package content
+
+import (
+	"fmt"
+	"github.com/samber/lo"
+	"github.com/wttech/aemc/pkg/common/filex"
+	"github.com/wttech/aemc/pkg/common/fmtx"
+	"github.com/wttech/aemc/pkg/common/pathx"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	SyncStateFile      = ".aem-sync"
+	SyncConflictFail   = "fail"
+	SyncConflictLocal  = "local"
+	SyncConflictRemote = "remote"
+)
+
+func SyncConflictStrategies() []string {
+	return []string{SyncConflictFail, SyncConflictLocal, SyncConflictRemote}
+}
+
+// SyncState is a baseline of content files (checksums of cleaned files keyed by paths relative to JCR root) as they were after the last pull or push
+type SyncState struct {
+	Files map[string]string `yaml:"files" json:"files"`
+}
+
+// SyncStatePath determines state file location next to the JCR root directory (so that it is never pushed as content)
+func SyncStatePath(path string) string {
+	before, _, _ := strings.Cut(path, JCRRoot)
+	return filepath.Join(before, SyncStateFile)
+}
+
+// JCRRootDir determines JCR root directory of path located under it
+func JCRRootDir(path string) string {
+	before, _, _ := strings.Cut(path, JCRRoot)
+	return before + JCRRoot
+}
+
+// SyncPath determines path relative to the JCR root directory
+func SyncPath(path string) string {
+	_, after, _ := strings.Cut(pathx.Normalize(path), JCRRoot)
+	return strings.Trim(after, "/")
+}
+
+func ReadSyncState(path string) (*SyncState, error) {
+	file := SyncStatePath(path)
+	state := &SyncState{Files: map[string]string{}}
+	if !pathx.Exists(file) {
+		return state, nil
+	}
+	if err := fmtx.UnmarshalFileInFormat(fmtx.YML, file, state); err != nil {
+		return nil, fmt.Errorf("cannot read content sync state: %w", err)
+	}
+	if state.Files == nil {
+		state.Files = map[string]string{}
+	}
+	return state, nil
+}
+
+func (s *SyncState) Write(path string) error {
+	file := SyncStatePath(path)
+	if err := fmtx.MarshalToFileInFormat(fmtx.YML, file, s); err != nil {
+		return fmt.Errorf("cannot write content sync state: %w", err)
+	}
+	return nil
+}
+
+// Scope returns checksums of files being one of given paths or located under them
+func (s *SyncState) Scope(paths []string) map[string]string {
+	return lo.PickBy(s.Files, func(file string, _ string) bool { return syncPathInScope(file, paths) })
+}
+
+// Update replaces checksums of files in given scope
+func (s *SyncState) Update(paths []string, files map[string]string) {
+	s.Files = lo.OmitBy(s.Files, func(file string, _ string) bool { return syncPathInScope(file, paths) })
+	for file, checksum := range files {
+		if syncPathInScope(file, paths) {
+			s.Files[file] = checksum
+		}
+	}
+}
+
+func syncPathInScope(file string, paths []string) bool {
+	return lo.SomeBy(paths, func(path string) bool {
+		return path == "" || file == path || strings.HasPrefix(file, path+"/")
+	})
+}
+
+// SyncChecksums calculates checksums of files under path (file or directory located under JCR root)
+func SyncChecksums(path string) (map[string]string, error) {
+	result := map[string]string{}
+	if !pathx.Exists(path) {
+		return result, nil
+	}
+	if err := filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		checksum, err := filex.ChecksumFile(file)
+		if err != nil {
+			return err
+		}
+		result[SyncPath(file)] = checksum
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("cannot calculate checksums of content files under '%s': %w", path, err)
+	}
+	return result, nil
+}
+
+// SyncComparison is a result of three-way comparison of local and remote files against the baseline
+type SyncComparison struct {
+	LocalChanged  []string `yaml:"local_changed" json:"localChanged"`
+	RemoteChanged []string `yaml:"remote_changed" json:"remoteChanged"`
+	Conflicts     []string `yaml:"conflicts" json:"conflicts"`
+}
+
+func CompareSync(base map[string]string, local map[string]string, remote map[string]string) SyncComparison {
+	result := SyncComparison{LocalChanged: []string{}, RemoteChanged: []string{}, Conflicts: []string{}}
+	files := lo.Uniq(append(append(lo.Keys(base), lo.Keys(local)...), lo.Keys(remote)...))
+	sort.Strings(files)
+	for _, file := range files {
+		localChanged := local[file] != base[file]
+		remoteChanged := remote[file] != base[file]
+		if localChanged && remoteChanged {
+			if local[file] != remote[file] {
+				result.Conflicts = append(result.Conflicts, file)
+			}
+		} else if localChanged {
+			result.LocalChanged = append(result.LocalChanged, file)
+		} else if remoteChanged {
+			result.RemoteChanged = append(result.RemoteChanged, file)
+		}
+	}
+	return result
+}
+
+// Resolve determines remote files to be taken locally; with 'fail' strategy conflicts are reported as error
+func (c SyncComparison) Resolve(strategy string) ([]string, error) {
+	switch strategy {
+	case SyncConflictFail, "":
+		if len(c.Conflicts) > 0 {
+			return nil, fmt.Errorf("content files changed both locally and remotely (use conflict strategy '%s' or '%s' to resolve them):\n%s", SyncConflictLocal, SyncConflictRemote, strings.Join(c.Conflicts, "\n"))
+		}
+		return c.RemoteChanged, nil
+	case SyncConflictLocal:
+		return c.RemoteChanged, nil
+	case SyncConflictRemote:
+		result := append(append([]string{}, c.RemoteChanged...), c.Conflicts...)
+		sort.Strings(result)
+		return result, nil
+	default:
+		return nil, fmt.Errorf("unsupported content sync conflict strategy '%s' (supported: %s)", strategy, strings.Join(SyncConflictStrategies(), ", "))
+	}
+}
@@ -0,0 +1,59 @@
+package content_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/wttech/aemc/pkg/content"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompareSync(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	base := map[string]string{"a.xml": "1", "b.xml": "1", "c.xml": "1", "d.xml": "1", "e.xml": "1"}
+	local := map[string]string{"a.xml": "1", "b.xml": "2", "c.xml": "1", "d.xml": "2", "e.xml": "3", "new.xml": "1"}
+	remote := map[string]string{"a.xml": "1", "b.xml": "1", "d.xml": "3", "e.xml": "3"}
+
+	comparison := content.CompareSync(base, local, remote)
+	a.Equal([]string{"b.xml", "new.xml"}, comparison.LocalChanged)
+	a.Equal([]string{"c.xml"}, comparison.RemoteChanged)
+	a.Equal([]string{"d.xml"}, comparison.Conflicts)
+
+	_, err := comparison.Resolve(content.SyncConflictFail)
+	a.Error(err)
+	files, err := comparison.Resolve(content.SyncConflictLocal)
+	a.NoError(err)
+	a.Equal([]string{"c.xml"}, files)
+	files, err = comparison.Resolve(content.SyncConflictRemote)
+	a.NoError(err)
+	a.Equal([]string{"c.xml", "d.xml"}, files)
+	_, err = comparison.Resolve("unknown")
+	a.Error(err)
+}
+
+func TestSyncState(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	moduleDir := filepath.Join(t.TempDir(), "ui.content")
+	dir := filepath.Join(moduleDir, "jcr_root", "content", "site")
+	a.Equal("content/site", content.SyncPath(dir))
+	a.Equal(filepath.Join(moduleDir, "jcr_root"), content.JCRRootDir(dir))
+	a.Equal(filepath.Join(moduleDir, content.SyncStateFile), content.SyncStatePath(dir))
+
+	state, err := content.ReadSyncState(dir)
+	a.NoError(err)
+	a.Empty(state.Files)
+
+	state.Files = map[string]string{"content/site/.content.xml": "1", "content/site/en/.content.xml": "1", "content/site-b/.content.xml": "1"}
+	a.Equal(map[string]string{"content/site/.content.xml": "1", "content/site/en/.content.xml": "1"}, state.Scope([]string{"content/site"}))
+
+	state.Update([]string{"content/site"}, map[string]string{"content/site/.content.xml": "2", "content/other/.content.xml": "2"})
+	a.Equal(map[string]string{"content/site/.content.xml": "2", "content/site-b/.content.xml": "1"}, state.Files)
+
+	a.NoError(state.Write(dir))
+	read, err := content.ReadSyncState(dir)
+	a.NoError(err)
+	a.Equal(state.Files, read.Files)
+}
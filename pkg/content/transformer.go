@@ -0,0 +1,347 @@
+package content
+
+import (
+	"fmt"
+	"github.com/samber/lo"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cast"
+	"github.com/wttech/aemc/pkg/cfg"
+	"github.com/wttech/aemc/pkg/common/fmtx"
+	"github.com/wttech/aemc/pkg/common/pathx"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	SlingResourceTypeProp      = "sling:resourceType"
+	SlingResourceSuperTypeProp = "sling:resourceSuperType"
+	VltFilterFile              = "META-INF/vault/filter.xml"
+)
+
+var (
+	filterPathAttrRegex  = regexp.MustCompile(`((?:root|pattern)=")([^"]*)(")`)
+	platformSegmentRegex = regexp.MustCompile(`^_([a-zA-Z0-9]+)_(.+)$`)
+)
+
+// Transformer rewrites unpacked package content (e.g. to clone a site under another path or to rename components)
+type Transformer struct {
+	PathMappings         []TransformMapping
+	PropertyReplacements []PropertyReplacement
+	ResourceTypeRenames  []TransformMapping
+	NodeTypesDropped     []string
+}
+
+type TransformMapping struct {
+	From string
+	To   string
+}
+
+type PropertyReplacement struct {
+	PathRule
+	Regex       *regexp.Regexp
+	Replacement string
+}
+
+func NewTransformer(config *cfg.Config) (*Transformer, error) {
+	return newTransformer(config.Values().Get("content.transform"))
+}
+
+// ReadTransformer reads rules from YAML file having the same structure as 'content.transform' config section
+func ReadTransformer(file string) (*Transformer, error) {
+	values := map[string]any{}
+	if err := fmtx.UnmarshalFileInFormat(fmtx.YML, file, &values); err != nil {
+		return nil, fmt.Errorf("cannot read content transform rules from file '%s': %w", file, err)
+	}
+	result, err := newTransformer(values)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read content transform rules from file '%s': %w", file, err)
+	}
+	return result, nil
+}
+
+func newTransformer(values any) (*Transformer, error) {
+	config := cast.ToStringMap(values)
+	result := &Transformer{
+		PathMappings:        determineTransformMappings(config["path_mappings"]),
+		ResourceTypeRenames: determineTransformMappings(config["resource_type_renames"]),
+		NodeTypesDropped:    cast.ToStringSlice(config["node_types_dropped"]),
+	}
+	for _, mapping := range result.PathMappings {
+		if !strings.HasPrefix(mapping.From, "/") || !strings.HasPrefix(mapping.To, "/") {
+			return nil, fmt.Errorf("path mapping '%s' -> '%s' should use absolute paths", mapping.From, mapping.To)
+		}
+	}
+	for _, value := range cast.ToSlice(config["property_replacements"]) {
+		regex := cast.ToString(cast.ToStringMap(value)["regex"])
+		compiled, err := regexp.Compile(regex)
+		if err != nil {
+			return nil, fmt.Errorf("property replacement regex '%s' is invalid: %w", regex, err)
+		}
+		result.PropertyReplacements = append(result.PropertyReplacements, PropertyReplacement{
+			PathRule: PathRule{
+				Patterns:      determineStringSlice(value, "patterns"),
+				ExcludedPaths: determineStringSlice(value, "excluded_paths"),
+				IncludedPaths: determineStringSlice(value, "included_paths"),
+			},
+			Regex:       compiled,
+			Replacement: cast.ToString(cast.ToStringMap(value)["replacement"]),
+		})
+	}
+	return result, nil
+}
+
+func determineTransformMappings(values any) []TransformMapping {
+	var result []TransformMapping
+	for _, value := range cast.ToSlice(values) {
+		mapping := cast.ToStringMap(value)
+		result = append(result, TransformMapping{
+			From: strings.TrimSuffix(cast.ToString(mapping["from"]), "/"),
+			To:   strings.TrimSuffix(cast.ToString(mapping["to"]), "/"),
+		})
+	}
+	return result
+}
+
+func (t *Transformer) Empty() bool {
+	return len(t.PathMappings) == 0 && len(t.PropertyReplacements) == 0 && len(t.ResourceTypeRenames) == 0 && len(t.NodeTypesDropped) == 0
+}
+
+// MapPath applies first path mapping matching JCR path
+func (t *Transformer) MapPath(jcrPath string) string {
+	for _, mapping := range t.PathMappings {
+		if mapped, ok := replacePathPrefix(jcrPath, mapping.From, mapping.To); ok {
+			return mapped
+		}
+	}
+	return jcrPath
+}
+
+// UnmapPath is the reverse of MapPath (e.g. to determine source paths of content to be pulled into mapped location)
+func (t *Transformer) UnmapPath(jcrPath string) string {
+	for _, mapping := range t.PathMappings {
+		if mapped, ok := replacePathPrefix(jcrPath, mapping.To, mapping.From); ok {
+			return mapped
+		}
+	}
+	return jcrPath
+}
+
+func replacePathPrefix(value string, from string, to string) (string, bool) {
+	if value == from {
+		return to, true
+	}
+	if strings.HasPrefix(value, from+"/") {
+		return to + strings.TrimPrefix(value, from), true
+	}
+	return value, false
+}
+
+// Transform rewrites package content unpacked into directory (containing 'jcr_root' and optionally 'META-INF')
+func (t *Transformer) Transform(dir string) error {
+	root := filepath.Join(dir, JCRRoot)
+	if !pathx.Exists(root) {
+		return nil
+	}
+	log.Infof("transforming content in directory '%s'", dir)
+	if err := t.transformFiles(root); err != nil {
+		return err
+	}
+	if err := t.moveFiles(root); err != nil {
+		return err
+	}
+	if err := t.transformFilter(filepath.Join(dir, VltFilterFile)); err != nil {
+		return err
+	}
+	log.Infof("transformed content in directory '%s'", dir)
+	return nil
+}
+
+func (t *Transformer) transformFiles(root string) error {
+	var files []string
+	if err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() && strings.HasSuffix(path, XmlFileSuffix) {
+			files = append(files, path)
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("cannot list content files under '%s': %w", root, err)
+	}
+	for _, file := range files {
+		if !pathx.Exists(file) {
+			continue
+		}
+		if err := t.transformFile(root, file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Transformer) transformFile(root string, file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("cannot read content file '%s': %w", file, err)
+	}
+	docView, err := ParseDocView(data)
+	if err != nil {
+		log.Debugf("skipping transforming file '%s' as it is not a document view: %s", file, err)
+		return nil
+	}
+	if t.nodeDropped(docView.Root) {
+		if filepath.Base(file) == JCRContentFile {
+			log.Infof("dropping content directory '%s'", filepath.Dir(file))
+			return os.RemoveAll(filepath.Dir(file))
+		}
+		log.Infof("dropping content file '%s'", file)
+		return os.Remove(file)
+	}
+	if !t.TransformDocView(docView, DocViewNodePath(root, file), file) {
+		return nil
+	}
+	log.Infof("transforming content file '%s'", file)
+	return docView.Write(file)
+}
+
+// TransformDocView applies rules to nodes of document view stored under JCR path; returns true if anything changed
+func (t *Transformer) TransformDocView(docView *DocView, jcrPath string, file string) bool {
+	before := docView.String()
+	t.transformNode(docView.Root, jcrPath, file)
+	return docView.String() != before
+}
+
+func (t *Transformer) transformNode(node *DocViewNode, jcrPath string, file string) {
+	for i, prop := range node.Properties {
+		node.Properties[i].Value = t.transformValue(prop, file)
+	}
+	node.Children = lo.Filter(node.Children, func(child *DocViewNode, _ int) bool { return !t.nodeDropped(child) })
+	for _, child := range node.Children {
+		childPath := strings.TrimSuffix(jcrPath, "/") + "/" + child.Name
+		if mapped := t.MapPath(childPath); mapped != childPath && path.Dir(mapped) == jcrPath {
+			child.Name = path.Base(mapped)
+		}
+		t.transformNode(child, childPath, file)
+	}
+}
+
+func (t *Transformer) transformValue(prop DocViewProperty, file string) string {
+	value := prop.Value
+	if prop.Name == SlingResourceTypeProp || prop.Name == SlingResourceSuperTypeProp {
+		for _, rename := range t.ResourceTypeRenames {
+			if renamed, ok := replacePathPrefix(value, rename.From, rename.To); ok {
+				value = renamed
+				break
+			}
+		}
+	}
+	for _, mapping := range t.PathMappings {
+		value = pathValueRegex(mapping.From).ReplaceAllString(value, strings.ReplaceAll(mapping.To, "$", "$$")+"${1}")
+	}
+	for _, replacement := range t.PropertyReplacements {
+		if matchRule(prop.Name, file, replacement.PathRule) {
+			value = replacement.Regex.ReplaceAllString(value, replacement.Replacement)
+		}
+	}
+	return value
+}
+
+// pathValueRegex matches path occurrences in property values (e.g. link or reference) but not paths of siblings with the same prefix
+func pathValueRegex(path string) *regexp.Regexp {
+	return regexp.MustCompile(regexp.QuoteMeta(path) + `([^A-Za-z0-9_.:\-]|$)`)
+}
+
+func (t *Transformer) nodeDropped(node *DocViewNode) bool {
+	if len(t.NodeTypesDropped) == 0 {
+		return false
+	}
+	primaryType, ok := node.Property(JCRPrimaryTypeProp)
+	return ok && matchString(strings.TrimPrefix(primaryType, "{Name}"), t.NodeTypesDropped)
+}
+
+func (t *Transformer) moveFiles(root string) error {
+	for _, mapping := range t.PathMappings {
+		for _, suffix := range []string{"", XmlFileSuffix} {
+			src := filepath.Join(root, PlatformPath(mapping.From)) + suffix
+			if !pathx.Exists(src) {
+				continue
+			}
+			dest := filepath.Join(root, PlatformPath(mapping.To)) + suffix
+			if pathx.Exists(dest) {
+				return fmt.Errorf("cannot move content '%s' to '%s' as target already exists", src, dest)
+			}
+			log.Infof("moving content '%s' to '%s'", src, dest)
+			if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+				return fmt.Errorf("cannot create content directory '%s': %w", filepath.Dir(dest), err)
+			}
+			if err := os.Rename(src, dest); err != nil {
+				return fmt.Errorf("cannot move content '%s' to '%s': %w", src, dest, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (t *Transformer) transformFilter(file string) error {
+	if len(t.PathMappings) == 0 || !pathx.Exists(file) {
+		return nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("cannot read filter file '%s': %w", file, err)
+	}
+	result := filterPathAttrRegex.ReplaceAllStringFunc(string(data), func(attr string) string {
+		groups := filterPathAttrRegex.FindStringSubmatch(attr)
+		return groups[1] + t.MapPath(groups[2]) + groups[3]
+	})
+	if err := os.WriteFile(file, []byte(result), 0644); err != nil {
+		return fmt.Errorf("cannot write filter file '%s': %w", file, err)
+	}
+	return nil
+}
+
+// PlatformPath converts JCR path to relative file path used by FileVault (e.g. '/content/a/jcr:content' to 'content/a/_jcr_content')
+func PlatformPath(jcrPath string) string {
+	var segments []string
+	for _, segment := range strings.Split(strings.Trim(jcrPath, "/"), "/") {
+		if prefix, name, ok := strings.Cut(segment, ":"); ok {
+			segment = "_" + prefix + "_" + name
+		} else if strings.HasPrefix(segment, "_") {
+			segment = "_" + segment
+		}
+		segments = append(segments, segment)
+	}
+	return filepath.Join(segments...)
+}
+
+// DocViewNodePath determines JCR path of the root node of document view file located under JCR root directory
+func DocViewNodePath(root string, file string) string {
+	rel, err := filepath.Rel(root, file)
+	if err != nil {
+		return "/"
+	}
+	rel = filepath.ToSlash(rel)
+	if path.Base(rel) == JCRContentFile {
+		rel = path.Dir(rel)
+	} else {
+		rel = strings.TrimSuffix(rel, XmlFileSuffix)
+	}
+	var segments []string
+	for _, segment := range strings.Split(rel, "/") {
+		if segment == "." {
+			continue
+		}
+		if strings.HasPrefix(segment, "__") {
+			segment = strings.TrimPrefix(segment, "_")
+		} else if groups := platformSegmentRegex.FindStringSubmatch(segment); groups != nil {
+			segment = groups[1] + ":" + groups[2]
+		}
+		segments = append(segments, segment)
+	}
+	return "/" + strings.Join(segments, "/")
+}
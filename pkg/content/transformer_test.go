@@ -0,0 +1,116 @@
+package content_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/wttech/aemc/pkg/content"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestPlatformPath(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	a.Equal(filepath.Join("content", "site", "_jcr_content"), content.PlatformPath("/content/site/jcr:content"))
+	a.Equal(filepath.Join("content", "__private"), content.PlatformPath("/content/_private"))
+	a.Equal("/content/site/jcr:content", content.DocViewNodePath("/tmp/jcr_root", "/tmp/jcr_root/content/site/_jcr_content.xml"))
+	a.Equal("/content/_private", content.DocViewNodePath("/tmp/jcr_root", "/tmp/jcr_root/content/__private/.content.xml"))
+}
+
+func TestTransformerMapPath(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	transformer := &content.Transformer{PathMappings: []content.TransformMapping{{From: "/content/site-a", To: "/content/site-b"}}}
+	a.Equal("/content/site-b/en", transformer.MapPath("/content/site-a/en"))
+	a.Equal("/content/site-ab", transformer.MapPath("/content/site-ab"))
+	a.Equal("/content/site-a/en", transformer.UnmapPath("/content/site-b/en"))
+}
+
+func TestTransformerTransform(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, content.VltFilterFile), `<?xml version="1.0" encoding="UTF-8"?>
+<workspaceFilter version="1.0">
+    <filter root="/content/site-a">
+        <exclude pattern="/content/site-a/tmp(/.*)?"/>
+    </filter>
+</workspaceFilter>
+`)
+	writeTestFile(t, filepath.Join(dir, content.JCRRoot, "content", ".content.xml"), `<?xml version="1.0" encoding="UTF-8"?>
+<jcr:root xmlns:jcr="http://www.jcp.org/jcr/1.0"
+    jcr:primaryType="sling:Folder">
+    <site-a/>
+    <site-ab/>
+</jcr:root>
+`)
+	writeTestFile(t, filepath.Join(dir, content.JCRRoot, "content", "site-a", "en", ".content.xml"), `<?xml version="1.0" encoding="UTF-8"?>
+<jcr:root xmlns:sling="http://sling.apache.org/jcr/sling/1.0" xmlns:cq="http://www.day.com/jcr/cq/1.0" xmlns:jcr="http://www.jcp.org/jcr/1.0"
+    jcr:primaryType="cq:Page">
+    <jcr:content
+        jcr:primaryType="cq:PageContent"
+        jcr:title="Site A"
+        sling:resourceType="site-a/components/page"
+        link="/content/site-a/de.html"
+        other="/content/site-ab">
+        <audit jcr:primaryType="cq:AuditEvent"/>
+    </jcr:content>
+</jcr:root>
+`)
+
+	transformer := &content.Transformer{
+		PathMappings:         []content.TransformMapping{{From: "/content/site-a", To: "/content/site-b"}},
+		ResourceTypeRenames:  []content.TransformMapping{{From: "site-a/components", To: "site-b/components"}},
+		PropertyReplacements: []content.PropertyReplacement{{PathRule: content.PathRule{Patterns: []string{"jcr:title"}}, Regex: regexp.MustCompile("Site A"), Replacement: "Site B"}},
+		NodeTypesDropped:     []string{"cq:AuditEvent"},
+	}
+	a.NoError(transformer.Transform(dir))
+
+	a.NoDirExists(filepath.Join(dir, content.JCRRoot, "content", "site-a"))
+	a.Equal(`<?xml version="1.0" encoding="UTF-8"?>
+<jcr:root xmlns:sling="http://sling.apache.org/jcr/sling/1.0" xmlns:cq="http://www.day.com/jcr/cq/1.0" xmlns:jcr="http://www.jcp.org/jcr/1.0"
+    jcr:primaryType="cq:Page">
+    <jcr:content
+        jcr:primaryType="cq:PageContent"
+        jcr:title="Site B"
+        sling:resourceType="site-b/components/page"
+        link="/content/site-b/de.html"
+        other="/content/site-ab"/>
+</jcr:root>
+`, readTestFile(t, filepath.Join(dir, content.JCRRoot, "content", "site-b", "en", ".content.xml")))
+	a.Equal(`<?xml version="1.0" encoding="UTF-8"?>
+<jcr:root xmlns:jcr="http://www.jcp.org/jcr/1.0"
+    jcr:primaryType="sling:Folder">
+    <site-b/>
+    <site-ab/>
+</jcr:root>
+`, readTestFile(t, filepath.Join(dir, content.JCRRoot, "content", ".content.xml")))
+	a.Equal(`<?xml version="1.0" encoding="UTF-8"?>
+<workspaceFilter version="1.0">
+    <filter root="/content/site-b">
+        <exclude pattern="/content/site-b/tmp(/.*)?"/>
+    </filter>
+</workspaceFilter>
+`, readTestFile(t, filepath.Join(dir, content.VltFilterFile)))
+}
+
+func writeTestFile(t *testing.T, file string, data string) {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readTestFile(t *testing.T, file string) string {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
@@ -1,8 +1,12 @@
 package pkg
 
 import (
+	"fmt"
+	"github.com/samber/lo"
+	log "github.com/sirupsen/logrus"
 	"github.com/wttech/aemc/pkg/common/filex"
 	"github.com/wttech/aemc/pkg/common/pathx"
+	"github.com/wttech/aemc/pkg/common/timex"
 	"github.com/wttech/aemc/pkg/content"
 	"os"
 	"path/filepath"
@@ -17,11 +21,20 @@ const (
 type ContentManager struct {
 	aem    *AEM
 	editor *content.Editor
+
+	SyncStateEnabled bool
+	SyncConflict     string
+	Transformer      *content.Transformer
+	Scrubber         *content.Scrubber
 }
 
 func NewContentManager(aem *AEM) *ContentManager {
+	cv := aem.config.Values()
+
 	result := &ContentManager{aem: aem}
 	result.editor = content.NewEditor(result.aem.config)
+	result.SyncStateEnabled = cv.GetBool("content.sync.state_enabled")
+	result.SyncConflict = cv.GetString("content.sync.conflict")
 	return result
 }
 
@@ -29,16 +42,104 @@ func (cm *ContentManager) Clean(path string) error {
 	return cm.editor.Clean(path)
 }
 
+// TransformByConfig enables transforming content using rules from 'content.transform' config section
+func (cm *ContentManager) TransformByConfig() error {
+	transformer, err := content.NewTransformer(cm.aem.config)
+	if err != nil {
+		return fmt.Errorf("cannot read content transform rules from config: %w", err)
+	}
+	cm.Transformer = transformer
+	return nil
+}
+
+// TransformByFile enables transforming content using rules from YAML file
+func (cm *ContentManager) TransformByFile(file string) error {
+	transformer, err := content.ReadTransformer(file)
+	if err != nil {
+		return err
+	}
+	cm.Transformer = transformer
+	return nil
+}
+
+func (cm *ContentManager) Lint(path string) (*content.LintResult, error) {
+	linter, err := content.NewLinter(cm.aem.config)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read content lint rules from config: %w", err)
+	}
+	return linter.Lint(path)
+}
+
+// ScrubByConfig enables scrubbing downloaded content using profile from 'content.scrub' config section
+func (cm *ContentManager) ScrubByConfig() error {
+	scrubber, err := content.NewScrubber(cm.aem.config)
+	if err != nil {
+		return fmt.Errorf("cannot read content scrub profile from config: %w", err)
+	}
+	cm.Scrubber = scrubber
+	return nil
+}
+
+// ScrubByFile enables scrubbing downloaded content using profile from YAML file
+func (cm *ContentManager) ScrubByFile(file string) error {
+	scrubber, err := content.ReadScrubber(file)
+	if err != nil {
+		return err
+	}
+	cm.Scrubber = scrubber
+	return nil
+}
+
+func (cm *ContentManager) scrubbing() bool {
+	return cm.Scrubber != nil && !cm.Scrubber.Empty()
+}
+
+func (cm *ContentManager) transforming() bool {
+	return cm.Transformer != nil && !cm.Transformer.Empty()
+}
+
+// syncing is disabled when transforming content as local and remote files are not comparable then
+func (cm *ContentManager) syncing() bool {
+	return cm.SyncStateEnabled && !cm.transforming()
+}
+
+func (cm *ContentManager) transform(workDir string) error {
+	if !cm.transforming() {
+		return nil
+	}
+	return cm.Transformer.Transform(workDir)
+}
+
+// transformPullOpts determines paths of content to be pulled from instance so that after transforming it lands under local paths
+func (cm *ContentManager) transformPullOpts(opts PackageCreateOpts) PackageCreateOpts {
+	if !cm.transforming() {
+		return opts
+	}
+	opts.FilterRoots = lo.Map(opts.FilterRoots, func(root string, _ int) string { return cm.Transformer.UnmapPath(root) })
+	opts.FilterRootExcludes = lo.Map(opts.FilterRootExcludes, func(exclude string, _ int) string { return cm.Transformer.UnmapPath(exclude) })
+	return opts
+}
+
 func (cm *ContentManager) Download(instance *Instance, localFile string, clean bool, opts PackageCreateOpts) error {
-	if clean {
+	if clean || cm.transforming() || cm.scrubbing() {
 		workDir := pathx.RandomDir(cm.tmpDir(), "content_pull")
 		defer func() { _ = pathx.DeleteIfExists(workDir) }()
 		if err := cm.pullContent(instance, workDir, opts); err != nil {
 			return err
 		}
-		if err := cm.Clean(filepath.Join(workDir, content.JCRRoot)); err != nil {
+		if err := cm.transform(workDir); err != nil {
 			return err
 		}
+		if cm.scrubbing() {
+			if err := cm.Scrubber.Scrub(workDir); err != nil {
+				return err
+			}
+		}
+		if clean {
+			if err := cm.Clean(filepath.Join(workDir, content.JCRRoot)); err != nil {
+				return err
+			}
+		}
 		if err := content.Zip(workDir, localFile); err != nil {
 			return err
 		}
@@ -51,9 +152,18 @@ func (cm *ContentManager) Download(instance *Instance, localFile string, clean b
 }
 
 func (cm *ContentManager) PullDir(instance *Instance, dir string, clean bool, replace bool, opts PackageCreateOpts) error {
+	if cm.syncing() {
+		synced, err := cm.pullSynced(instance, dir, false, clean, replace, opts)
+		if err != nil || synced {
+			return err
+		}
+	}
 	workDir := pathx.RandomDir(cm.tmpDir(), "content_pull")
 	defer func() { _ = pathx.DeleteIfExists(workDir) }()
-	if err := cm.pullContent(instance, workDir, opts); err != nil {
+	if err := cm.pullContent(instance, workDir, cm.transformPullOpts(opts)); err != nil {
+		return err
+	}
+	if err := cm.transform(workDir); err != nil {
 		return err
 	}
 	if replace {
@@ -70,13 +180,22 @@ func (cm *ContentManager) PullDir(instance *Instance, dir string, clean bool, re
 			return err
 		}
 	}
-	return nil
+	return cm.saveSyncState(content.JCRRootDir(dir), dir, false)
 }
 
 func (cm *ContentManager) PullFile(instance *Instance, file string, clean bool, replace bool, opts PackageCreateOpts) error {
+	if cm.syncing() {
+		synced, err := cm.pullSynced(instance, file, true, clean, true, opts)
+		if err != nil || synced {
+			return err
+		}
+	}
 	workDir := pathx.RandomDir(cm.tmpDir(), "content_pull")
 	defer func() { _ = pathx.DeleteIfExists(workDir) }()
-	if err := cm.pullContent(instance, workDir, opts); err != nil {
+	if err := cm.pullContent(instance, workDir, cm.transformPullOpts(opts)); err != nil {
+		return err
+	}
+	if err := cm.transform(workDir); err != nil {
 		return err
 	}
 	syncFile := DetermineSyncFile(workDir, file)
@@ -94,15 +213,34 @@ func (cm *ContentManager) PullFile(instance *Instance, file string, clean bool,
 			return err
 		}
 	}
-	return nil
+	return cm.saveSyncState(content.JCRRootDir(file), file, true)
 }
 
 func (cm *ContentManager) Push(instances []Instance, clean bool, opts PackageCreateOpts) error {
+	if cm.syncing() && opts.ContentPath != "" && len(instances) > 0 {
+		if err := cm.pushSyncCheck(&instances[0], clean, opts); err != nil {
+			return err
+		}
+	}
+	file := pathx.IsFile(opts.ContentPath)
+	return cm.push(instances, clean, opts, func(pushedRoot string) error {
+		if opts.ContentPath == "" {
+			return nil
+		}
+		return cm.saveSyncState(pushedRoot, opts.ContentPath, file)
+	})
+}
+
+// push sends content to instances; callback receives JCR root of pushed files (cleaned if requested) before they are deleted
+func (cm *ContentManager) push(instances []Instance, clean bool, opts PackageCreateOpts, pushed func(pushedRoot string) error) error {
 	workDir := pathx.RandomDir(cm.tmpDir(), "content_push")
 	defer func() { _ = pathx.DeleteIfExists(workDir) }()
 	if err := copyPackageAllFiles(workDir, opts); err != nil {
 		return err
 	}
+	if err := cm.transform(workDir); err != nil {
+		return err
+	}
 	if clean {
 		if err := cm.Clean(filepath.Join(workDir, content.JCRRoot)); err != nil {
 			return err
@@ -116,6 +254,9 @@ func (cm *ContentManager) Push(instances []Instance, clean bool, opts PackageCre
 	if err := cm.pushContent(instances, pkgFile); err != nil {
 		return err
 	}
+	if pushed != nil {
+		return pushed(filepath.Join(workDir, content.JCRRoot))
+	}
 	return nil
 }
 
@@ -131,6 +272,192 @@ func (cm *ContentManager) Copy(srcInstance *Instance, destInstances []Instance,
 	return nil
 }
 
+// pullSynced applies only remote changes when sync state has a baseline for given path (otherwise returns false to pull content regularly)
+func (cm *ContentManager) pullSynced(instance *Instance, path string, file bool, clean bool, replace bool, opts PackageCreateOpts) (bool, error) {
+	state, err := content.ReadSyncState(path)
+	if err != nil {
+		return false, err
+	}
+	scope := contentSyncScope(path, file)
+	base := state.Scope(scope)
+	if len(base) == 0 {
+		return false, nil
+	}
+	remoteRoot, cleanup, err := cm.stageRemote(instance, path, file, clean, opts)
+	defer cleanup()
+	if err != nil {
+		return false, err
+	}
+	localRoot := content.JCRRootDir(path)
+	comparison, remote, err := compareContentSync(base, localRoot, remoteRoot, scope)
+	if err != nil {
+		return false, err
+	}
+	files, err := comparison.Resolve(cm.SyncConflict)
+	if err != nil {
+		return false, fmt.Errorf("cannot pull content '%s': %w", path, err)
+	}
+	if err := applyContentSync(remoteRoot, localRoot, files, remote, replace); err != nil {
+		return false, err
+	}
+	log.Infof("pulled content '%s' (updated: %d, changed locally: %d, conflicts: %d)", path, len(files), len(comparison.LocalChanged), len(comparison.Conflicts))
+	state.Update(scope, remote)
+	return true, state.Write(path)
+}
+
+// pushSyncCheck takes remote changes locally before pushing so that they are not overridden
+func (cm *ContentManager) pushSyncCheck(instance *Instance, clean bool, opts PackageCreateOpts) error {
+	path := opts.ContentPath
+	file := pathx.IsFile(path)
+	state, err := content.ReadSyncState(path)
+	if err != nil {
+		return err
+	}
+	scope := contentSyncScope(path, file)
+	base := state.Scope(scope)
+	if len(base) == 0 {
+		return nil
+	}
+	filterRoots := opts.FilterRoots
+	if len(filterRoots) == 0 {
+		filterRoots = []string{DetermineFilterRoot(path)}
+	}
+	remoteRoot, cleanup, err := cm.stageRemote(instance, path, file, clean, PackageCreateOpts{
+		PID:                fmt.Sprintf("aemc:content-sync:%s-SNAPSHOT", timex.FileTimestampForNow()),
+		FilterRoots:        filterRoots,
+		FilterRootExcludes: opts.FilterRootExcludes,
+	})
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+	localRoot := content.JCRRootDir(path)
+	comparison, remote, err := compareContentSync(base, localRoot, remoteRoot, scope)
+	if err != nil {
+		return err
+	}
+	files, err := comparison.Resolve(cm.SyncConflict)
+	if err != nil {
+		return fmt.Errorf("cannot push content '%s': %w", path, err)
+	}
+	if len(files) > 0 {
+		log.Infof("merging remote changes of content '%s' before pushing (updated: %d)", path, len(files))
+		if err := applyContentSync(remoteRoot, localRoot, files, remote, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stageRemote pulls content into a temporary JCR root having the same layout as the local one (cleaned and flattened if requested)
+func (cm *ContentManager) stageRemote(instance *Instance, path string, file bool, clean bool, opts PackageCreateOpts) (string, func(), error) {
+	workDir := pathx.RandomDir(cm.tmpDir(), "content_sync")
+	cleanup := func() { _ = pathx.DeleteIfExists(workDir) }
+	pulledDir := filepath.Join(workDir, "pulled")
+	if err := cm.pullContent(instance, pulledDir, opts); err != nil {
+		return "", cleanup, err
+	}
+	if !file {
+		remoteDir := filepath.Join(pulledDir, content.JCRRoot, content.SyncPath(path))
+		if clean && pathx.Exists(remoteDir) {
+			if err := cm.Clean(remoteDir); err != nil {
+				return "", cleanup, err
+			}
+		}
+		return filepath.Join(pulledDir, content.JCRRoot), cleanup, nil
+	}
+	stagedRoot := filepath.Join(workDir, "staged", content.JCRRoot)
+	syncPath := content.SyncPath(DetermineSyncFile(pulledDir, path))
+	pulledFile := filepath.Join(pulledDir, content.JCRRoot, syncPath)
+	if pathx.Exists(pulledFile) {
+		stagedFile := filepath.Join(stagedRoot, syncPath)
+		if err := filex.Copy(pulledFile, stagedFile, true); err != nil {
+			return "", cleanup, err
+		}
+		if clean {
+			if err := cm.Clean(stagedFile); err != nil {
+				return "", cleanup, err
+			}
+		}
+	}
+	return stagedRoot, cleanup, nil
+}
+
+// saveSyncState records checksums of files under root (local JCR root or the pushed one) as a baseline of path
+func (cm *ContentManager) saveSyncState(root string, path string, file bool) error {
+	if !cm.syncing() {
+		return nil
+	}
+	state, err := content.ReadSyncState(path)
+	if err != nil {
+		return err
+	}
+	scope := contentSyncScope(path, file)
+	local, err := contentSyncChecksums(root, scope)
+	if err != nil {
+		return err
+	}
+	state.Update(scope, local)
+	return state.Write(path)
+}
+
+// contentSyncScope covers also the unflattened variant of a flattened file (e.g. '_cq_dialog.xml' and '_cq_dialog/.content.xml')
+func contentSyncScope(path string, file bool) []string {
+	result := []string{content.SyncPath(path)}
+	if file && regexp.MustCompile(FlattenFilePattern).MatchString(path) {
+		result = append(result, content.SyncPath(filepath.Join(strings.TrimSuffix(path, content.XmlFileSuffix), content.JCRContentFile)))
+	}
+	return result
+}
+
+func contentSyncChecksums(root string, scope []string) (map[string]string, error) {
+	result := map[string]string{}
+	for _, path := range scope {
+		checksums, err := content.SyncChecksums(filepath.Join(root, path))
+		if err != nil {
+			return nil, err
+		}
+		for file, checksum := range checksums {
+			result[file] = checksum
+		}
+	}
+	return result, nil
+}
+
+func compareContentSync(base map[string]string, localRoot string, remoteRoot string, scope []string) (content.SyncComparison, map[string]string, error) {
+	local, err := contentSyncChecksums(localRoot, scope)
+	if err != nil {
+		return content.SyncComparison{}, nil, err
+	}
+	remote, err := contentSyncChecksums(remoteRoot, scope)
+	if err != nil {
+		return content.SyncComparison{}, nil, err
+	}
+	return content.CompareSync(base, local, remote), remote, nil
+}
+
+func applyContentSync(remoteRoot string, localRoot string, files []string, remote map[string]string, deleting bool) error {
+	for _, file := range files {
+		localFile := filepath.Join(localRoot, file)
+		if remote[file] == "" {
+			if !deleting {
+				log.Infof("keeping content file '%s' (deleted remotely)", localFile)
+				continue
+			}
+			log.Infof("deleting content file '%s' (deleted remotely)", localFile)
+			if err := pathx.DeleteIfExists(localFile); err != nil {
+				return err
+			}
+		} else {
+			log.Infof("updating content file '%s' (changed remotely)", localFile)
+			if err := filex.Copy(filepath.Join(remoteRoot, file), localFile, true); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func DetermineSyncFile(workDir string, file string) string {
 	if regexp.MustCompile(FlattenFilePattern).MatchString(file) {
 		syncFile := filepath.Join(strings.ReplaceAll(file, content.XmlFileSuffix, ""), content.JCRContentFile)
@@ -142,6 +469,29 @@ func DetermineSyncFile(workDir string, file string) string {
 	return file
 }
 
+// DetermineFilterRootExcludes excludes sibling nodes when pushing or pulling a single non-page '.content.xml' file (so that its children are not replaced)
+func DetermineFilterRootExcludes(file string) []string {
+	if file == "" || !strings.HasSuffix(file, content.JCRContentFile) || content.IsPageContentFile(file) {
+		return nil
+	}
+
+	dir := filepath.Dir(file)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var filterRootExcludes []string
+	for _, entry := range entries {
+		if entry.Name() != content.JCRContentFile {
+			jcrPath := DetermineFilterRoot(filepath.Join(dir, entry.Name()))
+			excludePattern := fmt.Sprintf("%s(/.*)?", jcrPath)
+			filterRootExcludes = append(filterRootExcludes, excludePattern)
+		}
+	}
+	return filterRootExcludes
+}
+
 func (cm *ContentManager) downloadContent(instance *Instance, pkgFile string, opts PackageCreateOpts) error {
 	remotePath, err := instance.PackageManager().Create(opts)
 	defer func() { _ = instance.PackageManager().Delete(remotePath) }()
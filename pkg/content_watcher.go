@@ -0,0 +1,384 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"github.com/fsnotify/fsnotify"
+	"github.com/samber/lo"
+	log "github.com/sirupsen/logrus"
+	"github.com/wttech/aemc/pkg/common/filex"
+	"github.com/wttech/aemc/pkg/common/pathx"
+	"github.com/wttech/aemc/pkg/common/stringsx"
+	"github.com/wttech/aemc/pkg/common/timex"
+	"github.com/wttech/aemc/pkg/content"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+type ContentWatchOpts struct {
+	Dir          string
+	Clean        bool
+	Debounce     time.Duration
+	PullInterval time.Duration
+	FilesIgnored []string
+}
+
+func (cm *ContentManager) WatchOpts() ContentWatchOpts {
+	cv := cm.aem.config.Values()
+
+	return ContentWatchOpts{
+		Debounce:     cv.GetDuration("content.watch.debounce"),
+		PullInterval: cv.GetDuration("content.watch.pull_interval"),
+		FilesIgnored: cv.GetStringSlice("content.watch.files_ignored"),
+	}
+}
+
+// ContentWatcher pushes local changes to instances in debounced batches and optionally pulls remote changes back.
+// Files are compared with a baseline (checksums of files known to be in sync with instances) to detect changes made on both sides.
+type ContentWatcher struct {
+	manager   *ContentManager
+	instances []Instance
+	opts      ContentWatchOpts
+
+	baseline  map[string]string
+	conflicts map[string]string
+}
+
+// Watch blocks until context is done; push and pull failures are only logged to keep watching
+func (cm *ContentManager) Watch(ctx context.Context, instances []Instance, opts ContentWatchOpts) error {
+	if len(instances) == 0 {
+		return fmt.Errorf("cannot watch content as no instances are selected")
+	}
+	if !pathx.IsDir(opts.Dir) || !strings.Contains(opts.Dir, content.JCRRoot) {
+		return fmt.Errorf("cannot watch content as directory '%s' does not exist or is not under '%s'", opts.Dir, content.JCRRoot)
+	}
+	w := &ContentWatcher{
+		manager:   cm,
+		instances: instances,
+		opts:      opts,
+		conflicts: map[string]string{},
+	}
+	return w.run(ctx)
+}
+
+func (w *ContentWatcher) run(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("cannot watch content directory '%s': %w", w.opts.Dir, err)
+	}
+	defer func() { _ = watcher.Close() }()
+	if err := w.watchDirs(watcher, w.opts.Dir); err != nil {
+		return err
+	}
+	if w.opts.PullInterval > 0 {
+		remote, err := w.pullRemote()
+		if err != nil {
+			return err
+		}
+		w.baseline = remote.checksums
+		remote.delete()
+	} else {
+		local, err := w.checksums(w.opts.Dir)
+		if err != nil {
+			return err
+		}
+		w.baseline = local
+	}
+
+	var pullTick <-chan time.Time
+	if w.opts.PullInterval > 0 {
+		ticker := time.NewTicker(w.opts.PullInterval)
+		defer ticker.Stop()
+		pullTick = ticker.C
+		log.Info(InstancesMsg(w.instances, fmt.Sprintf("watching content directory '%s' (pulling every %s from instance '%s')", w.opts.Dir, w.opts.PullInterval, w.instances[0].ID())))
+	} else {
+		log.Info(InstancesMsg(w.instances, fmt.Sprintf("watching content directory '%s'", w.opts.Dir)))
+	}
+
+	pending := map[string]bool{}
+	var pushTimer <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			log.Infof("stopped watching content directory '%s'", w.opts.Dir)
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if w.ignored(event.Name) || event.Op == fsnotify.Chmod {
+				continue
+			}
+			if event.Has(fsnotify.Create) && pathx.IsDir(event.Name) {
+				if err := w.watchDirs(watcher, event.Name); err != nil {
+					log.Warn(err)
+				}
+			}
+			pending[event.Name] = true
+			pushTimer = time.After(w.opts.Debounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			log.Warnf("error while watching content directory '%s': %s", w.opts.Dir, err)
+		case <-pushTimer:
+			paths := lo.Keys(pending)
+			pending = map[string]bool{}
+			pushTimer = nil
+			if err := w.push(paths); err != nil {
+				log.Error(err)
+			}
+		case <-pullTick:
+			if len(pending) > 0 {
+				continue
+			}
+			if err := w.pull(); err != nil {
+				log.Error(err)
+			}
+		}
+	}
+}
+
+func (w *ContentWatcher) watchDirs(watcher *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		if w.ignored(path) {
+			return filepath.SkipDir
+		}
+		if err := watcher.Add(path); err != nil {
+			return fmt.Errorf("cannot watch content directory '%s': %w", path, err)
+		}
+		return nil
+	})
+}
+
+func (w *ContentWatcher) ignored(path string) bool {
+	return stringsx.MatchSome(pathx.Normalize(path), w.opts.FilesIgnored)
+}
+
+// push sends changed files to instances; paths of deleted files are pushed as their parent directories so that deletions are applied too.
+// Deletions which could be applied only by pushing the whole JCR root are skipped, as such push would replace all repository content.
+func (w *ContentWatcher) push(paths []string) error {
+	changed, err := w.changedFiles(paths)
+	if err != nil {
+		return err
+	}
+	var targets []string
+	for file, checksum := range changed {
+		path := filepath.Join(w.opts.Dir, file)
+		if checksum == "" {
+			path = filepath.Dir(path)
+			for !pathx.Exists(path) && path != w.opts.Dir {
+				path = filepath.Dir(path)
+			}
+			if lo.Contains([]string{"", "/"}, DetermineFilterRoot(path)) {
+				log.Warnf("skipping deletion of content '%s' as it would require pushing whole JCR root; delete it on instance manually", file)
+				delete(changed, file)
+				delete(w.baseline, file)
+				continue
+			}
+		}
+		targets = append(targets, path)
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+	for _, target := range contentPushTargets(targets) {
+		log.Infof("pushing content '%s'", target)
+		if err := w.manager.push(w.instances, w.opts.Clean, PackageCreateOpts{
+			PID:                fmt.Sprintf("aemc:content-watch:%s-SNAPSHOT", timex.FileTimestampForNow()),
+			FilterRoots:        []string{DetermineFilterRoot(target)},
+			FilterRootExcludes: DetermineFilterRootExcludes(target),
+			ContentPath:        target,
+		}, nil); err != nil {
+			return fmt.Errorf("cannot push content '%s': %w", target, err)
+		}
+		log.Infof("pushed content '%s'", target)
+	}
+	for file, checksum := range changed {
+		if checksum == "" {
+			delete(w.baseline, file)
+		} else {
+			w.baseline[file] = checksum
+		}
+	}
+	return nil
+}
+
+// changedFiles maps files differing from baseline to their current checksums (empty for deleted ones)
+func (w *ContentWatcher) changedFiles(paths []string) (map[string]string, error) {
+	result := map[string]string{}
+	for _, path := range paths {
+		rel, err := filepath.Rel(w.opts.Dir, path)
+		if err != nil {
+			return nil, err
+		}
+		rel = filepath.ToSlash(rel)
+		if pathx.Exists(path) {
+			local, err := w.checksums(path)
+			if err != nil {
+				return nil, err
+			}
+			for file, checksum := range local {
+				file = filepath.ToSlash(filepath.Join(rel, file))
+				if w.baseline[file] != checksum {
+					result[file] = checksum
+				}
+			}
+		} else {
+			for file := range w.baseline {
+				if file == rel || strings.HasPrefix(file, rel+"/") {
+					result[file] = ""
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+// contentPushTargets sorts paths and skips ones already covered by an ancestor directory
+func contentPushTargets(paths []string) []string {
+	paths = lo.Uniq(paths)
+	sort.Strings(paths)
+	var result []string
+	for _, path := range paths {
+		if !lo.SomeBy(result, func(target string) bool { return strings.HasPrefix(path, target+string(filepath.Separator)) }) {
+			result = append(result, path)
+		}
+	}
+	return result
+}
+
+// pull applies files changed only on the instance; files changed on both sides are reported as conflicts and kept untouched
+func (w *ContentWatcher) pull() error {
+	remote, err := w.pullRemote()
+	if err != nil {
+		return err
+	}
+	defer remote.delete()
+	files := lo.Uniq(append(lo.Keys(remote.checksums), lo.Keys(w.baseline)...))
+	sort.Strings(files)
+	for _, file := range files {
+		remoteChecksum := remote.checksums[file]
+		baseChecksum := w.baseline[file]
+		if remoteChecksum == baseChecksum {
+			continue
+		}
+		localFile := filepath.Join(w.opts.Dir, file)
+		localChecksum := ""
+		if pathx.Exists(localFile) {
+			localChecksum, err = filex.ChecksumFile(localFile)
+			if err != nil {
+				return err
+			}
+		}
+		if localChecksum == remoteChecksum {
+			w.baseline[file] = remoteChecksum
+			continue
+		}
+		if localChecksum != baseChecksum {
+			if w.conflicts[file] != remoteChecksum {
+				log.Warnf("content file '%s' changed both locally and on instance '%s' (keeping local one)", localFile, w.instances[0].ID())
+				w.conflicts[file] = remoteChecksum
+			}
+			continue
+		}
+		if remoteChecksum == "" {
+			log.Infof("deleting content file '%s' (deleted on instance '%s')", localFile, w.instances[0].ID())
+			if err := pathx.DeleteIfExists(localFile); err != nil {
+				return err
+			}
+			delete(w.baseline, file)
+		} else {
+			log.Infof("updating content file '%s' (changed on instance '%s')", localFile, w.instances[0].ID())
+			if err := filex.Copy(filepath.Join(remote.dir, file), localFile, true); err != nil {
+				return err
+			}
+			w.baseline[file] = remoteChecksum
+		}
+		delete(w.conflicts, file)
+	}
+	return nil
+}
+
+type contentWatchRemote struct {
+	workDir   string
+	dir       string
+	checksums map[string]string
+}
+
+func (r contentWatchRemote) delete() {
+	_ = pathx.DeleteIfExists(r.workDir)
+}
+
+func (w *ContentWatcher) pullRemote() (*contentWatchRemote, error) {
+	instance := w.instances[0]
+	workDir := pathx.RandomDir(w.manager.tmpDir(), "content_watch")
+	if err := w.manager.pullContent(&instance, workDir, PackageCreateOpts{
+		PID:         fmt.Sprintf("aemc:content-watch:%s-SNAPSHOT", timex.FileTimestampForNow()),
+		FilterRoots: []string{DetermineFilterRoot(w.opts.Dir)},
+	}); err != nil {
+		_ = pathx.DeleteIfExists(workDir)
+		return nil, err
+	}
+	_, jcrPath, _ := strings.Cut(w.opts.Dir, content.JCRRoot)
+	remote := &contentWatchRemote{workDir: workDir, dir: filepath.Join(workDir, content.JCRRoot, jcrPath), checksums: map[string]string{}}
+	if !pathx.Exists(remote.dir) {
+		return remote, nil
+	}
+	if w.opts.Clean {
+		if err := w.manager.Clean(remote.dir); err != nil {
+			remote.delete()
+			return nil, err
+		}
+	}
+	checksums, err := w.checksums(remote.dir)
+	if err != nil {
+		remote.delete()
+		return nil, err
+	}
+	remote.checksums = checksums
+	return remote, nil
+}
+
+// checksums maps file paths relative to root (or '.' when root is a file) to their checksums
+func (w *ContentWatcher) checksums(root string) (map[string]string, error) {
+	result := map[string]string{}
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if w.ignored(path) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		checksum, err := filex.ChecksumFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		result[filepath.ToSlash(rel)] = checksum
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("cannot calculate checksums of content files under '%s': %w", root, err)
+	}
+	return result, nil
+}
@@ -18,6 +18,7 @@ type AEM struct {
 	vendorManager   *VendorManager
 	instanceManager *InstanceManager
 	contentManager  *ContentManager
+	artifactManager *ArtifactManager
 }
 
 func DefaultAEM() *AEM {
@@ -33,6 +34,7 @@ func NewAEM(config *cfg.Config) *AEM {
 	result.vendorManager = NewVendorManager(result)
 	result.instanceManager = NewInstanceManager(result)
 	result.contentManager = NewContentManager(result)
+	result.artifactManager = NewArtifactManager(result)
 	return result
 }
 
@@ -69,6 +71,10 @@ func (a *AEM) ContentManager() *ContentManager {
 	return a.contentManager
 }
 
+func (a *AEM) ArtifactManager() *ArtifactManager {
+	return a.artifactManager
+}
+
 func (a *AEM) Project() *Project {
 	return a.project
 }
@@ -0,0 +1,30 @@
+package instance
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/wttech/aemc/pkg/common/fmtx"
+)
+
+// Diagnosis describes archive with data collected from instance for troubleshooting (e.g. when awaiting timed out)
+type Diagnosis struct {
+	InstanceID string   `yaml:"instance_id" json:"instanceId"`
+	File       string   `yaml:"file" json:"file"`
+	Collected  []string `yaml:"collected" json:"collected"`
+	Failed     []string `yaml:"failed,omitempty" json:"failed,omitempty"`
+}
+
+func (d Diagnosis) MarshalText() string {
+	bs := bytes.NewBufferString("")
+	bs.WriteString(fmtx.TblMap("diagnosis", "name", "value", map[string]any{
+		"instance":  d.InstanceID,
+		"file":      d.File,
+		"collected": len(d.Collected),
+		"failed":    len(d.Failed),
+	}))
+	for _, failure := range d.Failed {
+		bs.WriteString(fmt.Sprintf("failed: %s\n", failure))
+	}
+	return bs.String()
+}
@@ -15,9 +15,10 @@ import (
 type InstanceManager struct {
 	aem *AEM
 
-	Instances []Instance
-	LocalOpts *LocalOpts
-	CheckOpts *CheckOpts
+	Instances    []Instance
+	LocalOpts    *LocalOpts
+	CheckOpts    *CheckOpts
+	DiagnoseOpts *DiagnoseOpts
 
 	AdHocURLs []string
 
@@ -42,6 +43,7 @@ func NewInstanceManager(aem *AEM) *InstanceManager {
 
 	result.LocalOpts = NewLocalOpts(result)
 	result.CheckOpts = NewCheckOpts(result)
+	result.DiagnoseOpts = NewDiagnoseOpts(result)
 
 	return result
 }
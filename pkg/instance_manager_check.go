@@ -138,6 +138,7 @@ func (im *InstanceManager) checkOne(ctx context.Context, i Instance, checks []Ch
 		results = append(results, result)
 		resultText := result.Text()
 		if result.abort {
+			im.diagnoseOnAbort(i)
 			log.Fatal(InstanceMsg(i, resultText))
 		}
 		if resultText != "" {
@@ -188,6 +189,18 @@ func (im *InstanceManager) AwaitStarted(instances []Instance) error {
 	return im.CheckUntilDone(instances, im.CheckOpts, checkers)
 }
 
+// AwaitBundlesStable waits only until all OSGi bundles are stable (e.g. after installing many bundles at once)
+func (im *InstanceManager) AwaitBundlesStable(instances []Instance) error {
+	if len(instances) == 0 || im.CheckOpts.Skip {
+		return nil
+	}
+	log.Info(InstancesMsg(instances, "awaiting bundles stable"))
+	return im.CheckUntilDone(instances, im.CheckOpts, []Checker{
+		im.CheckOpts.Reachable,
+		im.CheckOpts.BundleStable,
+	})
+}
+
 func (im *InstanceManager) AwaitStoppedOne(instance Instance) error {
 	return im.AwaitStopped([]Instance{instance})
 }
@@ -0,0 +1,160 @@
+package pkg
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/wttech/aemc/pkg/common/filex"
+	"github.com/wttech/aemc/pkg/common/fmtx"
+	"github.com/wttech/aemc/pkg/common/pathx"
+	"github.com/wttech/aemc/pkg/common/timex"
+	"github.com/wttech/aemc/pkg/instance"
+)
+
+const (
+	ThreadDumpPath = "/system/console/status-jstack-threaddump.txt"
+	LogTailerPath  = "/system/console/slinglog/tailer.txt"
+	ErrorLogName   = "error.log"
+)
+
+type DiagnoseOpts struct {
+	Dir            string
+	LogLines       int
+	OnAwaitTimeout bool
+}
+
+func NewDiagnoseOpts(manager *InstanceManager) *DiagnoseOpts {
+	cv := manager.aem.config.Values()
+
+	return &DiagnoseOpts{
+		Dir:            cv.GetString("instance.diagnose.dir"),
+		LogLines:       cv.GetInt("instance.diagnose.log_lines"),
+		OnAwaitTimeout: cv.GetBool("instance.diagnose.on_await_timeout"),
+	}
+}
+
+type diagnoseCollector struct {
+	file    string
+	collect func(i Instance) (string, error)
+}
+
+// Diagnose collects thread dump, OSGi bundles and components, recent error log and system/Sling properties into single archive
+func (im *InstanceManager) Diagnose(i Instance) (*instance.Diagnosis, error) {
+	name := fmt.Sprintf("%s-%s", i.ID(), timex.FileTimestampForNow())
+	dir := pathx.Canonical(fmt.Sprintf("%s/%s", im.DiagnoseOpts.Dir, name))
+	file := dir + ".zip"
+	log.Infof("%s > collecting diagnosis to file '%s'", i.IDColor(), file)
+	if err := pathx.Ensure(dir); err != nil {
+		return nil, fmt.Errorf("%s > cannot prepare diagnosis dir '%s': %w", i.IDColor(), dir, err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	result := &instance.Diagnosis{InstanceID: i.ID(), File: file}
+	for _, collector := range im.diagnoseCollectors() {
+		text, err := collector.collect(i)
+		if err != nil {
+			log.Warnf("%s > cannot collect diagnosis file '%s': %s", i.IDColor(), collector.file, err)
+			result.Failed = append(result.Failed, fmt.Sprintf("%s: %s", collector.file, err))
+			continue
+		}
+		if err := filex.WriteString(filepath.Join(dir, collector.file), text); err != nil {
+			return nil, err
+		}
+		result.Collected = append(result.Collected, collector.file)
+	}
+	if err := fmtx.MarshalToFile(filepath.Join(dir, "diagnosis.yml"), result); err != nil {
+		return nil, err
+	}
+	if err := filex.Archive(dir, file); err != nil {
+		return nil, fmt.Errorf("%s > cannot archive diagnosis: %w", i.IDColor(), err)
+	}
+	log.Infof("%s > collected diagnosis to file '%s'", i.IDColor(), file)
+	return result, nil
+}
+
+func (im *InstanceManager) diagnoseCollectors() []diagnoseCollector {
+	return []diagnoseCollector{
+		{file: "thread-dump.txt", collect: im.diagnoseThreadDump},
+		{file: "bundles.json", collect: func(i Instance) (string, error) {
+			bundles, err := i.OSGI().BundleManager().List()
+			if err != nil {
+				return "", err
+			}
+			return fmtx.MarshalJSON(bundles)
+		}},
+		{file: "components.json", collect: func(i Instance) (string, error) {
+			components, err := i.OSGI().ComponentManager().List()
+			if err != nil {
+				return "", err
+			}
+			return fmtx.MarshalJSON(components)
+		}},
+		{file: ErrorLogName, collect: im.diagnoseErrorLog},
+		{file: "system-props.json", collect: func(i Instance) (string, error) {
+			props, err := i.Status().SystemProps()
+			if err != nil {
+				return "", err
+			}
+			return fmtx.MarshalJSON(props)
+		}},
+		{file: "sling-props.json", collect: func(i Instance) (string, error) {
+			props, err := i.Status().SlingProps()
+			if err != nil {
+				return "", err
+			}
+			return fmtx.MarshalJSON(props)
+		}},
+	}
+}
+
+// diagnoseThreadDump uses 'jstack' for running local instances (works even when HTTP is not responding), otherwise Felix Web Console
+func (im *InstanceManager) diagnoseThreadDump(i Instance) (string, error) {
+	if i.IsLocal() && i.Local().IsRunning() {
+		text, err := i.Local().ThreadDump()
+		if err == nil {
+			return text, nil
+		}
+		log.Debugf("%s > cannot make thread dump using jstack, falling back to web console: %s", i.IDColor(), err)
+	}
+	return im.diagnoseRequestText(i, ThreadDumpPath)
+}
+
+// diagnoseErrorLog reads last lines of error log from file for local instances, otherwise using Sling log tailer
+func (im *InstanceManager) diagnoseErrorLog(i Instance) (string, error) {
+	if i.IsLocal() {
+		file := i.Local().LogFile(ErrorLogName)
+		if pathx.Exists(file) {
+			return filex.ReadTail(file, im.DiagnoseOpts.LogLines)
+		}
+	}
+	return im.diagnoseRequestText(i, fmt.Sprintf("%s?tail=%d&name=%s", LogTailerPath, im.DiagnoseOpts.LogLines, url.QueryEscape("/logs/"+ErrorLogName)))
+}
+
+func (im *InstanceManager) diagnoseRequestText(i Instance, path string) (string, error) {
+	resp, err := i.http.Request().Get(path)
+	if err != nil {
+		return "", fmt.Errorf("cannot request '%s': %w", path, err)
+	} else if resp.IsError() {
+		return "", fmt.Errorf("cannot request '%s': %s", path, resp.Status())
+	}
+	defer func() { _ = resp.RawBody().Close() }()
+	data, err := io.ReadAll(resp.RawBody())
+	if err != nil {
+		return "", fmt.Errorf("cannot read '%s': %w", path, err)
+	}
+	return string(data), nil
+}
+
+// diagnoseOnAbort collects diagnosis of instances before aborting awaiting (if enabled)
+func (im *InstanceManager) diagnoseOnAbort(i Instance) {
+	if !im.DiagnoseOpts.OnAwaitTimeout {
+		return
+	}
+	if _, err := im.Diagnose(i); err != nil {
+		log.Warn(err)
+	}
+}
@@ -180,6 +180,18 @@ func (jm *JavaManager) Executable() (string, error) {
 	return pathx.Canonical(homeDir + "/bin/java"), nil
 }
 
+// ToolExecutable returns path to JDK tool (e.g. 'jstack')
+func (jm *JavaManager) ToolExecutable(name string) (string, error) {
+	homeDir, err := jm.FindHomeDir()
+	if err != nil {
+		return "", err
+	}
+	if osx.IsWindows() {
+		return pathx.Canonical(homeDir + "/bin/" + name + ".exe"), nil
+	}
+	return pathx.Canonical(homeDir + "/bin/" + name), nil
+}
+
 func (jm *JavaManager) Env() ([]string, error) {
 	homeDir, err := jm.FindHomeDir()
 	if err != nil {
@@ -1022,6 +1022,33 @@ func (li LocalInstance) PID() (int, error) {
 	return num, nil
 }
 
+func (li LocalInstance) LogFile(name string) string {
+	return fmt.Sprintf("%s/crx-quickstart/logs/%s", li.Dir(), name)
+}
+
+// ThreadDump makes thread dump of running instance process using 'jstack'
+func (li LocalInstance) ThreadDump() (string, error) {
+	pid, err := li.PID()
+	if err != nil {
+		return "", err
+	}
+	executable, err := li.JavaManager().ToolExecutable("jstack")
+	if err != nil {
+		return "", err
+	}
+	env, err := li.JavaManager().Env()
+	if err != nil {
+		return "", err
+	}
+	cmd := exec.Command(executable, "-l", strconv.Itoa(pid))
+	cmd.Env = env
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%s > cannot make thread dump of process '%d': %w", li.instance.IDColor(), pid, err)
+	}
+	return string(out), nil
+}
+
 func (li LocalInstance) ProposeBackupFileToMake() string {
 	nameParts := []string{li.Name()}
 	if li.IsRunning() {
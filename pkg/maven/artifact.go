@@ -0,0 +1,78 @@
+package maven
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	ExtensionDefault = "jar"
+	SnapshotSuffix   = "-SNAPSHOT"
+	MetadataFile     = "maven-metadata.xml"
+)
+
+// Artifact represents Maven coordinates in format 'groupId:artifactId:version[:classifier][@extension]'
+type Artifact struct {
+	GroupID    string `yaml:"group_id" json:"groupId"`
+	ArtifactID string `yaml:"artifact_id" json:"artifactId"`
+	Version    string `yaml:"version" json:"version"`
+	Classifier string `yaml:"classifier" json:"classifier"`
+	Extension  string `yaml:"extension" json:"extension"`
+}
+
+func ParseArtifact(coords string) (*Artifact, error) {
+	extension := ExtensionDefault
+	value, ext, found := strings.Cut(coords, "@")
+	if found {
+		extension = ext
+	}
+	parts := strings.Split(value, ":")
+	if len(parts) < 3 || len(parts) > 4 || extension == "" {
+		return nil, fmt.Errorf("artifact '%s' has different format than expected 'groupId:artifactId:version[:classifier][@extension]'", coords)
+	}
+	for _, part := range parts {
+		if part == "" {
+			return nil, fmt.Errorf("artifact '%s' has empty coordinate", coords)
+		}
+	}
+	result := &Artifact{GroupID: parts[0], ArtifactID: parts[1], Version: parts[2], Extension: extension}
+	if len(parts) == 4 {
+		result.Classifier = parts[3]
+	}
+	return result, nil
+}
+
+func (a Artifact) String() string {
+	result := strings.Join([]string{a.GroupID, a.ArtifactID, a.Version}, ":")
+	if a.Classifier != "" {
+		result += ":" + a.Classifier
+	}
+	return result + "@" + a.Extension
+}
+
+func (a Artifact) IsSnapshot() bool {
+	return strings.HasSuffix(a.Version, SnapshotSuffix)
+}
+
+// DirPath returns artifact directory relative to repository root
+func (a Artifact) DirPath() string {
+	return fmt.Sprintf("%s/%s/%s", strings.ReplaceAll(a.GroupID, ".", "/"), a.ArtifactID, a.Version)
+}
+
+// FileName returns artifact file name for a particular (e.g. timestamped snapshot) version
+func (a Artifact) FileName(version string) string {
+	result := a.ArtifactID + "-" + version
+	if a.Classifier != "" {
+		result += "-" + a.Classifier
+	}
+	return result + "." + a.Extension
+}
+
+// Path returns artifact file path relative to repository root
+func (a Artifact) Path() string {
+	return a.DirPath() + "/" + a.FileName(a.Version)
+}
+
+func (a Artifact) MetadataPath() string {
+	return a.DirPath() + "/" + MetadataFile
+}
@@ -0,0 +1,51 @@
+package maven_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/wttech/aemc/pkg/maven"
+	"strings"
+	"testing"
+)
+
+func TestParseArtifact(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	artifact, err := maven.ParseArtifact("com.acme.aem:acme.all:1.0.0-SNAPSHOT:cloud@zip")
+	a.NoError(err)
+	a.Equal("com/acme/aem/acme.all/1.0.0-SNAPSHOT/acme.all-1.0.0-SNAPSHOT-cloud.zip", artifact.Path())
+	a.True(artifact.IsSnapshot())
+
+	artifact, err = maven.ParseArtifact("com.acme.aem:acme.core:2.1.0")
+	a.NoError(err)
+	a.Equal("com/acme/aem/acme.core/2.1.0/acme.core-2.1.0.jar", artifact.Path())
+	a.False(artifact.IsSnapshot())
+
+	_, err = maven.ParseArtifact("com.acme.aem:acme.core")
+	a.Error(err)
+	_, err = maven.ParseArtifact("com.acme.aem::1.0.0")
+	a.Error(err)
+}
+
+func TestMetadataSnapshotVersion(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	metadata, err := maven.ReadMetadata(strings.NewReader(`<metadata>
+  <versioning>
+    <snapshot><timestamp>20240101.120000</timestamp><buildNumber>3</buildNumber></snapshot>
+    <snapshotVersions>
+      <snapshotVersion><classifier>cloud</classifier><extension>zip</extension><value>1.0.0-20240101.120000-3</value></snapshotVersion>
+    </snapshotVersions>
+  </versioning>
+</metadata>`))
+	a.NoError(err)
+
+	version, err := metadata.SnapshotVersion(maven.Artifact{ArtifactID: "acme.all", Version: "1.0.0-SNAPSHOT", Classifier: "cloud", Extension: "zip"})
+	a.NoError(err)
+	a.Equal("1.0.0-20240101.120000-3", version)
+
+	version, err = metadata.SnapshotVersion(maven.Artifact{ArtifactID: "acme.all", Version: "1.0.0-SNAPSHOT", Extension: "jar"})
+	a.NoError(err)
+	a.Equal("1.0.0-20240101.120000-3", version)
+}
@@ -0,0 +1,53 @@
+package maven
+
+import (
+	"encoding/xml"
+	"fmt"
+	"github.com/samber/lo"
+	"io"
+	"strings"
+)
+
+// Metadata represents 'maven-metadata.xml' of snapshot version directory
+type Metadata struct {
+	Versioning MetadataVersioning `xml:"versioning"`
+}
+
+type MetadataVersioning struct {
+	Snapshot         MetadataSnapshot          `xml:"snapshot"`
+	SnapshotVersions []MetadataSnapshotVersion `xml:"snapshotVersions>snapshotVersion"`
+}
+
+type MetadataSnapshot struct {
+	Timestamp   string `xml:"timestamp"`
+	BuildNumber int    `xml:"buildNumber"`
+}
+
+type MetadataSnapshotVersion struct {
+	Classifier string `xml:"classifier"`
+	Extension  string `xml:"extension"`
+	Value      string `xml:"value"`
+}
+
+func ReadMetadata(reader io.Reader) (*Metadata, error) {
+	result := new(Metadata)
+	if err := xml.NewDecoder(reader).Decode(result); err != nil {
+		return nil, fmt.Errorf("cannot parse Maven metadata: %w", err)
+	}
+	return result, nil
+}
+
+// SnapshotVersion determines timestamped version of the snapshot artifact (e.g. '1.0.0-20240101.120000-3')
+func (m Metadata) SnapshotVersion(artifact Artifact) (string, error) {
+	snapshotVersion, ok := lo.Find(m.Versioning.SnapshotVersions, func(sv MetadataSnapshotVersion) bool {
+		return sv.Classifier == artifact.Classifier && sv.Extension == artifact.Extension
+	})
+	if ok {
+		return snapshotVersion.Value, nil
+	}
+	snapshot := m.Versioning.Snapshot
+	if snapshot.Timestamp == "" || snapshot.BuildNumber == 0 {
+		return "", fmt.Errorf("maven metadata does not contain snapshot version of artifact '%s'", artifact)
+	}
+	return fmt.Sprintf("%s-%s-%d", strings.TrimSuffix(artifact.Version, SnapshotSuffix), snapshot.Timestamp, snapshot.BuildNumber), nil
+}
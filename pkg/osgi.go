@@ -26,8 +26,8 @@ func NewOSGi(instance *Instance) *OSGi {
 
 		bundleManager:    NewBundleManager(instance),
 		componentManager: NewComponentManager(instance),
-		eventManager:     &OSGiEventManager{instance: instance},
-		configManager:    &OSGiConfigManager{instance: instance},
+		eventManager:     NewEventManager(instance),
+		configManager:    NewConfigManager(instance),
 
 		shutdownDelay: cv.GetDuration("instance.osgi.shutdown_delay"),
 	}
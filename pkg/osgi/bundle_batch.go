@@ -0,0 +1,144 @@
+package osgi
+
+import (
+	"bytes"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/wttech/aemc/pkg/common/fmtx"
+)
+
+// BundleFile is a local bundle JAR with its manifest
+type BundleFile struct {
+	Path     string
+	Manifest BundleManifest
+}
+
+func (f BundleFile) Fragment() bool {
+	return f.Manifest.FragmentHost != ""
+}
+
+func (f BundleFile) fragmentHost() string {
+	return strings.TrimSpace(strings.Split(f.Manifest.FragmentHost, ";")[0])
+}
+
+// ReadBundleFiles reads manifests of all JAR files found in directory (recursively)
+func ReadBundleFiles(dir string) ([]BundleFile, error) {
+	var result []BundleFile
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.EqualFold(filepath.Ext(path), ".jar") {
+			return nil
+		}
+		manifest, err := ReadBundleManifest(path)
+		if err != nil {
+			return err
+		}
+		result = append(result, BundleFile{Path: path, Manifest: *manifest})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Path < result[j].Path })
+	return result, nil
+}
+
+// OrderBundleFiles sorts bundles so that ones exporting packages (or hosting fragments) come before ones importing them;
+// bundles in dependency cycles are appended in original order
+func OrderBundleFiles(files []BundleFile) []BundleFile {
+	exporters := map[string][]int{}
+	for i, f := range files {
+		for _, pkg := range f.Manifest.ExportedPackages() {
+			exporters[pkg.Name] = append(exporters[pkg.Name], i)
+		}
+	}
+	deps := make([]map[int]bool, len(files))
+	for i, f := range files {
+		deps[i] = map[int]bool{}
+		for _, pkg := range f.Manifest.ImportedPackages() {
+			for _, e := range exporters[pkg.Name] {
+				if e != i {
+					deps[i][e] = true
+				}
+			}
+		}
+		if f.Fragment() {
+			for j, host := range files {
+				if j != i && host.Manifest.SymbolicName == f.fragmentHost() {
+					deps[i][j] = true
+				}
+			}
+		}
+	}
+	var result []BundleFile
+	done := make([]bool, len(files))
+	for len(result) < len(files) {
+		progressed := false
+		for i, f := range files {
+			if done[i] || lo.SomeBy(lo.Keys(deps[i]), func(d int) bool { return !done[d] }) {
+				continue
+			}
+			done[i] = true
+			result = append(result, f)
+			progressed = true
+		}
+		if !progressed {
+			for i, f := range files {
+				if !done[i] {
+					done[i] = true
+					result = append(result, f)
+				}
+			}
+		}
+	}
+	return result
+}
+
+// BundleFilesExports collects packages exported by bundle files (to be merged with ones exported on instance)
+func BundleFilesExports(files []BundleFile) map[string][]BundleExporter {
+	result := map[string][]BundleExporter{}
+	for _, f := range files {
+		for _, pkg := range f.Manifest.ExportedPackages() {
+			result[pkg.Name] = append(result[pkg.Name], BundleExporter{SymbolicName: f.Manifest.SymbolicName, Version: pkg.Version})
+		}
+	}
+	return result
+}
+
+// BundleBatchInstall is a result of installing many bundles with a single packages refresh
+type BundleBatchInstall struct {
+	Dir       string            `yaml:"dir" json:"dir"`
+	Refreshed bool              `yaml:"refreshed" json:"refreshed"`
+	Bundles   []BundleBatchItem `yaml:"bundles" json:"bundles"`
+}
+
+type BundleBatchItem struct {
+	File         string `yaml:"file" json:"file"`
+	SymbolicName string `yaml:"symbolic_name" json:"symbolicName"`
+	Version      string `yaml:"version" json:"version"`
+	Installed    bool   `yaml:"installed" json:"installed"`
+	Started      bool   `yaml:"started" json:"started"`
+}
+
+func (b BundleBatchInstall) Changed() bool {
+	return lo.SomeBy(b.Bundles, func(i BundleBatchItem) bool { return i.Installed || i.Started })
+}
+
+func (b BundleBatchInstall) MarshalText() string {
+	bs := bytes.NewBufferString("")
+	bs.WriteString(fmtx.TblRows("bundles", true, []string{"symbolic name", "version", "installed", "started"}, lo.Map(b.Bundles, func(i BundleBatchItem, _ int) map[string]any {
+		return map[string]any{
+			"symbolic name": i.SymbolicName,
+			"version":       i.Version,
+			"installed":     i.Installed,
+			"started":       i.Started,
+		}
+	})))
+	return bs.String()
+}
@@ -0,0 +1,47 @@
+package osgi_test
+
+import (
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/wttech/aemc/pkg/osgi"
+)
+
+func TestOrderBundleFiles(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	files := []osgi.BundleFile{
+		{Path: "core.jar", Manifest: osgi.BundleManifest{SymbolicName: "com.example.core", ImportPackage: `com.example.api;version="[1,2)",org.osgi.framework`, ExportPackage: "com.example.core"}},
+		{Path: "fragment.jar", Manifest: osgi.BundleManifest{SymbolicName: "com.example.fragment", FragmentHost: "com.example.api;bundle-version=1.0"}},
+		{Path: "web.jar", Manifest: osgi.BundleManifest{SymbolicName: "com.example.web", ImportPackage: "com.example.core,com.example.api"}},
+		{Path: "api.jar", Manifest: osgi.BundleManifest{SymbolicName: "com.example.api", ExportPackage: `com.example.api;version="1.0.0"`}},
+	}
+	ordered := lo.Map(osgi.OrderBundleFiles(files), func(f osgi.BundleFile, _ int) string { return f.Path })
+	a.Equal([]string{"api.jar", "core.jar", "fragment.jar", "web.jar"}, ordered)
+}
+
+func TestOrderBundleFilesCycle(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	files := []osgi.BundleFile{
+		{Path: "a.jar", Manifest: osgi.BundleManifest{SymbolicName: "a", ImportPackage: "b", ExportPackage: "a"}},
+		{Path: "b.jar", Manifest: osgi.BundleManifest{SymbolicName: "b", ImportPackage: "a", ExportPackage: "b"}},
+		{Path: "c.jar", Manifest: osgi.BundleManifest{SymbolicName: "c"}},
+	}
+	ordered := lo.Map(osgi.OrderBundleFiles(files), func(f osgi.BundleFile, _ int) string { return f.Path })
+	a.Equal([]string{"c.jar", "a.jar", "b.jar"}, ordered)
+}
+
+func TestBundleFilesExports(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	exports := osgi.BundleFilesExports([]osgi.BundleFile{
+		{Manifest: osgi.BundleManifest{SymbolicName: "com.example.api", ExportPackage: `com.example.api;version="1.2.0",com.example.spi`}},
+	})
+	a.Equal([]osgi.BundleExporter{{SymbolicName: "com.example.api", Version: "1.2.0"}}, exports["com.example.api"])
+	a.Len(exports["com.example.spi"], 1)
+}
@@ -0,0 +1,86 @@
+package osgi
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/samber/lo"
+	"github.com/wttech/aemc/pkg/common/fmtx"
+	"strings"
+)
+
+const (
+	BundleInstallCheckNone = "none"
+	BundleInstallCheckWarn = "warn"
+	BundleInstallCheckFail = "fail"
+)
+
+func BundleInstallChecks() []string {
+	return []string{BundleInstallCheckNone, BundleInstallCheckWarn, BundleInstallCheckFail}
+}
+
+// BundleCheck is a result of checking bundle file against packages exported on instance before installing it
+type BundleCheck struct {
+	File             string                 `yaml:"file" json:"file"`
+	SymbolicName     string                 `yaml:"symbolic_name" json:"symbolicName"`
+	Version          string                 `yaml:"version" json:"version"`
+	InstalledVersion string                 `yaml:"installed_version,omitempty" json:"installedVersion,omitempty"`
+	Downgrade        bool                   `yaml:"downgrade" json:"downgrade"`
+	Missing          []BundleMissingPackage `yaml:"missing,omitempty" json:"missing,omitempty"`
+}
+
+// CheckBundle verifies if all required imports of bundle could be wired to exports available on instance
+func CheckBundle(file string, manifest BundleManifest, installed *BundleListItem, exports map[string][]BundleExporter) BundleCheck {
+	result := BundleCheck{File: file, SymbolicName: manifest.SymbolicName, Version: manifest.Version}
+	if installed != nil {
+		result.InstalledVersion = installed.Version
+		installedVersion, errInstalled := ParseVersion(installed.Version)
+		version, err := ParseVersion(manifest.Version)
+		result.Downgrade = errInstalled == nil && err == nil && installedVersion.Compare(version) > 0
+	}
+	selfExported := lo.Map(manifest.ExportedPackages(), func(p BundlePackage, _ int) string { return p.Name })
+	for _, pkg := range manifest.ImportedPackages() {
+		if pkg.Optional || strings.HasPrefix(pkg.Name, "java.") || lo.Contains(selfExported, pkg.Name) {
+			continue
+		}
+		missing := diagnoseMissingPackage(pkg, exports[pkg.Name])
+		if !lo.SomeBy(missing.Exporters, func(e BundleExporter) bool { return e.InRange }) {
+			result.Missing = append(result.Missing, missing)
+		}
+	}
+	return result
+}
+
+// Resolvable tells if bundle is expected to leave 'Installed' state after installation
+func (c BundleCheck) Resolvable() bool {
+	return len(c.Missing) == 0
+}
+
+func (c BundleCheck) Problems() []string {
+	var result []string
+	for _, m := range c.Missing {
+		result = append(result, fmt.Sprintf("package '%s' in range '%s' is not available: %s", m.Package, m.Range, m.Suggestion))
+	}
+	if c.Downgrade {
+		result = append(result, fmt.Sprintf("bundle is already installed in higher version '%s' than '%s'", c.InstalledVersion, c.Version))
+	}
+	return result
+}
+
+func (c BundleCheck) MarshalText() string {
+	bs := bytes.NewBufferString("")
+	bs.WriteString(fmtx.TblMap("details", "key", "value", map[string]any{
+		"file":              c.File,
+		"symbolic name":     c.SymbolicName,
+		"version":           c.Version,
+		"installed version": c.InstalledVersion,
+		"downgrade":         c.Downgrade,
+		"resolvable":        c.Resolvable(),
+	}))
+	if len(c.Missing) > 0 {
+		bs.WriteString("\n")
+		bs.WriteString(fmtx.TblRows("missing packages", false, []string{"package", "range", "suggestion"}, lo.Map(c.Missing, func(m BundleMissingPackage, _ int) map[string]any {
+			return map[string]any{"package": m.Package, "range": m.Range, "suggestion": m.Suggestion}
+		})))
+	}
+	return bs.String()
+}
@@ -0,0 +1,44 @@
+package osgi_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/wttech/aemc/pkg/osgi"
+	"testing"
+)
+
+func TestParseBundlePackageHeader(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	packages := osgi.ParseBundlePackageHeader(`com.acme.api;com.acme.spi;version="[1.0,2)",org.slf4j;version="[1.7,2)";resolution:=optional,javax.inject;version=1`)
+	a.Equal([]osgi.BundlePackage{
+		{Name: "com.acme.api", Version: "[1.0,2)"},
+		{Name: "com.acme.spi", Version: "[1.0,2)"},
+		{Name: "org.slf4j", Version: "[1.7,2)", Optional: true},
+		{Name: "javax.inject", Version: "1"},
+	}, packages)
+	a.Empty(osgi.ParseBundlePackageHeader(""))
+}
+
+func TestCheckBundle(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	manifest := osgi.BundleManifest{
+		SymbolicName:  "com.acme.site.core",
+		Version:       "1.2.0",
+		ImportPackage: `com.acme.site.core.models;version="[1.0,2)",com.google.gson;version="[2.9,3)",javax.annotation;version="[1.3,2)";resolution:=optional,org.apache.sling.api;version="[2.3,3)"`,
+		ExportPackage: `com.acme.site.core.models;version="1.2.0"`,
+	}
+	installed := &osgi.BundleListItem{SymbolicName: "com.acme.site.core", Version: "1.3.0"}
+	exports := map[string][]osgi.BundleExporter{
+		"org.apache.sling.api": {{SymbolicName: "org.apache.sling.api", Version: "2.27.2"}},
+		"com.google.gson":      {{SymbolicName: "com.google.gson", Version: "2.8.9"}},
+	}
+	check := osgi.CheckBundle("core.jar", manifest, installed, exports)
+	a.False(check.Resolvable())
+	a.True(check.Downgrade)
+	a.Len(check.Missing, 1)
+	a.Equal("com.google.gson", check.Missing[0].Package)
+	a.Len(check.Problems(), 2)
+}
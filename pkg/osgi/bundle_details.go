@@ -0,0 +1,206 @@
+package osgi
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/samber/lo"
+	"github.com/spf13/cast"
+	"github.com/wttech/aemc/pkg/common/fmtx"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	BundlePropImportedPackages = "Imported Packages"
+	BundlePropExportedPackages = "Exported Packages"
+)
+
+type BundleDetailsResponse struct {
+	Data []BundleDetails `json:"data"`
+}
+
+// BundleDetails is a bundle with properties rendered by Felix Web Console ('/system/console/bundles/{id}.json')
+type BundleDetails struct {
+	BundleListItem
+	Props []BundleDetailsProp `json:"props"`
+}
+
+type BundleDetailsProp struct {
+	Key   string `json:"key"`
+	Value any    `json:"value"`
+}
+
+func (b BundleDetails) PropValues(key string) []string {
+	prop, ok := lo.Find(b.Props, func(p BundleDetailsProp) bool { return p.Key == key })
+	if !ok {
+		return []string{}
+	}
+	if values, ok := prop.Value.([]any); ok {
+		return lo.Map(values, func(v any, _ int) string { return cast.ToString(v) })
+	}
+	return []string{cast.ToString(prop.Value)}
+}
+
+func (b BundleDetails) ImportedPackages() []BundlePackage {
+	return lo.FilterMap(b.PropValues(BundlePropImportedPackages), func(entry string, _ int) (BundlePackage, bool) { return ParseBundlePackage(entry) })
+}
+
+func (b BundleDetails) ExportedPackages() []BundlePackage {
+	return lo.FilterMap(b.PropValues(BundlePropExportedPackages), func(entry string, _ int) (BundlePackage, bool) { return ParseBundlePackage(entry) })
+}
+
+// MissingImports returns required packages which cannot be wired to any exporter
+func (b BundleDetails) MissingImports() []BundlePackage {
+	return lo.Filter(b.ImportedPackages(), func(p BundlePackage, _ int) bool { return p.Missing && !p.Optional })
+}
+
+// BundlePackage is imported or exported Java package (version is a range for imports)
+type BundlePackage struct {
+	Name     string `yaml:"name" json:"name"`
+	Version  string `yaml:"version" json:"version"`
+	Missing  bool   `yaml:"missing,omitempty" json:"missing,omitempty"`
+	Optional bool   `yaml:"optional,omitempty" json:"optional,omitempty"`
+}
+
+var htmlTagRegex = regexp.MustCompile(`<[^>]*>`)
+
+// ParseBundlePackage parses package entry as rendered by Felix Web Console (e.g. 'com.acme,version=[1.0,2) -- Cannot be resolved')
+func ParseBundlePackage(entry string) (BundlePackage, bool) {
+	stripped := strings.TrimSpace(html.UnescapeString(htmlTagRegex.ReplaceAllString(entry, "")))
+	text := strings.TrimSpace(strings.TrimPrefix(stripped, "ERROR:"))
+	if text == "" || strings.HasPrefix(text, "---") {
+		return BundlePackage{}, false
+	}
+	name, rest, _ := strings.Cut(text, ",")
+	name = strings.TrimSpace(name)
+	if strings.ContainsAny(name, " ") {
+		return BundlePackage{}, false
+	}
+	result := BundlePackage{Name: name}
+	if _, version, ok := strings.Cut(rest, "version="); ok {
+		result.Version, _, _ = strings.Cut(version, " ")
+		result.Version = strings.Trim(result.Version, `"`)
+	}
+	lower := strings.ToLower(text)
+	result.Missing = strings.Contains(lower, "cannot be resolved") || strings.HasPrefix(stripped, "ERROR:")
+	result.Optional = strings.Contains(lower, "optional") || strings.Contains(lower, "not required")
+	return result, true
+}
+
+// BundleExporter is a bundle exporting package in particular version
+type BundleExporter struct {
+	SymbolicName string `yaml:"symbolic_name" json:"symbolicName"`
+	Version      string `yaml:"version" json:"version"`
+	InRange      bool   `yaml:"in_range" json:"inRange"`
+}
+
+type BundleMissingPackage struct {
+	Package    string           `yaml:"package" json:"package"`
+	Range      string           `yaml:"range" json:"range"`
+	Exporters  []BundleExporter `yaml:"exporters,omitempty" json:"exporters,omitempty"`
+	Suggestion string           `yaml:"suggestion" json:"suggestion"`
+}
+
+type BundleDiagnosis struct {
+	ID           int                    `yaml:"id" json:"id"`
+	SymbolicName string                 `yaml:"symbolic_name" json:"symbolicName"`
+	Version      string                 `yaml:"version" json:"version"`
+	State        string                 `yaml:"state" json:"state"`
+	Stable       bool                   `yaml:"stable" json:"stable"`
+	Reasons      []string               `yaml:"reasons" json:"reasons"`
+	Missing      []BundleMissingPackage `yaml:"missing,omitempty" json:"missing,omitempty"`
+}
+
+// DiagnoseBundle explains why bundle is not active; exports maps package names to bundles exporting them
+func DiagnoseBundle(bundle BundleDetails, exports map[string][]BundleExporter) BundleDiagnosis {
+	result := BundleDiagnosis{ID: bundle.ID, SymbolicName: bundle.SymbolicName, Version: bundle.Version, State: bundle.State, Stable: bundle.Stable()}
+	for _, pkg := range bundle.MissingImports() {
+		result.Missing = append(result.Missing, diagnoseMissingPackage(pkg, exports[pkg.Name]))
+	}
+	switch {
+	case len(result.Missing) > 0:
+		result.Reasons = append(result.Reasons, fmt.Sprintf("cannot resolve as %d required package(s) are not wired: %s", len(result.Missing), strings.Join(lo.Map(result.Missing, func(m BundleMissingPackage, _ int) string { return m.Package }), ", ")))
+	case result.Stable:
+		result.Reasons = append(result.Reasons, "bundle is active")
+	case bundle.StateRaw == int(BundleStateRawInstalled):
+		result.Reasons = append(result.Reasons, "cannot resolve although all packages are available; check other requirements (e.g. fragment host, capabilities) or refresh packages")
+	case bundle.StateRaw == int(BundleStateRawResolved):
+		result.Reasons = append(result.Reasons, "resolved but not started; check if it is stopped manually, its start level or activator errors in logs")
+	default:
+		result.Reasons = append(result.Reasons, fmt.Sprintf("bundle is in transitional state '%s'", bundle.State))
+	}
+	return result
+}
+
+func diagnoseMissingPackage(pkg BundlePackage, exporters []BundleExporter) BundleMissingPackage {
+	result := BundleMissingPackage{Package: pkg.Name, Range: pkg.Version}
+	versionRange, err := ParseVersionRange(pkg.Version)
+	if err != nil {
+		result.Suggestion = fmt.Sprintf("cannot check exporters as version range '%s' is invalid", pkg.Version)
+		return result
+	}
+	for _, exporter := range exporters {
+		version, err := ParseVersion(exporter.Version)
+		exporter.InRange = err == nil && versionRange.Includes(version)
+		result.Exporters = append(result.Exporters, exporter)
+	}
+	if len(result.Exporters) == 0 {
+		result.Suggestion = fmt.Sprintf("no bundle exports package '%s'; install bundle providing it or embed it", pkg.Name)
+		return result
+	}
+	if matching, ok := lo.Find(result.Exporters, func(e BundleExporter) bool { return e.InRange }); ok {
+		result.Suggestion = fmt.Sprintf("bundle '%s' exports version '%s' matching range; refresh packages or restart bundle", matching.SymbolicName, matching.Version)
+		return result
+	}
+	closest := closestExporter(result.Exporters, versionRange)
+	result.Suggestion = fmt.Sprintf("bundle '%s' exports version '%s' out of range '%s'; align dependency version or adjust import range", closest.SymbolicName, closest.Version, versionRange)
+	return result
+}
+
+// closestExporter picks exporter with highest version below range or otherwise the lowest one above it
+func closestExporter(exporters []BundleExporter, versionRange VersionRange) BundleExporter {
+	sorted := append([]BundleExporter{}, exporters...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		vi, _ := ParseVersion(sorted[i].Version)
+		vj, _ := ParseVersion(sorted[j].Version)
+		return vi.Compare(vj) < 0
+	})
+	below := lo.Filter(sorted, func(e BundleExporter, _ int) bool {
+		v, _ := ParseVersion(e.Version)
+		return v.Compare(versionRange.Floor) < 0
+	})
+	if len(below) > 0 {
+		return below[len(below)-1]
+	}
+	return sorted[0]
+}
+
+type BundleDiagnosisReport struct {
+	Bundles []BundleDiagnosis `yaml:"bundles" json:"bundles"`
+}
+
+func (r BundleDiagnosisReport) Unstable() []BundleDiagnosis {
+	return lo.Filter(r.Bundles, func(d BundleDiagnosis, _ int) bool { return !d.Stable })
+}
+
+func (r BundleDiagnosisReport) MarshalText() string {
+	bs := bytes.NewBufferString("")
+	bs.WriteString(fmtx.TblMap("stats", "stat", "value", map[string]any{"diagnosed": len(r.Bundles), "unstable": len(r.Unstable())}))
+	bs.WriteString("\n")
+	bs.WriteString(fmtx.TblRows("bundles", false, []string{"symbolic name", "state", "reasons"}, lo.Map(r.Bundles, func(d BundleDiagnosis, _ int) map[string]any {
+		return map[string]any{"symbolic name": d.SymbolicName, "state": d.State, "reasons": strings.Join(d.Reasons, "; ")}
+	})))
+	var missing []map[string]any
+	for _, d := range r.Bundles {
+		for _, m := range d.Missing {
+			missing = append(missing, map[string]any{"symbolic name": d.SymbolicName, "package": m.Package, "range": m.Range, "suggestion": m.Suggestion})
+		}
+	}
+	if len(missing) > 0 {
+		bs.WriteString("\n")
+		bs.WriteString(fmtx.TblRows("missing packages", false, []string{"symbolic name", "package", "range", "suggestion"}, missing))
+	}
+	return bs.String()
+}
@@ -0,0 +1,53 @@
+package osgi_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/wttech/aemc/pkg/osgi"
+	"testing"
+)
+
+func TestParseBundlePackage(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	pkg, ok := osgi.ParseBundlePackage("com.acme.api,version=1.2.0 from <a href='/system/console/bundles/12'>com.acme.core (12)</a>")
+	a.True(ok)
+	a.Equal(osgi.BundlePackage{Name: "com.acme.api", Version: "1.2.0"}, pkg)
+
+	pkg, ok = osgi.ParseBundlePackage("<span style='color: red;'>ERROR: com.google.gson,version=[2.9,3) -- Cannot be resolved</span>")
+	a.True(ok)
+	a.Equal(osgi.BundlePackage{Name: "com.google.gson", Version: "[2.9,3)", Missing: true}, pkg)
+
+	pkg, ok = osgi.ParseBundlePackage("ERROR: org.slf4j.ext,version=[1.7,2) -- Cannot be resolved but is not required")
+	a.True(ok)
+	a.True(pkg.Optional)
+
+	_, ok = osgi.ParseBundlePackage("---")
+	a.False(ok)
+}
+
+func TestDiagnoseBundle(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	bundle := osgi.BundleDetails{
+		BundleListItem: osgi.BundleListItem{ID: 500, SymbolicName: "com.acme.site.core", State: "Installed", StateRaw: int(osgi.BundleStateRawInstalled)},
+		Props: []osgi.BundleDetailsProp{{Key: osgi.BundlePropImportedPackages, Value: []any{
+			"org.apache.sling.api,version=[2.3,3) from org.apache.sling.api (40)",
+			"ERROR: com.google.gson,version=[2.9,3) -- Cannot be resolved",
+			"ERROR: com.acme.missing,version=[1.0,2) -- Cannot be resolved",
+			"ERROR: com.acme.utils,version=[1.0,2) -- Cannot be resolved",
+		}}},
+	}
+	exports := map[string][]osgi.BundleExporter{
+		"com.google.gson": {{SymbolicName: "com.google.gson", Version: "2.8.9"}, {SymbolicName: "gson-shaded", Version: "3.1.0"}},
+		"com.acme.utils":  {{SymbolicName: "com.acme.utils", Version: "1.4.0"}},
+	}
+	diagnosis := osgi.DiagnoseBundle(bundle, exports)
+	a.False(diagnosis.Stable)
+	a.Len(diagnosis.Missing, 3)
+	a.Contains(diagnosis.Missing[0].Suggestion, "'com.google.gson' exports version '2.8.9' out of range '[2.9.0,3.0.0)'")
+	a.Contains(diagnosis.Missing[1].Suggestion, "no bundle exports package 'com.acme.missing'")
+	a.Contains(diagnosis.Missing[2].Suggestion, "refresh packages")
+	a.Contains(diagnosis.Reasons[0], "com.google.gson, com.acme.missing, com.acme.utils")
+}
@@ -0,0 +1,268 @@
+package osgi
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/samber/lo"
+	"github.com/wttech/aemc/pkg/common/fmtx"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	ComponentPropServices        = "Services"
+	ComponentPropReferencePrefix = "Reference "
+
+	ComponentGraphFormatDot     = "dot"
+	ComponentGraphFormatMermaid = "mermaid"
+	ComponentGraphFormatJSON    = "json"
+)
+
+func ComponentGraphFormats() []string {
+	return []string{ComponentGraphFormatDot, ComponentGraphFormatMermaid, ComponentGraphFormatJSON}
+}
+
+type ComponentDetailsResponse struct {
+	Data []ComponentDetails `json:"data"`
+}
+
+// ComponentDetails is a component with properties rendered by Felix Web Console ('/system/console/components/{id}.json')
+type ComponentDetails struct {
+	ComponentListItem
+	Props []BundleDetailsProp `json:"props"`
+}
+
+func (c ComponentDetails) PropValues(key string) []string {
+	return BundleDetails{Props: c.Props}.PropValues(key)
+}
+
+// Services returns interfaces provided by component
+func (c ComponentDetails) Services() []string {
+	return lo.FlatMap(c.PropValues(ComponentPropServices), func(v string, _ int) []string {
+		return lo.Compact(lo.Map(strings.Split(v, ","), func(s string, _ int) string { return strings.TrimSpace(s) }))
+	})
+}
+
+type ComponentReference struct {
+	Name        string   `yaml:"name" json:"name"`
+	Interface   string   `yaml:"interface" json:"interface"`
+	Cardinality string   `yaml:"cardinality" json:"cardinality"`
+	Policy      string   `yaml:"policy,omitempty" json:"policy,omitempty"`
+	Target      string   `yaml:"target,omitempty" json:"target,omitempty"`
+	Satisfied   bool     `yaml:"satisfied" json:"satisfied"`
+	Bound       []string `yaml:"bound,omitempty" json:"bound,omitempty"`
+}
+
+// Optional tells if reference does not need to be bound for component to be satisfied
+func (r ComponentReference) Optional() bool {
+	return strings.HasPrefix(r.Cardinality, "0")
+}
+
+var componentBoundRegex = regexp.MustCompile(`^Bound Service ID \d+(?: \((.+)\))?`)
+
+// References parses references as rendered by Felix SCR plugin (e.g. 'Reference resolverFactory' with lines 'Satisfied', 'Service Name: ...', 'Cardinality: 1..1')
+func (c ComponentDetails) References() []ComponentReference {
+	var result []ComponentReference
+	for _, prop := range c.Props {
+		if !strings.HasPrefix(prop.Key, ComponentPropReferencePrefix) {
+			continue
+		}
+		ref := ComponentReference{Name: strings.TrimPrefix(prop.Key, ComponentPropReferencePrefix)}
+		for _, line := range c.PropValues(prop.Key) {
+			line = strings.TrimSpace(htmlTagRegex.ReplaceAllString(line, ""))
+			key, value, _ := strings.Cut(line, ":")
+			value = strings.TrimSpace(value)
+			switch {
+			case line == "Satisfied":
+				ref.Satisfied = true
+			case key == "Service Name" || key == "Interface":
+				ref.Interface = value
+			case key == "Cardinality" || key == "Multiple":
+				ref.Cardinality = lo.CoalesceOrEmpty(ref.Cardinality, value)
+			case key == "Policy":
+				ref.Policy = value
+			case key == "Target Filter" || key == "Target":
+				ref.Target = value
+			default:
+				if groups := componentBoundRegex.FindStringSubmatch(line); groups != nil {
+					ref.Bound = append(ref.Bound, groups[1])
+				}
+			}
+		}
+		result = append(result, ref)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+type ComponentGraph struct {
+	Nodes []ComponentGraphNode `yaml:"nodes" json:"nodes"`
+	Edges []ComponentGraphEdge `yaml:"edges" json:"edges"`
+}
+
+type ComponentGraphNode struct {
+	ID       string   `yaml:"id" json:"id"`
+	State    string   `yaml:"state,omitempty" json:"state,omitempty"`
+	Services []string `yaml:"services,omitempty" json:"services,omitempty"`
+	// Missing marks service interface which no component provides
+	Missing bool `yaml:"missing,omitempty" json:"missing,omitempty"`
+}
+
+type ComponentGraphEdge struct {
+	From        string `yaml:"from" json:"from"`
+	To          string `yaml:"to" json:"to"`
+	Reference   string `yaml:"reference" json:"reference"`
+	Interface   string `yaml:"interface" json:"interface"`
+	Cardinality string `yaml:"cardinality" json:"cardinality"`
+	Satisfied   bool   `yaml:"satisfied" json:"satisfied"`
+}
+
+// NewComponentGraph links components by references; unsatisfied references without provider point to missing service interface nodes
+func NewComponentGraph(components []ComponentDetails) ComponentGraph {
+	result := ComponentGraph{}
+	nodes := map[string]*ComponentGraphNode{}
+	addNode := func(node ComponentGraphNode) {
+		if existing, ok := nodes[node.ID]; ok {
+			if node.State != "" {
+				*existing = node
+			}
+			return
+		}
+		nodes[node.ID] = &node
+	}
+	providers := map[string][]string{}
+	for _, c := range components {
+		addNode(ComponentGraphNode{ID: c.UID(), State: c.State, Services: c.Services()})
+		for _, service := range c.Services() {
+			providers[service] = append(providers[service], c.UID())
+		}
+	}
+	for _, c := range components {
+		for _, ref := range c.References() {
+			edge := ComponentGraphEdge{From: c.UID(), Reference: ref.Name, Interface: ref.Interface, Cardinality: ref.Cardinality, Satisfied: ref.Satisfied}
+			targets := lo.Compact(ref.Bound)
+			if len(targets) == 0 {
+				targets = providers[ref.Interface]
+			}
+			if len(targets) == 0 {
+				if ref.Satisfied || ref.Optional() {
+					continue
+				}
+				addNode(ComponentGraphNode{ID: ref.Interface, Missing: true})
+				targets = []string{ref.Interface}
+			}
+			for _, target := range targets {
+				addNode(ComponentGraphNode{ID: target})
+				edge.To = target
+				result.Edges = append(result.Edges, edge)
+			}
+		}
+	}
+	result.Nodes = lo.Map(lo.Values(nodes), func(n *ComponentGraphNode, _ int) ComponentGraphNode { return *n })
+	sort.Slice(result.Nodes, func(i, j int) bool { return result.Nodes[i].ID < result.Nodes[j].ID })
+	return result
+}
+
+func (g ComponentGraph) Unsatisfied() []ComponentGraphEdge {
+	return lo.Filter(g.Edges, func(e ComponentGraphEdge, _ int) bool { return !e.Satisfied })
+}
+
+func (g ComponentGraph) node(id string) ComponentGraphNode {
+	node, _ := lo.Find(g.Nodes, func(n ComponentGraphNode) bool { return n.ID == id })
+	return node
+}
+
+func (g ComponentGraph) nodeUnsatisfied(n ComponentGraphNode) bool {
+	return n.State == ComponentStateUnsatisfiedReference || strings.HasPrefix(n.State, "unsatisfied")
+}
+
+func (g ComponentGraph) Render(format string) (string, error) {
+	switch format {
+	case ComponentGraphFormatDot:
+		return g.renderDot(), nil
+	case ComponentGraphFormatMermaid:
+		return g.renderMermaid(), nil
+	case ComponentGraphFormatJSON:
+		return fmtx.MarshalJSON(g)
+	}
+	return "", fmt.Errorf("unsupported component graph format '%s' (supported: %s)", format, strings.Join(ComponentGraphFormats(), ", "))
+}
+
+func (g ComponentGraph) renderDot() string {
+	bs := bytes.NewBufferString("digraph components {\n  rankdir=LR;\n  node [shape=box];\n")
+	for _, n := range g.Nodes {
+		var attrs []string
+		if n.Missing {
+			attrs = append(attrs, "shape=ellipse", "style=dashed", "color=red", "fontcolor=red")
+		} else if g.nodeUnsatisfied(n) {
+			attrs = append(attrs, "color=red", "fontcolor=red")
+		}
+		bs.WriteString(fmt.Sprintf("  %q", n.ID))
+		if len(attrs) > 0 {
+			bs.WriteString(fmt.Sprintf(" [%s]", strings.Join(attrs, ", ")))
+		}
+		bs.WriteString(";\n")
+	}
+	for _, e := range g.Edges {
+		attrs := []string{fmt.Sprintf("label=%q", fmt.Sprintf("%s (%s)", e.Reference, e.Cardinality))}
+		if !e.Satisfied {
+			attrs = append(attrs, "color=red", "fontcolor=red")
+		}
+		bs.WriteString(fmt.Sprintf("  %q -> %q [%s];\n", e.From, e.To, strings.Join(attrs, ", ")))
+	}
+	bs.WriteString("}\n")
+	return bs.String()
+}
+
+func (g ComponentGraph) renderMermaid() string {
+	ids := map[string]string{}
+	for i, n := range g.Nodes {
+		ids[n.ID] = fmt.Sprintf("n%d", i)
+	}
+	bs := bytes.NewBufferString("graph LR\n")
+	var unsatisfied, missing []string
+	for _, n := range g.Nodes {
+		if n.Missing {
+			bs.WriteString(fmt.Sprintf("  %s([\"%s\"])\n", ids[n.ID], mermaidEscape(n.ID)))
+			missing = append(missing, ids[n.ID])
+		} else {
+			bs.WriteString(fmt.Sprintf("  %s[\"%s\"]\n", ids[n.ID], mermaidEscape(n.ID)))
+			if g.nodeUnsatisfied(n) {
+				unsatisfied = append(unsatisfied, ids[n.ID])
+			}
+		}
+	}
+	for _, e := range g.Edges {
+		arrow := lo.Ternary(e.Satisfied, "-->", "-.->")
+		bs.WriteString(fmt.Sprintf("  %s %s|\"%s (%s)\"| %s\n", ids[e.From], arrow, mermaidEscape(e.Reference), e.Cardinality, ids[e.To]))
+	}
+	bs.WriteString("  classDef unsatisfied stroke:#f00,color:#f00\n")
+	bs.WriteString("  classDef missing stroke:#f00,stroke-dasharray:5 5,color:#f00\n")
+	if len(unsatisfied) > 0 {
+		bs.WriteString(fmt.Sprintf("  class %s unsatisfied\n", strings.Join(unsatisfied, ",")))
+	}
+	if len(missing) > 0 {
+		bs.WriteString(fmt.Sprintf("  class %s missing\n", strings.Join(missing, ",")))
+	}
+	return bs.String()
+}
+
+func mermaidEscape(text string) string {
+	return strings.ReplaceAll(text, `"`, "#quot;")
+}
+
+func (g ComponentGraph) MarshalText() string {
+	bs := bytes.NewBufferString("")
+	bs.WriteString(fmtx.TblMap("stats", "stat", "value", map[string]any{
+		"components":  len(lo.Filter(g.Nodes, func(n ComponentGraphNode, _ int) bool { return !n.Missing })),
+		"references":  len(g.Edges),
+		"unsatisfied": len(g.Unsatisfied()),
+		"missing":     len(lo.Filter(g.Nodes, func(n ComponentGraphNode, _ int) bool { return n.Missing })),
+	}))
+	bs.WriteString("\n")
+	bs.WriteString(fmtx.TblRows("unsatisfied references", false, []string{"component", "reference", "interface", "provider"}, lo.Map(g.Unsatisfied(), func(e ComponentGraphEdge, _ int) map[string]any {
+		return map[string]any{"component": e.From, "reference": e.Reference, "interface": e.Interface, "provider": lo.Ternary(g.node(e.To).Missing, "(missing)", e.To)}
+	})))
+	return bs.String()
+}
@@ -0,0 +1,49 @@
+package osgi_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/wttech/aemc/pkg/osgi"
+	"testing"
+)
+
+func TestNewComponentGraph(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	components := []osgi.ComponentDetails{
+		{
+			ComponentListItem: osgi.ComponentListItem{ID: "1", Name: "com.acme.core.SearchService", State: osgi.ComponentStateUnsatisfiedReference},
+			Props: []osgi.BundleDetailsProp{
+				{Key: "Services", Value: []any{"com.acme.core.api.Search"}},
+				{Key: "Reference resolverFactory", Value: []any{"Satisfied", "Service Name: org.apache.sling.api.resource.ResourceResolverFactory", "Cardinality: 1..1", "Policy: static", "Bound Service ID 12 (org.apache.sling.resourceresolver.impl.ResourceResolverFactoryActivator)"}},
+				{Key: "Reference indexer", Value: []any{"Unsatisfied", "Service Name: com.acme.core.api.Indexer", "Cardinality: 1..1", "Policy: static"}},
+				{Key: "Reference metrics", Value: []any{"Unsatisfied", "Service Name: com.acme.core.api.Metrics", "Cardinality: 0..1"}},
+			},
+		},
+		{
+			ComponentListItem: osgi.ComponentListItem{ID: "2", Name: "com.acme.core.SearchServlet", State: osgi.ComponentStateActive},
+			Props: []osgi.BundleDetailsProp{
+				{Key: "Reference search", Value: []any{"Satisfied", "Service Name: com.acme.core.api.Search", "Cardinality: 1..1"}},
+			},
+		},
+	}
+	graph := osgi.NewComponentGraph(components)
+	a.Len(graph.Nodes, 4)
+	a.Len(graph.Edges, 3)
+	unsatisfied := graph.Unsatisfied()
+	a.Len(unsatisfied, 1)
+	a.Equal("com.acme.core.api.Indexer", unsatisfied[0].To)
+
+	dot, err := graph.Render(osgi.ComponentGraphFormatDot)
+	a.NoError(err)
+	a.Contains(dot, `"com.acme.core.api.Indexer" [shape=ellipse, style=dashed, color=red, fontcolor=red];`)
+	a.Contains(dot, `"com.acme.core.SearchServlet" -> "com.acme.core.SearchService" [label="search (1..1)"];`)
+
+	mermaid, err := graph.Render(osgi.ComponentGraphFormatMermaid)
+	a.NoError(err)
+	a.Contains(mermaid, "graph LR\n")
+	a.Contains(mermaid, `-.->|"indexer (1..1)"|`)
+
+	_, err = graph.Render("svg")
+	a.Error(err)
+}
@@ -29,6 +29,7 @@ type ConfigListItem struct {
 	AdditionalProperties string                    `json:"additionalProperties"`
 	BundleLocation       string                    `json:"bundle_location"`
 	ServiceLocation      string                    `json:"service_location"`
+	HasMetatype          *bool                     `json:"has_metatype,omitempty"`
 }
 
 func (c ConfigListItem) PropertyValues() map[string]any {
@@ -47,6 +48,17 @@ func (c ConfigListItem) PropertyValues() map[string]any {
 	return result
 }
 
+// PropertyNamesSet returns names of properties set explicitly (not coming from metatype defaults)
+func (c ConfigListItem) PropertyNamesSet() []string {
+	var result []string
+	for k, def := range c.Properties {
+		if set, ok := def["is_set"].(bool); ok && set {
+			result = append(result, k)
+		}
+	}
+	return result
+}
+
 func (c ConfigListItem) Alias() string {
 	for _, prop := range strings.Split(c.AdditionalProperties, ",") {
 		if strings.HasPrefix(prop, ConfigAliasPropPrefix) {
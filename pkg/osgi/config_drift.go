@@ -0,0 +1,187 @@
+package osgi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/samber/lo"
+	"github.com/spf13/cast"
+	"github.com/wttech/aemc/pkg/common/fmtx"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ConfigSource is a '.cfg.json' file applied by Sling Installer when instance has all run modes of its directory (e.g. 'config.author.dev')
+type ConfigSource struct {
+	PID        string
+	File       string
+	RunModes   []string
+	Properties map[string]any
+}
+
+var configDirRegex = regexp.MustCompile(`^config(\.[^/\\]+)?$`)
+
+// ReadConfigSources finds '.cfg.json' files placed in run mode specific config directories
+func ReadConfigSources(dir string) ([]ConfigSource, error) {
+	var result []ConfigSource
+	if err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ConfigFileExt) {
+			return nil
+		}
+		configDir := filepath.Base(filepath.Dir(path))
+		if !configDirRegex.MatchString(configDir) {
+			return nil
+		}
+		props, err := ReadConfigFile(path)
+		if err != nil {
+			return err
+		}
+		result = append(result, ConfigSource{
+			PID:        strings.TrimSuffix(entry.Name(), ConfigFileExt),
+			File:       path,
+			RunModes:   lo.Compact(strings.Split(strings.TrimPrefix(configDir, "config"), ".")),
+			Properties: props,
+		})
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("cannot read config files from dir '%s': %w", dir, err)
+	}
+	return result, nil
+}
+
+// ReadConfigFile reads '.cfg.json' file; type hints are stripped from property names (e.g. 'port:Integer' becomes 'port')
+func ReadConfigFile(file string) (map[string]any, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read config file '%s': %w", file, err)
+	}
+	var props map[string]any
+	if err := json.Unmarshal(data, &props); err != nil {
+		return nil, fmt.Errorf("cannot parse config file '%s': %w", file, err)
+	}
+	result := map[string]any{}
+	for key, value := range props {
+		name, _, _ := strings.Cut(key, ":")
+		result[name] = value
+	}
+	return result, nil
+}
+
+// ResolveConfigSources picks config sources applicable for run modes; when many apply to the same PID, one with most run modes wins
+func ResolveConfigSources(sources []ConfigSource, runModes []string) []ConfigSource {
+	resolved := map[string]ConfigSource{}
+	for _, source := range sources {
+		if !lo.Every(runModes, source.RunModes) {
+			continue
+		}
+		current, ok := resolved[source.PID]
+		if !ok || len(source.RunModes) > len(current.RunModes) || (len(source.RunModes) == len(current.RunModes) && source.File > current.File) {
+			resolved[source.PID] = source
+		}
+	}
+	result := lo.Values(resolved)
+	sort.Slice(result, func(i, j int) bool { return result[i].PID < result[j].PID })
+	return result
+}
+
+type ConfigDrift struct {
+	PID       string           `yaml:"pid" json:"pid"`
+	File      string           `yaml:"file" json:"file"`
+	Exists    bool             `yaml:"exists" json:"exists"`
+	Missing   []string         `yaml:"missing,omitempty" json:"missing,omitempty"`
+	Extra     []string         `yaml:"extra,omitempty" json:"extra,omitempty"`
+	Different []ConfigPropDiff `yaml:"different,omitempty" json:"different,omitempty"`
+}
+
+type ConfigPropDiff struct {
+	Name     string `yaml:"name" json:"name"`
+	Expected any    `yaml:"expected" json:"expected"`
+	Actual   any    `yaml:"actual" json:"actual"`
+}
+
+func (d ConfigDrift) Drifted() bool {
+	return !d.Exists || len(d.Missing) > 0 || len(d.Extra) > 0 || len(d.Different) > 0
+}
+
+// CompareConfigProps compares values from file with live ones; values with placeholders (e.g. '$[secret:X]') are not compared
+func CompareConfigProps(expected map[string]any, actual map[string]any, actualSet []string) (missing []string, extra []string, different []ConfigPropDiff) {
+	for _, name := range lo.Keys(expected) {
+		expectedValue := expected[name]
+		actualValue, ok := actual[name]
+		if !ok {
+			missing = append(missing, name)
+		} else if !configValuePlaceholder(expectedValue) && !cmpConfigValues(expectedValue, actualValue) {
+			different = append(different, ConfigPropDiff{Name: name, Expected: expectedValue, Actual: actualValue})
+		}
+	}
+	for _, name := range actualSet {
+		if _, ok := expected[name]; !ok && !lo.Contains(configPropsSkipped, name) && !strings.HasPrefix(name, ConfigAliasPropPrefix) {
+			extra = append(extra, name)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(extra)
+	sort.Slice(different, func(i, j int) bool { return different[i].Name < different[j].Name })
+	return
+}
+
+func configValuePlaceholder(value any) bool {
+	if configValueSlice(value) {
+		return lo.SomeBy(cast.ToStringSlice(value), func(v string) bool { return strings.Contains(v, "$[") })
+	}
+	return strings.Contains(cast.ToString(value), "$[")
+}
+
+func configValueSlice(value any) bool {
+	_, ok := value.([]any)
+	return ok
+}
+
+// cmpConfigValues compares values as strings as Felix Web Console renders most of the values as strings
+func cmpConfigValues(expected any, actual any) bool {
+	expectedSlice, actualSlice := configValueSlice(expected), configValueSlice(actual)
+	if expectedSlice != actualSlice {
+		return false
+	}
+	if expectedSlice {
+		return slices.Equal(cast.ToStringSlice(expected), cast.ToStringSlice(actual))
+	}
+	return cast.ToString(expected) == cast.ToString(actual)
+}
+
+type ConfigDriftReport struct {
+	RunModes []string      `yaml:"run_modes" json:"runModes"`
+	Configs  []ConfigDrift `yaml:"configs" json:"configs"`
+}
+
+func (r ConfigDriftReport) Drifted() []ConfigDrift {
+	return lo.Filter(r.Configs, func(d ConfigDrift, _ int) bool { return d.Drifted() })
+}
+
+func (r ConfigDriftReport) MarshalText() string {
+	bs := bytes.NewBufferString("")
+	bs.WriteString(fmtx.TblMap("stats", "stat", "value", map[string]any{
+		"run modes": strings.Join(r.RunModes, ","),
+		"checked":   len(r.Configs),
+		"drifted":   len(r.Drifted()),
+	}))
+	bs.WriteString("\n")
+	bs.WriteString(fmtx.TblRows("drifted", true, []string{"pid", "exists", "missing", "extra", "different"}, lo.Map(r.Drifted(), func(d ConfigDrift, _ int) map[string]any {
+		return map[string]any{
+			"pid":       d.PID,
+			"exists":    d.Exists,
+			"missing":   strings.Join(d.Missing, ", "),
+			"extra":     strings.Join(d.Extra, ", "),
+			"different": strings.Join(lo.Map(d.Different, func(diff ConfigPropDiff, _ int) string { return diff.Name }), ", "),
+		}
+	})))
+	return bs.String()
+}
@@ -0,0 +1,51 @@
+package osgi_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/wttech/aemc/pkg/osgi"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveConfigSources(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	dir := t.TempDir()
+	writeConfigFile := func(configDir string, name string, data string) {
+		file := filepath.Join(dir, "apps", "acme", "osgiconfig", configDir, name)
+		a.NoError(os.MkdirAll(filepath.Dir(file), 0755))
+		a.NoError(os.WriteFile(file, []byte(data), 0644))
+	}
+	writeConfigFile("config", "com.acme.Mailer.cfg.json", `{"host": "localhost"}`)
+	writeConfigFile("config.author", "com.acme.Mailer.cfg.json", `{"host": "author"}`)
+	writeConfigFile("config.author.prod", "com.acme.Mailer.cfg.json", `{"host": "prod"}`)
+	writeConfigFile("config.publish", "com.acme.Cache.cfg.json", `{"size:Integer": 10}`)
+
+	sources, err := osgi.ReadConfigSources(dir)
+	a.NoError(err)
+	a.Len(sources, 4)
+
+	resolved := osgi.ResolveConfigSources(sources, []string{"author", "dev", "crx3"})
+	a.Len(resolved, 1)
+	a.Equal("author", resolved[0].Properties["host"])
+
+	resolved = osgi.ResolveConfigSources(sources, []string{"publish", "prod"})
+	a.Len(resolved, 2)
+	a.Equal("com.acme.Cache", resolved[0].PID)
+	a.Equal(float64(10), resolved[0].Properties["size"])
+	a.Equal("localhost", resolved[1].Properties["host"])
+}
+
+func TestCompareConfigProps(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	expected := map[string]any{"host": "smtp", "port": float64(25), "ssl": true, "tags": []any{"a", "b"}, "password": "$[secret:PASS]", "user": "admin"}
+	actual := map[string]any{"host": "smtp", "port": "25", "ssl": "false", "tags": []any{"a", "b"}, "password": "********", "debug": "true"}
+	missing, extra, different := osgi.CompareConfigProps(expected, actual, []string{"host", "debug", "service.pid"})
+	a.Equal([]string{"user"}, missing)
+	a.Equal([]string{"debug"}, extra)
+	a.Equal([]osgi.ConfigPropDiff{{Name: "ssl", Expected: true, Actual: "false"}}, different)
+}
@@ -0,0 +1,152 @@
+package osgi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/samber/lo"
+	"github.com/spf13/cast"
+	"github.com/wttech/aemc/pkg/common/fmtx"
+	"regexp"
+	"strings"
+)
+
+// Config property types as rendered by Felix Web Console (org.osgi.service.metatype.AttributeDefinition)
+const (
+	ConfigPropTypeString    = 1
+	ConfigPropTypeLong      = 2
+	ConfigPropTypeInteger   = 3
+	ConfigPropTypeShort     = 4
+	ConfigPropTypeCharacter = 5
+	ConfigPropTypeByte      = 6
+	ConfigPropTypeDouble    = 7
+	ConfigPropTypeFloat     = 8
+	ConfigPropTypeBoolean   = 11
+	ConfigPropTypePassword  = 12
+
+	ConfigFileExt = ".cfg.json"
+)
+
+// configPropTypeHints are Sling Installer / OSGi Configurator type hints for types not inferred from JSON values (doubles without fraction would be read as longs)
+var configPropTypeHints = map[int]string{
+	ConfigPropTypeInteger:   "Integer",
+	ConfigPropTypeShort:     "Short",
+	ConfigPropTypeCharacter: "Character",
+	ConfigPropTypeByte:      "Byte",
+	ConfigPropTypeDouble:    "Double",
+	ConfigPropTypeFloat:     "Float",
+}
+
+var configPropsSkipped = []string{"service.pid", "service.factoryPid", "service.bundleLocation", "felix.fileinstall.filename"}
+
+// ExportProperties converts config properties to form used in '.cfg.json' files; unset properties (metatype defaults) could be skipped
+func (c ConfigListItem) ExportProperties(defaultsSkipped bool) map[string]any {
+	result := map[string]any{}
+	for name, def := range c.Properties {
+		if lo.Contains(configPropsSkipped, name) {
+			continue
+		}
+		if set, ok := def["is_set"]; ok && defaultsSkipped && !cast.ToBool(set) {
+			continue
+		}
+		propType := configPropType(def["type"])
+		key := name
+		if hint, ok := configPropTypeHints[propType]; ok {
+			key = name + ":" + hint
+		}
+		if values, ok := def["values"]; ok {
+			items := cast.ToSlice(values)
+			if propType == ConfigPropTypePassword {
+				result[key] = lo.Map(items, func(_ any, i int) any { return ConfigSecretPlaceholder(c.PID, fmt.Sprintf("%s_%d", name, i)) })
+			} else {
+				result[key+lo.Ternary(key != name, "[]", "")] = lo.Map(items, func(item any, _ int) any { return configPropValue(propType, item) })
+			}
+		} else if value, ok := def["value"]; ok {
+			if propType == ConfigPropTypePassword {
+				result[key] = ConfigSecretPlaceholder(c.PID, name)
+			} else {
+				result[key] = configPropValue(propType, value)
+			}
+		}
+	}
+	return result
+}
+
+// configPropType reads type code; options (dropdowns) are rendered as objects and are always strings
+func configPropType(value any) int {
+	if value == nil {
+		return ConfigPropTypeString
+	}
+	if _, ok := value.(map[string]any); ok {
+		return ConfigPropTypeString
+	}
+	return cast.ToInt(value)
+}
+
+func configPropValue(propType int, value any) any {
+	switch propType {
+	case ConfigPropTypeLong, ConfigPropTypeInteger, ConfigPropTypeShort, ConfigPropTypeByte:
+		return cast.ToInt64(value)
+	case ConfigPropTypeDouble, ConfigPropTypeFloat:
+		return cast.ToFloat64(value)
+	case ConfigPropTypeBoolean:
+		return cast.ToBool(value)
+	default:
+		return cast.ToString(value)
+	}
+}
+
+var configSecretNameRegex = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// ConfigSecretPlaceholder returns Cloud Manager secret placeholder used instead of real password value (e.g. '$[secret:MAILER_PASSWORD]')
+func ConfigSecretPlaceholder(pid string, name string) string {
+	fpid, _, _ := strings.Cut(pid, ConfigAliasSeparator)
+	component := fpid[strings.LastIndex(fpid, ".")+1:]
+	return fmt.Sprintf("$[secret:%s]", strings.Trim(strings.ToUpper(configSecretNameRegex.ReplaceAllString(component+"_"+name, "_")), "_"))
+}
+
+// ConfigFileName determines '.cfg.json' file name; factory configs are named using alias (e.g. 'com.acme.Logger~site.cfg.json')
+func ConfigFileName(pid string, fpid string, alias string) string {
+	if fpid == "" {
+		return pid + ConfigFileExt
+	}
+	return fpid + ConfigAliasSeparator + alias + ConfigFileExt
+}
+
+// MarshalConfigJSON renders properties with sorted keys as in files written by developers
+func MarshalConfigJSON(props map[string]any) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(props); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type ConfigExport struct {
+	PID        string `yaml:"pid" json:"pid"`
+	File       string `yaml:"file" json:"file"`
+	Properties int    `yaml:"properties" json:"properties"`
+	Changed    bool   `yaml:"changed" json:"changed"`
+}
+
+type ConfigExportList struct {
+	Dir     string         `yaml:"dir" json:"dir"`
+	Configs []ConfigExport `yaml:"configs" json:"configs"`
+}
+
+func (l ConfigExportList) Changed() []ConfigExport {
+	return lo.Filter(l.Configs, func(c ConfigExport, _ int) bool { return c.Changed })
+}
+
+func (l ConfigExportList) MarshalText() string {
+	bs := bytes.NewBufferString("")
+	bs.WriteString(fmtx.TblMap("stats", "stat", "value", map[string]any{"dir": l.Dir, "total": len(l.Configs), "changed": len(l.Changed())}))
+	bs.WriteString("\n")
+	bs.WriteString(fmtx.TblRows("configs", true, []string{"pid", "file", "properties", "changed"}, lo.Map(l.Configs, func(c ConfigExport, _ int) map[string]any {
+		return map[string]any{"pid": c.PID, "file": c.File, "properties": c.Properties, "changed": c.Changed}
+	})))
+	return bs.String()
+}
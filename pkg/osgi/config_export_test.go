@@ -0,0 +1,57 @@
+package osgi_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/wttech/aemc/pkg/osgi"
+	"testing"
+)
+
+func TestConfigExportProperties(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	config := osgi.ConfigListItem{
+		PID: "com.acme.core.Mailer",
+		Properties: map[string]map[string]any{
+			"host":      {"type": float64(1), "is_set": true, "value": "smtp.acme.com"},
+			"port":      {"type": float64(3), "is_set": true, "value": "25"},
+			"timeout":   {"type": float64(2), "is_set": true, "value": "3000"},
+			"ssl":       {"type": float64(11), "is_set": true, "value": true},
+			"ratio":     {"type": float64(7), "is_set": true, "value": "1"},
+			"weight":    {"type": float64(8), "is_set": true, "value": "0.5"},
+			"password":  {"type": float64(12), "is_set": true, "value": "********"},
+			"ports":     {"type": float64(3), "is_set": true, "values": []any{"25", "587"}},
+			"recipents": {"type": float64(1), "is_set": true, "values": []any{"a@acme.com"}},
+			"mode":      {"type": map[string]any{"labels": []any{"A"}, "values": []any{"a"}}, "is_set": false, "value": "a"},
+		},
+	}
+	a.Equal(map[string]any{
+		"host":            "smtp.acme.com",
+		"port:Integer":    int64(25),
+		"timeout":         int64(3000),
+		"ssl":             true,
+		"ratio:Double":    float64(1),
+		"weight:Float":    0.5,
+		"password":        "$[secret:MAILER_PASSWORD]",
+		"ports:Integer[]": []any{int64(25), int64(587)},
+		"recipents":       []any{"a@acme.com"},
+	}, config.ExportProperties(true))
+	a.Contains(config.ExportProperties(false), "mode")
+}
+
+func TestConfigFileName(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	a.Equal("com.acme.core.Mailer.cfg.json", osgi.ConfigFileName("com.acme.core.Mailer", "", ""))
+	a.Equal("org.apache.sling.commons.log.LogManager.factory.config~acme.cfg.json", osgi.ConfigFileName("org.apache.sling.commons.log.LogManager.factory.config~acme", "org.apache.sling.commons.log.LogManager.factory.config", "acme"))
+}
+
+func TestMarshalConfigJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	data, err := osgi.MarshalConfigJSON(map[string]any{"b": "<x>", "a": int64(1)})
+	a.NoError(err)
+	a.Equal("{\n  \"a\": 1,\n  \"b\": \"<x>\"\n}\n", string(data))
+}
@@ -0,0 +1,260 @@
+package osgi
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cast"
+	"github.com/wttech/aemc/pkg/common/fmtx"
+)
+
+const (
+	ConfigValidateNone = "none"
+	ConfigValidateWarn = "warn"
+	ConfigValidateFail = "fail"
+)
+
+func ConfigValidateModes() []string {
+	return []string{ConfigValidateNone, ConfigValidateWarn, ConfigValidateFail}
+}
+
+// ConfigMetatype describes config properties as defined by component metatype (as rendered by Felix Web Console)
+type ConfigMetatype struct {
+	PID        string
+	Properties map[string]ConfigPropMetatype
+}
+
+type ConfigPropMetatype struct {
+	Name     string
+	Type     int
+	Multiple bool
+	Options  []string
+}
+
+// configPropsListed is a single property rendered by Felix Web Console for configs without metatype (all values as 'key=value' lines)
+const configPropsListed = "propertylist"
+
+// NewConfigMetatype reads metatype from config properties; options (dropdowns) are rendered as objects with 'labels' and 'values'.
+// Configs without real metatype are rendered with properties inferred from current values, so they are treated as not validatable.
+func NewConfigMetatype(item ConfigListItem) ConfigMetatype {
+	result := ConfigMetatype{PID: item.PID, Properties: map[string]ConfigPropMetatype{}}
+	if !item.MetatypeDefined() {
+		return result
+	}
+	for name, def := range item.Properties {
+		prop := ConfigPropMetatype{Name: name, Type: configPropType(def["type"])}
+		if options, ok := def["type"].(map[string]any); ok {
+			prop.Options = cast.ToStringSlice(options["values"])
+		}
+		_, prop.Multiple = def["values"]
+		result.Properties[name] = prop
+	}
+	return result
+}
+
+// MetatypeDefined tells if config properties are described by metatype provided by some bundle
+func (c ConfigListItem) MetatypeDefined() bool {
+	if c.HasMetatype != nil {
+		return *c.HasMetatype
+	}
+	_, listed := c.Properties[configPropsListed]
+	return !listed
+}
+
+// Defined tells if metatype is available (otherwise properties cannot be validated)
+func (m ConfigMetatype) Defined() bool {
+	return len(m.Properties) > 0
+}
+
+// ConfigProblem describes invalid config property
+type ConfigProblem struct {
+	Property string `yaml:"property" json:"property"`
+	Message  string `yaml:"message" json:"message"`
+}
+
+func (p ConfigProblem) String() string {
+	return fmt.Sprintf("property '%s': %s", p.Property, p.Message)
+}
+
+// Validate checks property names, types, options and cardinality against metatype
+func (m ConfigMetatype) Validate(props map[string]any) []ConfigProblem {
+	var result []ConfigProblem
+	names := lo.Keys(props)
+	sort.Strings(names)
+	for _, name := range names {
+		if lo.Contains(configPropsSkipped, name) || strings.HasPrefix(name, ConfigAliasPropPrefix) {
+			continue
+		}
+		prop, ok := m.Properties[name]
+		if !ok {
+			message := "is not defined in metatype"
+			if suggestion := m.suggestProperty(name); suggestion != "" {
+				message += fmt.Sprintf(" (did you mean '%s'?)", suggestion)
+			}
+			result = append(result, ConfigProblem{Property: name, Message: message})
+			continue
+		}
+		value := props[name]
+		values, multiple := value.([]any)
+		if multiple && !prop.Multiple {
+			result = append(result, ConfigProblem{Property: name, Message: fmt.Sprintf("expects single value but got %d values", len(values))})
+			continue
+		}
+		if !multiple {
+			values = []any{value}
+		}
+		for _, item := range values {
+			if message := prop.validateValue(item); message != "" {
+				result = append(result, ConfigProblem{Property: name, Message: message})
+			}
+		}
+	}
+	return result
+}
+
+func (p ConfigPropMetatype) validateValue(value any) string {
+	if text, ok := value.(string); ok && configValuePlaceholder(text) {
+		return ""
+	}
+	switch p.Type {
+	case ConfigPropTypeLong, ConfigPropTypeInteger, ConfigPropTypeShort, ConfigPropTypeByte:
+		number, ok := configIntValue(value)
+		if !ok {
+			return fmt.Sprintf("value '%s' is not %s", cast.ToString(value), configPropTypeName(p.Type))
+		}
+		if min, max := configIntRange(p.Type); number < min || number > max {
+			return fmt.Sprintf("value '%s' is out of %s range", cast.ToString(value), configPropTypeName(p.Type))
+		}
+	case ConfigPropTypeDouble, ConfigPropTypeFloat:
+		if _, err := cast.ToFloat64E(value); err != nil || lo.IsEmpty(fmt.Sprint(value)) {
+			return fmt.Sprintf("value '%s' is not %s", cast.ToString(value), configPropTypeName(p.Type))
+		}
+	case ConfigPropTypeBoolean:
+		if _, err := strconv.ParseBool(fmt.Sprint(value)); err != nil {
+			return fmt.Sprintf("value '%s' is not %s", cast.ToString(value), configPropTypeName(p.Type))
+		}
+	case ConfigPropTypeCharacter:
+		if len([]rune(fmt.Sprint(value))) != 1 {
+			return fmt.Sprintf("value '%s' is not %s", cast.ToString(value), configPropTypeName(p.Type))
+		}
+	}
+	if len(p.Options) > 0 && !lo.Contains(p.Options, fmt.Sprint(value)) {
+		return fmt.Sprintf("value '%s' is not one of options '%s'", cast.ToString(value), strings.Join(p.Options, "', '"))
+	}
+	return ""
+}
+
+func configIntValue(value any) (int64, bool) {
+	switch typed := value.(type) {
+	case float64:
+		return int64(typed), typed == math.Trunc(typed)
+	case string:
+		number, err := strconv.ParseInt(strings.TrimSpace(typed), 10, 64)
+		return number, err == nil
+	case bool:
+		return 0, false
+	}
+	number, err := cast.ToInt64E(value)
+	return number, err == nil
+}
+
+func configIntRange(propType int) (int64, int64) {
+	switch propType {
+	case ConfigPropTypeInteger:
+		return math.MinInt32, math.MaxInt32
+	case ConfigPropTypeShort:
+		return math.MinInt16, math.MaxInt16
+	case ConfigPropTypeByte:
+		return math.MinInt8, math.MaxInt8
+	default:
+		return math.MinInt64, math.MaxInt64
+	}
+}
+
+func configPropTypeName(propType int) string {
+	switch propType {
+	case ConfigPropTypeLong:
+		return "Long"
+	case ConfigPropTypeBoolean:
+		return "Boolean"
+	default:
+		return configPropTypeHints[propType]
+	}
+}
+
+// suggestProperty finds defined property with the most similar name (typos, case differences)
+func (m ConfigMetatype) suggestProperty(name string) string {
+	best, bestDistance := "", 4
+	for defined := range m.Properties {
+		distance := levenshtein(strings.ToLower(name), strings.ToLower(defined))
+		if distance < bestDistance || (distance == bestDistance && defined < best) {
+			best, bestDistance = defined, distance
+		}
+	}
+	return best
+}
+
+func levenshtein(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr := make([]int, len(rb)+1)
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := lo.Ternary(ra[i-1] == rb[j-1], 0, 1)
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev = curr
+	}
+	return prev[len(rb)]
+}
+
+// ConfigValidation is a result of validating config properties against metatype
+type ConfigValidation struct {
+	PID      string          `yaml:"pid" json:"pid"`
+	File     string          `yaml:"file,omitempty" json:"file,omitempty"`
+	Metatype bool            `yaml:"metatype" json:"metatype"`
+	Problems []ConfigProblem `yaml:"problems,omitempty" json:"problems,omitempty"`
+}
+
+func (v ConfigValidation) Valid() bool {
+	return len(v.Problems) == 0
+}
+
+type ConfigValidationReport struct {
+	Configs []ConfigValidation `yaml:"configs" json:"configs"`
+}
+
+func (r ConfigValidationReport) Invalid() []ConfigValidation {
+	return lo.Filter(r.Configs, func(v ConfigValidation, _ int) bool { return !v.Valid() })
+}
+
+func (r ConfigValidationReport) MarshalText() string {
+	bs := bytes.NewBufferString("")
+	bs.WriteString(fmtx.TblRows("configs", true, []string{"pid", "metatype", "problems"}, lo.Map(r.Configs, func(v ConfigValidation, _ int) map[string]any {
+		return map[string]any{
+			"pid":      v.PID,
+			"metatype": v.Metatype,
+			"problems": len(v.Problems),
+		}
+	})))
+	var rows []map[string]any
+	for _, v := range r.Invalid() {
+		for _, p := range v.Problems {
+			rows = append(rows, map[string]any{"pid": v.PID, "file": v.File, "property": p.Property, "message": p.Message})
+		}
+	}
+	if len(rows) > 0 {
+		bs.WriteString("\n")
+		bs.WriteString(fmtx.TblRows("problems", false, []string{"pid", "file", "property", "message"}, rows))
+	}
+	return bs.String()
+}
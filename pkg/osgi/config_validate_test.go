@@ -0,0 +1,85 @@
+package osgi_test
+
+import (
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/wttech/aemc/pkg/osgi"
+)
+
+func validateMetatype() osgi.ConfigMetatype {
+	return osgi.NewConfigMetatype(osgi.ConfigListItem{
+		PID: "com.example.Mailer",
+		Properties: map[string]map[string]any{
+			"host":    {"type": float64(osgi.ConfigPropTypeString), "value": "localhost"},
+			"port":    {"type": float64(osgi.ConfigPropTypeInteger), "value": "25"},
+			"timeout": {"type": float64(osgi.ConfigPropTypeLong), "value": "1000"},
+			"debug":   {"type": float64(osgi.ConfigPropTypeBoolean), "value": "false"},
+			"ratio":   {"type": float64(osgi.ConfigPropTypeFloat), "value": "0.5"},
+			"mode":    {"type": map[string]any{"labels": []any{"Plain", "TLS"}, "values": []any{"plain", "tls"}}, "value": "plain"},
+			"admins":  {"type": float64(osgi.ConfigPropTypeString), "values": []any{"admin"}},
+		},
+	})
+}
+
+func TestConfigMetatypeValidateValid(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	problems := validateMetatype().Validate(map[string]any{
+		"host":        "smtp.example.com",
+		"port":        float64(587),
+		"timeout":     "5000",
+		"debug":       true,
+		"ratio":       0.75,
+		"mode":        "tls",
+		"admins":      []any{"a", "b"},
+		"service.pid": "com.example.Mailer",
+		"alias~aemc":  "aemc",
+		"secretKey":   nil,
+	})
+	a.Len(problems, 1)
+	a.Equal("secretKey", problems[0].Property)
+}
+
+func TestConfigMetatypeValidateProblems(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	problems := validateMetatype().Validate(map[string]any{
+		"hots":    "smtp.example.com",
+		"port":    float64(99999999999),
+		"timeout": "soon",
+		"debug":   "yes",
+		"mode":    "ssl",
+		"host":    []any{"a", "b"},
+		"admins":  []any{"$[secret:ADMIN]"},
+	})
+	messages := lo.Map(problems, func(p osgi.ConfigProblem, _ int) string { return p.String() })
+	a.Equal([]string{
+		"property 'debug': value 'yes' is not Boolean",
+		"property 'host': expects single value but got 2 values",
+		"property 'hots': is not defined in metatype (did you mean 'host'?)",
+		"property 'mode': value 'ssl' is not one of options 'plain', 'tls'",
+		"property 'port': value '99999999999' is out of Integer range",
+		"property 'timeout': value 'soon' is not Long",
+	}, messages)
+}
+
+func TestConfigMetatypeUndefined(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	metatype := osgi.NewConfigMetatype(osgi.ConfigListItem{PID: "com.example.Unknown"})
+	a.False(metatype.Defined())
+	metatype = osgi.NewConfigMetatype(osgi.ConfigListItem{PID: "com.example.Plain", HasMetatype: lo.ToPtr(false), Properties: map[string]map[string]any{
+		"host": {"type": float64(osgi.ConfigPropTypeString), "value": "localhost"},
+	}})
+	a.False(metatype.Defined())
+	metatype = osgi.NewConfigMetatype(osgi.ConfigListItem{PID: "com.example.Plain", Properties: map[string]map[string]any{
+		"propertylist": {"type": "text", "value": "host=localhost"},
+	}})
+	a.False(metatype.Defined())
+	a.True(validateMetatype().Defined())
+}
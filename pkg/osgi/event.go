@@ -1,9 +1,13 @@
 package osgi
 
 import (
+	"sort"
+	"strings"
+	"time"
+
 	"github.com/samber/lo"
+	"github.com/wttech/aemc/pkg/common/fmtx"
 	"github.com/wttech/aemc/pkg/common/stringsx"
-	"strings"
 )
 
 type EventList struct {
@@ -24,10 +28,47 @@ func (el *EventList) StatusUnknown() bool {
 	return len(el.List) == 0
 }
 
+func (el EventList) MarshalText() string {
+	return fmtx.TblRows("list", false, []string{"received", "topic", "bundle", "details"}, lo.Map(el.List, func(e Event, _ int) map[string]any {
+		return map[string]any{
+			"received": e.ReceivedTime().Format(time.DateTime),
+			"topic":    e.Topic,
+			"bundle":   e.Bundle(),
+			"details":  e.Details(),
+		}
+	}))
+}
+
+func (e Event) ReceivedTime() time.Time {
+	return time.UnixMilli(e.Received)
+}
+
 func (e Event) Service() string {
 	return stringsx.Between(e.Info, ", objectClass=", ", bundle=")
 }
 
+// ServiceClasses returns object classes of service which caused the event (empty for non-service events)
+func (e Event) ServiceClasses() []string {
+	service := strings.TrimSpace(e.Service())
+	if service == "" {
+		return []string{}
+	}
+	classes := strings.Split(strings.TrimSuffix(strings.TrimPrefix(service, "["), "]"), ",")
+	return lo.Compact(lo.Map(classes, func(s string, _ int) string { return strings.TrimSpace(s) }))
+}
+
+// Bundle returns symbolic name of bundle which caused the event (if mentioned in event info)
+func (e Event) Bundle() string {
+	if !strings.Contains(e.Info, "bundle=") {
+		return ""
+	}
+	bundle := stringsx.After(e.Info, "bundle=")
+	if i := strings.IndexAny(bundle, ",}] "); i >= 0 {
+		bundle = bundle[:i]
+	}
+	return bundle
+}
+
 func (e Event) Details() string {
 	return detailsUnwrap(e.detailsDetermine())
 }
@@ -51,3 +92,71 @@ func detailsUnwrap(details string) string {
 	}
 	return ""
 }
+
+// EventFilter narrows events by topic, bundle and service patterns (wildcards supported) and by time window
+type EventFilter struct {
+	Topics   []string
+	Bundles  []string
+	Services []string
+	Since    time.Time
+	Until    time.Time
+}
+
+func (f EventFilter) Match(e Event) bool {
+	if len(f.Topics) > 0 && !stringsx.MatchSome(e.Topic, f.Topics) {
+		return false
+	}
+	if len(f.Bundles) > 0 && !stringsx.MatchSome(e.Bundle(), f.Bundles) {
+		return false
+	}
+	if len(f.Services) > 0 && !lo.SomeBy(e.ServiceClasses(), func(c string) bool { return stringsx.MatchSome(c, f.Services) }) {
+		return false
+	}
+	if !f.Since.IsZero() && e.ReceivedTime().Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && e.ReceivedTime().After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// Filter returns events matching filter sorted from the oldest to the newest
+func (el EventList) Filter(filter EventFilter) EventList {
+	events := lo.Filter(el.List, func(e Event, _ int) bool { return filter.Match(e) })
+	return EventList{Status: el.Status, List: sortEvents(events)}
+}
+
+func sortEvents(events []Event) []Event {
+	result := append([]Event{}, events...)
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Received < result[j].Received })
+	return result
+}
+
+// EventTracker remembers already seen events so that only new ones are reported when polling
+type EventTracker struct {
+	seen map[string]bool
+}
+
+func NewEventTracker() *EventTracker {
+	return &EventTracker{seen: map[string]bool{}}
+}
+
+// Track returns events not seen before; events no longer listed are forgotten to keep memory bounded
+func (t *EventTracker) Track(events []Event) []Event {
+	current := map[string]bool{}
+	var result []Event
+	for _, e := range events {
+		key := e.key()
+		current[key] = true
+		if !t.seen[key] {
+			result = append(result, e)
+		}
+	}
+	t.seen = current
+	return result
+}
+
+func (e Event) key() string {
+	return e.ID + "|" + e.Topic + "|" + e.Info
+}
@@ -0,0 +1,55 @@
+package osgi_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/wttech/aemc/pkg/osgi"
+)
+
+func eventIDs(events []osgi.Event) []string {
+	return lo.Map(events, func(e osgi.Event, _ int) string { return e.ID })
+}
+
+func TestEventListFilter(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	now := time.Now()
+	list := osgi.EventList{List: []osgi.Event{
+		{ID: "3", Topic: "org/osgi/framework/ServiceEvent/REGISTERED", Received: now.Add(-time.Minute).UnixMilli(), Info: "service.id=12, objectClass=[com.example.Foo], bundle=com.example.core"},
+		{ID: "1", Topic: "org/osgi/framework/BundleEvent/STARTED", Received: now.Add(-time.Hour).UnixMilli(), Info: "bundle=com.example.core"},
+		{ID: "2", Topic: "org/osgi/framework/ServiceEvent/UNREGISTERING", Received: now.Add(-time.Minute * 2).UnixMilli(), Info: "service.id=13, objectClass=[org.other.Bar], bundle=org.other"},
+	}}
+
+	a.Equal([]string{"1", "2", "3"}, eventIDs(list.Filter(osgi.EventFilter{}).List))
+	a.Equal([]string{"2", "3"}, eventIDs(list.Filter(osgi.EventFilter{Topics: []string{"org/osgi/framework/ServiceEvent/*"}}).List))
+	a.Equal([]string{"1", "3"}, eventIDs(list.Filter(osgi.EventFilter{Bundles: []string{"com.example.*"}}).List))
+	a.Equal([]string{"3"}, eventIDs(list.Filter(osgi.EventFilter{Services: []string{"*.Foo"}}).List))
+	a.Equal([]string{"3"}, eventIDs(list.Filter(osgi.EventFilter{Services: []string{"*example*"}}).List))
+	a.Equal([]string{"2", "3"}, eventIDs(list.Filter(osgi.EventFilter{Since: now.Add(-time.Minute * 10)}).List))
+	a.Equal([]string{"1", "2"}, eventIDs(list.Filter(osgi.EventFilter{Until: now.Add(-time.Second * 90)}).List))
+}
+
+func TestEventBundle(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	a.Equal("com.example.core", osgi.Event{Info: "service.id=12, objectClass=[com.example.Foo], bundle=com.example.core"}.Bundle())
+	a.Equal("com.example.core", osgi.Event{Info: "bundle=com.example.core, state=ACTIVE"}.Bundle())
+	a.Equal([]string{"com.example.Foo", "com.example.Bar"}, osgi.Event{Info: "service.id=12, objectClass=[com.example.Foo, com.example.Bar], bundle=com.example.core"}.ServiceClasses())
+	a.Empty(osgi.Event{Info: "bundle=com.example.core"}.ServiceClasses())
+	a.Equal("", osgi.Event{Info: "framework started"}.Bundle())
+}
+
+func TestEventTracker(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	tracker := osgi.NewEventTracker()
+	a.Equal([]string{"1", "2"}, eventIDs(tracker.Track([]osgi.Event{{ID: "1"}, {ID: "2"}})))
+	a.Equal([]string{"3"}, eventIDs(tracker.Track([]osgi.Event{{ID: "1"}, {ID: "2"}, {ID: "3"}})))
+	a.Empty(tracker.Track([]osgi.Event{{ID: "2"}, {ID: "3"}}))
+}
@@ -3,6 +3,7 @@ package osgi
 import (
 	"fmt"
 	"github.com/essentialkaos/go-jar"
+	"strings"
 )
 
 func ReadBundleManifest(localPath string) (*BundleManifest, error) {
@@ -10,15 +11,88 @@ func ReadBundleManifest(localPath string) (*BundleManifest, error) {
 	if err != nil {
 		return nil, fmt.Errorf("cannot read OSGi bundle manifest from file '%s'", localPath)
 	}
-	return &BundleManifest{SymbolicName: manifest[AttributeSymbolicName], Version: manifest[AttributeVersion]}, nil
+	return &BundleManifest{
+		SymbolicName:  strings.TrimSpace(strings.Split(manifest[AttributeSymbolicName], ";")[0]),
+		Version:       manifest[AttributeVersion],
+		ImportPackage: manifest[AttributeImportPackage],
+		ExportPackage: manifest[AttributeExportPackage],
+		FragmentHost:  manifest[AttributeFragmentHost],
+	}, nil
 }
 
 type BundleManifest struct {
-	SymbolicName string
-	Version      string
+	SymbolicName  string
+	Version       string
+	ImportPackage string
+	ExportPackage string
+	FragmentHost  string
+}
+
+// ImportedPackages parses 'Import-Package' header (version is a range)
+func (m BundleManifest) ImportedPackages() []BundlePackage {
+	return ParseBundlePackageHeader(m.ImportPackage)
+}
+
+// ExportedPackages parses 'Export-Package' header
+func (m BundleManifest) ExportedPackages() []BundlePackage {
+	return ParseBundlePackageHeader(m.ExportPackage)
+}
+
+// ParseBundlePackageHeader parses manifest header listing packages (e.g. 'a;b;version="[1,2)",c;resolution:=optional')
+func ParseBundlePackageHeader(header string) []BundlePackage {
+	var result []BundlePackage
+	for _, clause := range splitManifestHeader(header, ',') {
+		var names []string
+		pkg := BundlePackage{}
+		for _, part := range splitManifestHeader(clause, ';') {
+			part = strings.TrimSpace(part)
+			if key, value, ok := strings.Cut(part, ":="); ok {
+				if strings.TrimSpace(key) == "resolution" && strings.Trim(strings.TrimSpace(value), `"`) == "optional" {
+					pkg.Optional = true
+				}
+			} else if key, value, ok := strings.Cut(part, "="); ok {
+				if strings.TrimSpace(key) == "version" {
+					pkg.Version = strings.Trim(strings.TrimSpace(value), `"`)
+				}
+			} else if part != "" {
+				names = append(names, part)
+			}
+		}
+		for _, name := range names {
+			pkg.Name = name
+			result = append(result, pkg)
+		}
+	}
+	return result
+}
+
+// splitManifestHeader splits header by separator ignoring ones in quoted values
+func splitManifestHeader(header string, separator rune) []string {
+	var result []string
+	var current strings.Builder
+	quoted := false
+	for _, c := range header {
+		switch {
+		case c == '"':
+			quoted = !quoted
+			current.WriteRune(c)
+		case c == separator && !quoted:
+			result = append(result, current.String())
+			current.Reset()
+		default:
+			current.WriteRune(c)
+		}
+	}
+	if strings.TrimSpace(current.String()) != "" {
+		result = append(result, current.String())
+	}
+	return result
 }
 
 const (
-	AttributeSymbolicName = "Bundle-SymbolicName"
-	AttributeVersion      = "Bundle-Version"
+	AttributeSymbolicName  = "Bundle-SymbolicName"
+	AttributeVersion       = "Bundle-Version"
+	AttributeImportPackage = "Import-Package"
+	AttributeExportPackage = "Export-Package"
+	AttributeFragmentHost  = "Fragment-Host"
 )
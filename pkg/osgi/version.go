@@ -0,0 +1,112 @@
+package osgi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is OSGi version (major.minor.micro.qualifier)
+type Version struct {
+	Major     int
+	Minor     int
+	Micro     int
+	Qualifier string
+}
+
+func ParseVersion(value string) (Version, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return Version{}, nil
+	}
+	parts := strings.SplitN(value, ".", 4)
+	numbers := [3]int{}
+	for i := 0; i < len(parts) && i < 3; i++ {
+		number, err := strconv.Atoi(parts[i])
+		if err != nil {
+			return Version{}, fmt.Errorf("invalid OSGi version '%s'", value)
+		}
+		numbers[i] = number
+	}
+	result := Version{Major: numbers[0], Minor: numbers[1], Micro: numbers[2]}
+	if len(parts) == 4 {
+		result.Qualifier = parts[3]
+	}
+	return result, nil
+}
+
+func (v Version) Compare(other Version) int {
+	for _, diff := range []int{v.Major - other.Major, v.Minor - other.Minor, v.Micro - other.Micro} {
+		if diff != 0 {
+			return diff
+		}
+	}
+	return strings.Compare(v.Qualifier, other.Qualifier)
+}
+
+func (v Version) String() string {
+	result := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Micro)
+	if v.Qualifier != "" {
+		result += "." + v.Qualifier
+	}
+	return result
+}
+
+// VersionRange is OSGi version range (e.g. '[1.0,2)'); single version means at least that version
+type VersionRange struct {
+	Floor          Version
+	FloorInclusive bool
+	Ceiling        *Version
+	CeilInclusive  bool
+}
+
+func ParseVersionRange(value string) (VersionRange, error) {
+	value = strings.Trim(strings.TrimSpace(value), `"`)
+	if value == "" {
+		return VersionRange{FloorInclusive: true}, nil
+	}
+	if !strings.HasPrefix(value, "[") && !strings.HasPrefix(value, "(") {
+		floor, err := ParseVersion(value)
+		if err != nil {
+			return VersionRange{}, err
+		}
+		return VersionRange{Floor: floor, FloorInclusive: true}, nil
+	}
+	if len(value) < 2 || !strings.ContainsAny(value[len(value)-1:], "])") {
+		return VersionRange{}, fmt.Errorf("invalid OSGi version range '%s'", value)
+	}
+	floorText, ceilText, ok := strings.Cut(value[1:len(value)-1], ",")
+	if !ok {
+		return VersionRange{}, fmt.Errorf("invalid OSGi version range '%s'", value)
+	}
+	floor, err := ParseVersion(floorText)
+	if err != nil {
+		return VersionRange{}, err
+	}
+	ceil, err := ParseVersion(ceilText)
+	if err != nil {
+		return VersionRange{}, err
+	}
+	return VersionRange{Floor: floor, FloorInclusive: value[0] == '[', Ceiling: &ceil, CeilInclusive: value[len(value)-1] == ']'}, nil
+}
+
+func (r VersionRange) Includes(v Version) bool {
+	floorCmp := v.Compare(r.Floor)
+	if floorCmp < 0 || (floorCmp == 0 && !r.FloorInclusive) {
+		return false
+	}
+	if r.Ceiling != nil {
+		ceilCmp := v.Compare(*r.Ceiling)
+		if ceilCmp > 0 || (ceilCmp == 0 && !r.CeilInclusive) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r VersionRange) String() string {
+	if r.Ceiling == nil {
+		return r.Floor.String()
+	}
+	return fmt.Sprintf("%s%s,%s%s", map[bool]string{true: "[", false: "("}[r.FloorInclusive], r.Floor, r.Ceiling, map[bool]string{true: "]", false: ")"}[r.CeilInclusive])
+}
@@ -0,0 +1,33 @@
+package osgi_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/wttech/aemc/pkg/osgi"
+	"testing"
+)
+
+func TestVersionRangeIncludes(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	includes := func(rangeText string, versionText string) bool {
+		versionRange, err := osgi.ParseVersionRange(rangeText)
+		a.NoError(err)
+		version, err := osgi.ParseVersion(versionText)
+		a.NoError(err)
+		return versionRange.Includes(version)
+	}
+	a.True(includes("[1.0,2)", "1.5.3"))
+	a.True(includes("[1.0,2)", "1.0.0"))
+	a.False(includes("[1.0,2)", "2.0.0"))
+	a.False(includes("(1.0,2]", "1.0.0"))
+	a.True(includes("(1.0,2]", "2.0"))
+	a.True(includes("1.2", "3.0.0.SNAPSHOT"))
+	a.False(includes("1.2", "1.1.9"))
+	a.True(includes("", "0.0.0"))
+
+	_, err := osgi.ParseVersionRange("[1.0")
+	a.Error(err)
+	_, err = osgi.ParseVersion("1.x")
+	a.Error(err)
+}
@@ -6,11 +6,13 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/wttech/aemc/pkg/common/filex"
 	"github.com/wttech/aemc/pkg/common/fmtx"
+	"github.com/wttech/aemc/pkg/common/lox"
 	"github.com/wttech/aemc/pkg/common/osx"
 	"github.com/wttech/aemc/pkg/common/pathx"
 	"github.com/wttech/aemc/pkg/common/stringsx"
 	"github.com/wttech/aemc/pkg/osgi"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -23,6 +25,8 @@ type OSGiBundleManager struct {
 	SnapshotInstallSkipping bool
 	SnapshotIgnored         bool
 	SnapshotPatterns        []string
+	DiagnoseParallelism     int
+	InstallCheck            string
 }
 
 func NewBundleManager(instance *Instance) *OSGiBundleManager {
@@ -37,6 +41,8 @@ func NewBundleManager(instance *Instance) *OSGiBundleManager {
 		SnapshotInstallSkipping: cv.GetBool("instance.osgi.bundle.snapshot_install_skipping"),
 		SnapshotIgnored:         cv.GetBool("instance.osgi.bundle.snapshot_ignored"),
 		SnapshotPatterns:        cv.GetStringSlice("instance.osgi.bundle.snapshot_patterns"),
+		DiagnoseParallelism:     cv.GetInt("instance.osgi.bundle.diagnose.parallelism"),
+		InstallCheck:            cv.GetString("instance.osgi.bundle.install.check"),
 	}
 }
 
@@ -82,6 +88,72 @@ func (bm *OSGiBundleManager) List() (*osgi.BundleList, error) {
 	return &res, nil
 }
 
+func (bm *OSGiBundleManager) Details(id int) (*osgi.BundleDetails, error) {
+	resp, err := bm.instance.http.Request().Get(fmt.Sprintf("%s/%d.json", BundlesPath, id))
+	if err != nil {
+		return nil, fmt.Errorf("%s > cannot request bundle '%d' details: %w", bm.instance.IDColor(), id, err)
+	}
+	if resp.IsError() {
+		return nil, fmt.Errorf("%s > cannot request bundle '%d' details: %s", bm.instance.IDColor(), id, resp.Status())
+	}
+	var res osgi.BundleDetailsResponse
+	if err = fmtx.UnmarshalJSON(resp.RawBody(), &res); err != nil {
+		return nil, fmt.Errorf("%s > cannot parse bundle '%d' details: %w", bm.instance.IDColor(), id, err)
+	}
+	if len(res.Data) == 0 {
+		return nil, fmt.Errorf("%s > cannot find bundle '%d' details", bm.instance.IDColor(), id)
+	}
+	return &res.Data[0], nil
+}
+
+// Diagnose explains why bundles are not active (all unstable ones or particular one when symbolic name is specified)
+func (bm *OSGiBundleManager) Diagnose(symbolicName string) (*osgi.BundleDiagnosisReport, error) {
+	bundles, err := bm.List()
+	if err != nil {
+		return nil, err
+	}
+	var diagnosed []osgi.BundleListItem
+	if symbolicName != "" {
+		diagnosed = lo.Filter(bundles.List, func(b osgi.BundleListItem, _ int) bool { return b.SymbolicName == symbolicName })
+		if len(diagnosed) == 0 {
+			return nil, fmt.Errorf("%s > cannot diagnose bundle '%s' as it does not exist", bm.instance.IDColor(), symbolicName)
+		}
+	} else {
+		diagnosed = bundles.FindUnstable()
+	}
+	details, err := lox.ParallelMapLimit(bm.DiagnoseParallelism, diagnosed, func(b osgi.BundleListItem) (*osgi.BundleDetails, error) { return bm.Details(b.ID) })
+	if err != nil {
+		return nil, err
+	}
+	exports := map[string][]osgi.BundleExporter{}
+	if lo.SomeBy(details, func(d *osgi.BundleDetails) bool { return len(d.MissingImports()) > 0 }) {
+		if exports, err = bm.exports(bundles.List); err != nil {
+			return nil, err
+		}
+	}
+	result := &osgi.BundleDiagnosisReport{}
+	for _, d := range details {
+		result.Bundles = append(result.Bundles, osgi.DiagnoseBundle(*d, exports))
+	}
+	return result, nil
+}
+
+// exports collects packages exported by all bundles (requires reading details of each bundle)
+func (bm *OSGiBundleManager) exports(bundles []osgi.BundleListItem) (map[string][]osgi.BundleExporter, error) {
+	log.Infof("%s > reading packages exported by bundles (%d)", bm.instance.IDColor(), len(bundles))
+	details, err := lox.ParallelMapLimit(bm.DiagnoseParallelism, bundles, func(b osgi.BundleListItem) (*osgi.BundleDetails, error) { return bm.Details(b.ID) })
+	if err != nil {
+		return nil, err
+	}
+	result := map[string][]osgi.BundleExporter{}
+	for _, d := range details {
+		for _, pkg := range d.ExportedPackages() {
+			result[pkg.Name] = append(result[pkg.Name], osgi.BundleExporter{SymbolicName: d.SymbolicName, Version: pkg.Version})
+		}
+	}
+	return result, nil
+}
+
 func (bm *OSGiBundleManager) Start(id int) error {
 	log.Infof("%s > starting bundle '%d'", bm.instance.IDColor(), id)
 	response, err := bm.instance.http.Request().
@@ -186,7 +258,50 @@ type osgiBundleInstallLock struct {
 	Checksum  string    `yaml:"checksum"`
 }
 
+// Check verifies bundle file against packages exported on instance (without installing it)
+func (bm *OSGiBundleManager) Check(localPath string) (*osgi.BundleCheck, error) {
+	manifest, err := osgi.ReadBundleManifest(localPath)
+	if err != nil {
+		return nil, err
+	}
+	bundles, err := bm.List()
+	if err != nil {
+		return nil, err
+	}
+	exports, err := bm.exports(bundles.List)
+	if err != nil {
+		return nil, err
+	}
+	installed, found := lo.Find(bundles.List, func(b osgi.BundleListItem) bool { return b.SymbolicName == manifest.SymbolicName })
+	result := osgi.CheckBundle(localPath, *manifest, lo.Ternary(found, &installed, nil), exports)
+	return &result, nil
+}
+
+func (bm *OSGiBundleManager) checkInstall(localPath string) error {
+	if bm.InstallCheck == "" || bm.InstallCheck == osgi.BundleInstallCheckNone {
+		return nil
+	}
+	check, err := bm.Check(localPath)
+	if err != nil {
+		return err
+	}
+	problems := check.Problems()
+	if len(problems) == 0 {
+		return nil
+	}
+	if bm.InstallCheck == osgi.BundleInstallCheckFail {
+		return fmt.Errorf("%s > cannot install bundle '%s' as check failed: %s", bm.instance.IDColor(), localPath, strings.Join(problems, "; "))
+	}
+	for _, problem := range problems {
+		log.Warnf("%s > bundle '%s' check: %s", bm.instance.IDColor(), localPath, problem)
+	}
+	return nil
+}
+
 func (bm *OSGiBundleManager) Install(localPath string) error {
+	if err := bm.checkInstall(localPath); err != nil {
+		return err
+	}
 	log.Infof("%s > installing bundle '%s'", bm.instance.IDColor(), localPath)
 	response, err := bm.instance.http.RequestFormData(map[string]any{
 		"action":           "install",
@@ -203,6 +318,111 @@ func (bm *OSGiBundleManager) Install(localPath string) error {
 	return nil
 }
 
+// InstallDir installs all bundles from directory without starting them, refreshes packages once, then starts bundles in dependency order
+func (bm *OSGiBundleManager) InstallDir(dir string, force bool) (*osgi.BundleBatchInstall, error) {
+	files, err := osgi.ReadBundleFiles(dir)
+	if err != nil {
+		return nil, fmt.Errorf("%s > cannot read bundles from dir '%s': %w", bm.instance.IDColor(), dir, err)
+	}
+	files = osgi.OrderBundleFiles(files)
+	if err := bm.checkInstallBatch(files); err != nil {
+		return nil, err
+	}
+	result := &osgi.BundleBatchInstall{Dir: dir}
+	batch := *bm
+	batch.InstallStart = false
+	batch.InstallRefreshPackages = false
+	batch.InstallCheck = osgi.BundleInstallCheckNone
+	for _, file := range files {
+		item := osgi.BundleBatchItem{File: file.Path, SymbolicName: file.Manifest.SymbolicName, Version: file.Manifest.Version}
+		if force {
+			err = batch.Install(file.Path)
+			item.Installed = err == nil
+		} else {
+			item.Installed, err = batch.InstallWithChanged(file.Path)
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.Bundles = append(result.Bundles, item)
+	}
+	if lo.SomeBy(result.Bundles, func(i osgi.BundleBatchItem) bool { return i.Installed }) {
+		if err := bm.RefreshPackages(); err != nil {
+			return nil, err
+		}
+		result.Refreshed = true
+	}
+	if !bm.InstallStart {
+		return result, nil
+	}
+	bundles, err := bm.List()
+	if err != nil {
+		return nil, err
+	}
+	for i, file := range files {
+		if file.Fragment() {
+			continue
+		}
+		bundle, found := lo.Find(bundles.List, func(b osgi.BundleListItem) bool { return b.SymbolicName == file.Manifest.SymbolicName })
+		if !found || bundle.StateRaw == int(osgi.BundleStateRawActive) {
+			continue
+		}
+		if err := bm.Start(bundle.ID); err != nil {
+			return nil, err
+		}
+		result.Bundles[i].Started = true
+	}
+	return result, nil
+}
+
+// checkInstallBatch checks bundles against packages exported both on instance and by other bundles being installed
+func (bm *OSGiBundleManager) checkInstallBatch(files []osgi.BundleFile) error {
+	if bm.InstallCheck == "" || bm.InstallCheck == osgi.BundleInstallCheckNone || len(files) == 0 {
+		return nil
+	}
+	bundles, err := bm.List()
+	if err != nil {
+		return err
+	}
+	exports, err := bm.exports(bundles.List)
+	if err != nil {
+		return err
+	}
+	for pkg, exporters := range osgi.BundleFilesExports(files) {
+		exports[pkg] = append(exports[pkg], exporters...)
+	}
+	var problems []string
+	for _, file := range files {
+		installed, found := lo.Find(bundles.List, func(b osgi.BundleListItem) bool { return b.SymbolicName == file.Manifest.SymbolicName })
+		check := osgi.CheckBundle(file.Path, file.Manifest, lo.Ternary(found, &installed, nil), exports)
+		for _, problem := range check.Problems() {
+			problems = append(problems, fmt.Sprintf("bundle '%s': %s", file.Path, problem))
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	if bm.InstallCheck == osgi.BundleInstallCheckFail {
+		return fmt.Errorf("%s > cannot install bundles as check failed: %s", bm.instance.IDColor(), strings.Join(problems, "; "))
+	}
+	for _, problem := range problems {
+		log.Warnf("%s > %s", bm.instance.IDColor(), problem)
+	}
+	return nil
+}
+
+func (bm *OSGiBundleManager) RefreshPackages() error {
+	log.Infof("%s > refreshing packages", bm.instance.IDColor())
+	response, err := bm.instance.http.RequestFormData(map[string]any{"action": "refreshPackages"}).Post(BundlesPath)
+	if err != nil {
+		return fmt.Errorf("%s > cannot refresh packages: %w", bm.instance.IDColor(), err)
+	} else if response.IsError() {
+		return fmt.Errorf("%s > cannot refresh packages: %s", bm.instance.IDColor(), response.Status())
+	}
+	log.Infof("%s > refreshed packages", bm.instance.IDColor())
+	return nil
+}
+
 func (bm *OSGiBundleManager) Uninstall(id int) error {
 	log.Infof("%s > uninstalling bundle '%d'", bm.instance.IDColor(), id)
 	response, err := bm.instance.http.RequestFormData(map[string]any{"action": "uninstall"}).Post(fmt.Sprintf("%s/%d", BundlesPath, id))
@@ -5,15 +5,25 @@ import (
 	"github.com/samber/lo"
 	log "github.com/sirupsen/logrus"
 	"github.com/wttech/aemc/pkg/common/fmtx"
+	"github.com/wttech/aemc/pkg/common/lox"
+	"github.com/wttech/aemc/pkg/common/stringsx"
 	"github.com/wttech/aemc/pkg/osgi"
 )
 
 type OSGiComponentManager struct {
 	instance *Instance
+
+	GraphParallelism int
 }
 
 func NewComponentManager(instance *Instance) *OSGiComponentManager {
-	return &OSGiComponentManager{instance: instance}
+	cv := instance.manager.aem.config.Values()
+
+	return &OSGiComponentManager{
+		instance: instance,
+
+		GraphParallelism: cv.GetInt("instance.osgi.component.graph.parallelism"),
+	}
 }
 
 func (cm *OSGiComponentManager) ByPID(pid string) OSGiComponent {
@@ -47,6 +57,48 @@ func (cm *OSGiComponentManager) List() (*osgi.ComponentList, error) {
 	return &res, nil
 }
 
+func (cm *OSGiComponentManager) Details(id string) (*osgi.ComponentDetails, error) {
+	resp, err := cm.instance.http.Request().Get(fmt.Sprintf("%s/%s.json", ComponentsPath, id))
+	if err != nil {
+		return nil, fmt.Errorf("%s > cannot request component '%s' details: %w", cm.instance.IDColor(), id, err)
+	}
+	if resp.IsError() {
+		return nil, fmt.Errorf("%s > cannot request component '%s' details: %s", cm.instance.IDColor(), id, resp.Status())
+	}
+	var res osgi.ComponentDetailsResponse
+	if err = fmtx.UnmarshalJSON(resp.RawBody(), &res); err != nil {
+		return nil, fmt.Errorf("%s > cannot parse component '%s' details: %w", cm.instance.IDColor(), id, err)
+	}
+	if len(res.Data) == 0 {
+		return nil, fmt.Errorf("%s > cannot find component '%s' details", cm.instance.IDColor(), id)
+	}
+	return &res.Data[0], nil
+}
+
+// Graph reads references of components matching patterns (all if none specified); unsatisfied ones could be graphed only
+func (cm *OSGiComponentManager) Graph(patterns []string, unsatisfiedOnly bool) (*osgi.ComponentGraph, error) {
+	components, err := cm.List()
+	if err != nil {
+		return nil, err
+	}
+	graphed := lo.Filter(components.List, func(c osgi.ComponentListItem, _ int) bool {
+		return c.Enabled() && (len(patterns) == 0 || stringsx.MatchSome(c.UID(), patterns)) && (!unsatisfiedOnly || c.Unsatisfied())
+	})
+	log.Infof("%s > reading details of components (%d)", cm.instance.IDColor(), len(graphed))
+	details, err := lox.ParallelMapLimit(cm.GraphParallelism, graphed, func(c osgi.ComponentListItem) (osgi.ComponentDetails, error) {
+		result, err := cm.Details(c.ID)
+		if err != nil {
+			return osgi.ComponentDetails{}, err
+		}
+		return *result, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := osgi.NewComponentGraph(details)
+	return &result, nil
+}
+
 func (cm *OSGiComponentManager) Enable(pid string) error {
 	log.Infof("%s > enabling component '%s'", cm.instance.IDColor(), pid)
 	response, err := cm.instance.http.Request().
@@ -92,6 +92,13 @@ func (c OSGiConfig) State() (*OSGiConfigState, error) {
 }
 
 func (c OSGiConfig) Save(props map[string]any) error {
+	if err := c.manager.validateSave(c.SymbolicPID(), c.fpid, props); err != nil {
+		return err
+	}
+	props, err := c.manager.resolveSecrets(c.SymbolicPID(), props)
+	if err != nil {
+		return err
+	}
 	state, err := c.State()
 	if err != nil {
 		return err
@@ -107,6 +114,13 @@ func (c OSGiConfig) Save(props map[string]any) error {
 }
 
 func (c OSGiConfig) SaveWithChanged(props map[string]any) (bool, error) {
+	if err := c.manager.validateSave(c.SymbolicPID(), c.fpid, props); err != nil {
+		return false, err
+	}
+	props, err := c.manager.resolveSecrets(c.SymbolicPID(), props)
+	if err != nil {
+		return false, err
+	}
 	state, err := c.State()
 	if err != nil {
 		return false, err
@@ -4,17 +4,43 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
+	"github.com/samber/lo"
 	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cast"
 	"github.com/wttech/aemc/pkg/common/fmtx"
+	"github.com/wttech/aemc/pkg/common/pathx"
+	"github.com/wttech/aemc/pkg/common/stringsx"
 	"github.com/wttech/aemc/pkg/osgi"
+	"github.com/wttech/aemc/pkg/secret"
 	"golang.org/x/exp/maps"
 )
 
 type OSGiConfigManager struct {
 	instance *Instance
+
+	SecretResolve  bool
+	SecretResolver *secret.Resolver
+	SecretProtect  bool
+	SaveValidate   string
+}
+
+func NewConfigManager(instance *Instance) *OSGiConfigManager {
+	cv := instance.manager.aem.config.Values()
+
+	return &OSGiConfigManager{
+		instance: instance,
+
+		SecretResolve:  cv.GetBool("secret.enabled"),
+		SecretResolver: secret.NewResolverByConfig(instance.manager.aem.config),
+		SecretProtect:  cv.GetBool("instance.osgi.config.secret_protect"),
+		SaveValidate:   cv.GetString("instance.osgi.config.save.validate"),
+	}
 }
 
 func (cm *OSGiConfigManager) ByPID(pid string) OSGiConfig {
@@ -158,6 +184,46 @@ func (cm *OSGiConfigManager) Save(pid string, fpid string, props map[string]any)
 	return nil
 }
 
+// resolveSecrets replaces '$[secret:NAME]' placeholders in props with values from secret providers (optionally protected by Crypto);
+// when resolving is disabled, placeholders are saved as-is (e.g. to be interpolated on AEMaaCS by Cloud Manager)
+func (cm *OSGiConfigManager) resolveSecrets(pid string, props map[string]any) (map[string]any, error) {
+	if !cm.SecretResolve {
+		return props, nil
+	}
+	result, secretProps, err := cm.SecretResolver.ResolveProps(props)
+	if err != nil {
+		return nil, fmt.Errorf("%s > cannot resolve secrets of config '%s': %w", cm.instance.IDColor(), pid, err)
+	}
+	if len(secretProps) == 0 {
+		return result, nil
+	}
+	log.Infof("%s > resolved secrets of config '%s' in properties '%s'", cm.instance.IDColor(), pid, strings.Join(secretProps, ","))
+	if !cm.SecretProtect {
+		return result, nil
+	}
+	for _, name := range secretProps {
+		switch value := result[name].(type) {
+		case string:
+			protected, err := cm.instance.Crypto().Protect(value)
+			if err != nil {
+				return nil, err
+			}
+			result[name] = protected
+		case []any:
+			for i, item := range value {
+				if itemText, ok := item.(string); ok && secret.Has(cast.ToString(props[name].([]any)[i])) {
+					protected, err := cm.instance.Crypto().Protect(itemText)
+					if err != nil {
+						return nil, err
+					}
+					value[i] = protected
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
 func saveConfigProps(fpid string, props map[string]any) map[string]any {
 	result := map[string]any{}
 	maps.Copy(result, props)
@@ -189,6 +255,156 @@ func (cm *OSGiConfigManager) Delete(pid string) error {
 	return nil
 }
 
+// Export writes configs matching PID patterns to '.cfg.json' files in directory
+func (cm *OSGiConfigManager) Export(pidPatterns []string, dir string, defaultsSkipped bool) (*osgi.ConfigExportList, error) {
+	list, err := cm.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	if err := pathx.Ensure(dir); err != nil {
+		return nil, fmt.Errorf("%s > cannot export configs to dir '%s': %w", cm.instance.IDColor(), dir, err)
+	}
+	result := &osgi.ConfigExportList{Dir: dir}
+	for _, config := range list.List {
+		if !stringsx.MatchSome(config.PID, pidPatterns) && (config.FPID == "" || !stringsx.MatchSome(config.FPID, pidPatterns)) {
+			continue
+		}
+		export, err := cm.exportFile(config, dir, defaultsSkipped)
+		if err != nil {
+			return nil, err
+		}
+		result.Configs = append(result.Configs, *export)
+	}
+	return result, nil
+}
+
+func (cm *OSGiConfigManager) exportFile(config osgi.ConfigListItem, dir string, defaultsSkipped bool) (*osgi.ConfigExport, error) {
+	fpid, alias := config.FPID, config.Alias()
+	if cm.IsFactoryPID(config.PID) {
+		fpid, alias = cm.SplitFactoryPID(config.PID)
+	} else if fpid != "" && alias == "" {
+		alias = strings.TrimPrefix(config.PID, fpid+".")
+	}
+	props := config.ExportProperties(defaultsSkipped)
+	data, err := osgi.MarshalConfigJSON(props)
+	if err != nil {
+		return nil, fmt.Errorf("%s > cannot export config '%s': %w", cm.instance.IDColor(), config.PID, err)
+	}
+	file := filepath.Join(dir, osgi.ConfigFileName(config.PID, fpid, alias))
+	result := &osgi.ConfigExport{PID: config.PID, File: file, Properties: len(props)}
+	if current, err := os.ReadFile(file); err == nil && bytes.Equal(current, data) {
+		return result, nil
+	}
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		return nil, fmt.Errorf("%s > cannot export config '%s' to file '%s': %w", cm.instance.IDColor(), config.PID, file, err)
+	}
+	log.Infof("%s > exported config '%s' to file '%s'", cm.instance.IDColor(), config.PID, file)
+	result.Changed = true
+	return result, nil
+}
+
+// Drift compares live configs with '.cfg.json' files from directory applicable for instance run modes
+func (cm *OSGiConfigManager) Drift(dir string) (*osgi.ConfigDriftReport, error) {
+	sources, err := osgi.ReadConfigSources(dir)
+	if err != nil {
+		return nil, fmt.Errorf("%s > cannot check config drift: %w", cm.instance.IDColor(), err)
+	}
+	runModes, err := cm.instance.Status().RunModes()
+	if err != nil {
+		return nil, fmt.Errorf("%s > cannot check config drift: %w", cm.instance.IDColor(), err)
+	}
+	result := &osgi.ConfigDriftReport{RunModes: runModes}
+	for _, source := range osgi.ResolveConfigSources(sources, runModes) {
+		state, err := cm.ByPID(source.PID).State()
+		if err != nil {
+			return nil, err
+		}
+		drift := osgi.ConfigDrift{PID: source.PID, File: source.File, Exists: state.Exists}
+		if state.Exists {
+			drift.Missing, drift.Extra, drift.Different = osgi.CompareConfigProps(source.Properties, state.Properties, state.data.PropertyNamesSet())
+		}
+		if drift.Drifted() {
+			log.Warnf("%s > config '%s' drifted from file '%s'", cm.instance.IDColor(), source.PID, source.File)
+		}
+		result.Configs = append(result.Configs, drift)
+	}
+	return result, nil
+}
+
+// Metatype reads definition of config properties (for factory configs definition is taken from factory PID)
+func (cm *OSGiConfigManager) Metatype(pid string, fpid string) (*osgi.ConfigMetatype, error) {
+	item, err := cm.Find(lo.Ternary(fpid != "", fpid, pid))
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return &osgi.ConfigMetatype{PID: pid}, nil
+	}
+	result := osgi.NewConfigMetatype(*item)
+	return &result, nil
+}
+
+// Validate checks props against metatype of config
+func (cm *OSGiConfigManager) Validate(pid string, fpid string, props map[string]any) (*osgi.ConfigValidation, error) {
+	metatype, err := cm.Metatype(pid, fpid)
+	if err != nil {
+		return nil, fmt.Errorf("%s > cannot validate config '%s': %w", cm.instance.IDColor(), pid, err)
+	}
+	result := &osgi.ConfigValidation{PID: pid, Metatype: metatype.Defined()}
+	if metatype.Defined() {
+		result.Problems = metatype.Validate(props)
+	}
+	return result, nil
+}
+
+// ValidateDir checks all '.cfg.json' files from directory against metatypes available on instance
+func (cm *OSGiConfigManager) ValidateDir(dir string) (*osgi.ConfigValidationReport, error) {
+	sources, err := osgi.ReadConfigSources(dir)
+	if err != nil {
+		return nil, fmt.Errorf("%s > cannot validate configs: %w", cm.instance.IDColor(), err)
+	}
+	sort.Slice(sources, func(i, j int) bool { return sources[i].File < sources[j].File })
+	result := &osgi.ConfigValidationReport{}
+	for _, source := range sources {
+		fpid := ""
+		if cm.IsFactoryPID(source.PID) {
+			fpid, _ = cm.SplitFactoryPID(source.PID)
+		}
+		validation, err := cm.Validate(source.PID, fpid, source.Properties)
+		if err != nil {
+			return nil, err
+		}
+		validation.File = source.File
+		if !validation.Metatype {
+			log.Warnf("%s > config '%s' from file '%s' cannot be validated as metatype is not available", cm.instance.IDColor(), source.PID, source.File)
+		}
+		result.Configs = append(result.Configs, *validation)
+	}
+	return result, nil
+}
+
+// validateSave checks props before saving them; depending on mode problems are only logged or fail saving
+func (cm *OSGiConfigManager) validateSave(pid string, fpid string, props map[string]any) error {
+	if cm.SaveValidate == "" || cm.SaveValidate == osgi.ConfigValidateNone {
+		return nil
+	}
+	validation, err := cm.Validate(pid, fpid, props)
+	if err != nil {
+		return err
+	}
+	if validation.Valid() {
+		return nil
+	}
+	problems := lo.Map(validation.Problems, func(p osgi.ConfigProblem, _ int) string { return p.String() })
+	if cm.SaveValidate == osgi.ConfigValidateFail {
+		return fmt.Errorf("%s > cannot save config '%s' as validation failed: %s", cm.instance.IDColor(), pid, strings.Join(problems, "; "))
+	}
+	for _, problem := range problems {
+		log.Warnf("%s > config '%s' %s", cm.instance.IDColor(), pid, problem)
+	}
+	return nil
+}
+
 const (
 	ConfigMgrPath = "/system/console/configMgr"
 )
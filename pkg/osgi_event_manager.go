@@ -1,8 +1,14 @@
 package pkg
 
 import (
+	"context"
 	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+	log "github.com/sirupsen/logrus"
 	"github.com/wttech/aemc/pkg/common/fmtx"
+	inst "github.com/wttech/aemc/pkg/instance"
 	"github.com/wttech/aemc/pkg/osgi"
 )
 
@@ -13,6 +19,20 @@ const (
 
 type OSGiEventManager struct {
 	instance *Instance
+
+	WatchInterval  time.Duration
+	DetailsIgnored []string
+}
+
+func NewEventManager(instance *Instance) *OSGiEventManager {
+	cv := instance.manager.aem.config.Values()
+
+	return &OSGiEventManager{
+		instance: instance,
+
+		WatchInterval:  cv.GetDuration("instance.osgi.event.watch.interval"),
+		DetailsIgnored: cv.GetStringSlice("instance.check.event_stable.details_ignored"),
+	}
 }
 
 func (em *OSGiEventManager) List() (*osgi.EventList, error) {
@@ -28,3 +48,54 @@ func (em *OSGiEventManager) List() (*osgi.EventList, error) {
 	}
 	return res, nil
 }
+
+// Find lists events matching filter; optionally skips events with details ignored by event stability check
+func (em *OSGiEventManager) Find(filter osgi.EventFilter, detailsIgnored bool) (*osgi.EventList, error) {
+	list, err := em.List()
+	if err != nil {
+		return nil, err
+	}
+	result := list.Filter(filter)
+	if detailsIgnored {
+		result.List = lo.Reject(result.List, func(e osgi.Event, _ int) bool {
+			return inst.MatchSome(em.instance.ID(), e.Details(), em.DetailsIgnored)
+		})
+	}
+	return &result, nil
+}
+
+// Watch polls events periodically and passes only new ones matching filter to handler until context is done
+func (em *OSGiEventManager) Watch(ctx context.Context, filter osgi.EventFilter, detailsIgnored bool, handler func(osgi.Event)) error {
+	if em.WatchInterval <= 0 {
+		return fmt.Errorf("%s > cannot watch events as interval '%s' is not positive", em.instance.IDColor(), em.WatchInterval)
+	}
+	if !filter.Until.IsZero() {
+		return fmt.Errorf("%s > cannot watch events received until given time as only new events are watched", em.instance.IDColor())
+	}
+	tracker := osgi.NewEventTracker()
+	events, err := em.Find(filter, detailsIgnored)
+	if err != nil {
+		return err
+	}
+	initial := tracker.Track(events.List)
+	if !filter.Since.IsZero() {
+		lo.ForEach(initial, func(e osgi.Event, _ int) { handler(e) })
+	}
+	log.Infof("%s > watching events (polling every %s)", em.instance.IDColor(), em.WatchInterval)
+	ticker := time.NewTicker(em.WatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Infof("%s > stopped watching events", em.instance.IDColor())
+			return nil
+		case <-ticker.C:
+			events, err := em.Find(filter, detailsIgnored)
+			if err != nil {
+				log.Warn(err)
+				continue
+			}
+			lo.ForEach(tracker.Track(events.List), func(e osgi.Event, _ int) { handler(e) })
+		}
+	}
+}
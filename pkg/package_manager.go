@@ -4,12 +4,17 @@ import (
 	"bufio"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/cheggaaa/pb/v3"
+	"github.com/dustin/go-humanize"
+	"github.com/fatih/color"
 	"github.com/samber/lo"
 	log "github.com/sirupsen/logrus"
 	"github.com/wttech/aemc/pkg/common/filex"
 	"github.com/wttech/aemc/pkg/common/fmtx"
 	"github.com/wttech/aemc/pkg/common/httpx"
+	"github.com/wttech/aemc/pkg/common/iox"
 	"github.com/wttech/aemc/pkg/common/osx"
 	"github.com/wttech/aemc/pkg/common/pathx"
 	"github.com/wttech/aemc/pkg/common/stringsx"
@@ -20,11 +25,14 @@ import (
 	"io"
 	"io/fs"
 	"mime/multipart"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+	"syscall"
 	"time"
 )
 
@@ -48,6 +56,13 @@ type PackageManager struct {
 	SnapshotIgnored           bool
 	SnapshotPatterns          []string
 	ToggledWorkflows          []string
+	UploadVerify              string
+	TransferRetryAttempts     int
+	TransferRetryDelay        time.Duration
+	TransferBandwidthLimit    uint64
+	PurgeKeepLast             int
+	PurgeOlderThanDays        int
+	PurgeProtectedPatterns    []string
 }
 
 func NewPackageManager(res *Instance) *PackageManager {
@@ -69,9 +84,28 @@ func NewPackageManager(res *Instance) *PackageManager {
 		SnapshotIgnored:           cv.GetBool("instance.package.snapshot_ignored"),
 		SnapshotPatterns:          cv.GetStringSlice("instance.package.snapshot_patterns"),
 		ToggledWorkflows:          cv.GetStringSlice("instance.package.toggled_workflows"),
+		UploadVerify:              cv.GetString("instance.package.upload_verify"),
+		TransferRetryAttempts:     cv.GetInt("instance.package.transfer.retry.attempts"),
+		TransferRetryDelay:        cv.GetDuration("instance.package.transfer.retry.delay"),
+		TransferBandwidthLimit:    determineBandwidthLimit(cv.GetString("instance.package.transfer.bandwidth_limit")),
+		PurgeKeepLast:             cv.GetInt("instance.package.purge.keep_last"),
+		PurgeOlderThanDays:        cv.GetInt("instance.package.purge.older_than_days"),
+		PurgeProtectedPatterns:    cv.GetStringSlice("instance.package.purge.protected_patterns"),
 	}
 }
 
+func determineBandwidthLimit(value string) uint64 {
+	if value == "" {
+		return 0
+	}
+	limit, err := humanize.ParseBytes(value)
+	if err != nil {
+		log.Warnf("invalid package transfer bandwidth limit '%s' (expected e.g '10 MB'), using no limit: %s", value, err)
+		return 0
+	}
+	return limit
+}
+
 func (pm *PackageManager) ByPID(pid string) (*Package, error) {
 	pidConfig, err := pkg.ParsePID(pid)
 	if err != nil {
@@ -169,6 +203,7 @@ func copyPackageAllFiles(targetTmpDir string, opts PackageCreateOpts) error {
 		"FilterRoots":        opts.FilterRoots,
 		"FilterRootExcludes": opts.FilterRootExcludes,
 		"FilterMode":         opts.FilterMode,
+		"Filters":            opts.Filters,
 	}
 	if err = pathx.DeleteIfExists(targetTmpDir); err != nil {
 		return fmt.Errorf("cannot delete temporary dir '%s': %w", targetTmpDir, err)
@@ -199,16 +234,35 @@ func copyPackageAllFiles(targetTmpDir string, opts PackageCreateOpts) error {
 }
 
 type PackageCreateOpts struct {
-	PID                string
-	FilterRoots        []string
-	FilterRootExcludes []string
-	FilterFile         string
-	FilterMode         string
-	ContentPath        string
+	PID                 string
+	FilterRoots         []string
+	FilterRootExcludes  []string
+	FilterFile          string
+	FilterMode          string
+	FilterQuery         string
+	FilterQueryLanguage string
+	FilterPathsFile     string
+	FilterDescendants   bool
+	Filters             []PackageFilter
+	ContentPath         string
 }
 
 func (pm *PackageManager) Create(opts PackageCreateOpts) (string, error) {
+	if opts.FilterMode != "" && !lo.Contains(pkg.FilterModes(), opts.FilterMode) {
+		return "", fmt.Errorf("%s > cannot create package '%s' as filter mode '%s' is not one of '%s'", pm.instance.IDColor(), opts.PID, opts.FilterMode, strings.Join(pkg.FilterModes(), "|"))
+	}
 	log.Infof("%s > creating package '%s'", pm.instance.IDColor(), opts.PID)
+	if opts.FilterQuery != "" || opts.FilterPathsFile != "" {
+		paths, err := pm.determineFilterPaths(opts)
+		if err != nil {
+			return "", fmt.Errorf("%s > cannot create package '%s': %w", pm.instance.IDColor(), opts.PID, err)
+		}
+		if opts.FilterDescendants {
+			opts.Filters = NewPackageFilters(paths)
+		} else {
+			opts.Filters = NewPackageFiltersExact(paths)
+		}
+	}
 	tmpDir := pathx.RandomDir(pm.tmpDir(), "pkg_create")
 	tmpFile := pathx.RandomFileName(pm.tmpDir(), "pkg_create", ".zip")
 	defer func() {
@@ -241,6 +295,49 @@ func (pm *PackageManager) Create(opts PackageCreateOpts) (string, error) {
 	return status.Path, nil
 }
 
+// determineFilterPaths collects paths from query results and/or text file
+func (pm *PackageManager) determineFilterPaths(opts PackageCreateOpts) ([]string, error) {
+	var paths []string
+	if opts.FilterQuery != "" {
+		queried, err := pm.instance.repo.QueryPaths(opts.FilterQueryLanguage, opts.FilterQuery)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, queried...)
+	}
+	if opts.FilterPathsFile != "" {
+		text, err := filex.ReadString(opts.FilterPathsFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read filter paths file '%s': %w", opts.FilterPathsFile, err)
+		}
+		paths = append(paths, ParseFilterPaths(text)...)
+	}
+	paths = NormalizeFilterPaths(paths)
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no filter paths found")
+	}
+	return paths, nil
+}
+
+// ParseFilterPaths reads node paths from text (one path per line, '#' starts a comment)
+func ParseFilterPaths(text string) []string {
+	var result []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			result = append(result, line)
+		}
+	}
+	return result
+}
+
+// NormalizeFilterPaths removes duplicates and sorts paths to keep filters deterministic
+func NormalizeFilterPaths(paths []string) []string {
+	result := lo.Uniq(paths)
+	sort.Strings(result)
+	return result
+}
+
 func DetermineFilterRoot(path string) string {
 	_, filterRoot, _ := strings.Cut(path, content.JCRRoot)
 	filterRoot = pathx.Normalize(filterRoot)
@@ -272,6 +369,10 @@ func copyContentFiles(path string, tmpDir string) error {
 }
 
 func (pm *PackageManager) Copy(remotePath string, destInstance *Instance) error {
+	return pm.copy(remotePath, destInstance, true)
+}
+
+func (pm *PackageManager) copy(remotePath string, destInstance *Instance, install bool) error {
 	localPath := pathx.RandomFileName(pm.tmpDir(), "pkg_copy", ".zip")
 	defer func() { _ = pathx.DeleteIfExists(localPath) }()
 	if err := pm.Download(remotePath, localPath); err != nil {
@@ -281,12 +382,213 @@ func (pm *PackageManager) Copy(remotePath string, destInstance *Instance) error
 	if err != nil {
 		return err
 	}
-	if err := destInstance.PackageManager().Install(destRemotePath); err != nil {
-		return err
+	if install {
+		if err := destInstance.PackageManager().Install(destRemotePath); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
+type PackageSyncOpts struct {
+	Patterns []string
+	Install  bool
+}
+
+type PackageSyncItem struct {
+	PID    string `yaml:"pid" json:"pid"`
+	Path   string `yaml:"path" json:"path"`
+	Reason string `yaml:"reason" json:"reason"`
+}
+
+func (i PackageSyncItem) MarshalText() string {
+	return fmt.Sprintf("%s (%s)", i.PID, i.Reason)
+}
+
+const (
+	PackageSyncReasonMissing = "missing"
+	PackageSyncReasonNewer   = "newer"
+)
+
+// SyncPlan determines packages of the source instance which are missing or outdated on the current one
+func (pm *PackageManager) SyncPlan(sourceInstance *Instance, opts PackageSyncOpts) ([]PackageSyncItem, error) {
+	sourceList, err := sourceInstance.PackageManager().List()
+	if err != nil {
+		return nil, err
+	}
+	targetList, err := pm.List()
+	if err != nil {
+		return nil, err
+	}
+	return PlanPackageSync(sourceList.List, targetList.List, opts.Patterns), nil
+}
+
+// PlanPackageSync selects source packages matching patterns which are missing on target or were touched after the target copy;
+// missing packages are skipped when target already has a higher version of the same package
+func PlanPackageSync(sourceItems []pkg.ListItem, targetItems []pkg.ListItem, patterns []string) []PackageSyncItem {
+	targetItemsByPID := lo.KeyBy(targetItems, func(item pkg.ListItem) string { return item.PID })
+	result := []PackageSyncItem{}
+	for _, sourceItem := range sourceItems {
+		if len(patterns) > 0 && !stringsx.MatchSome(sourceItem.PID, patterns) {
+			continue
+		}
+		targetItem, exists := targetItemsByPID[sourceItem.PID]
+		if !exists {
+			if syncOutdated(sourceItem, targetItems) {
+				log.Debugf("skipping syncing package '%s' as newer version already exists", sourceItem.PID)
+				continue
+			}
+			result = append(result, PackageSyncItem{PID: sourceItem.PID, Path: sourceItem.Path, Reason: PackageSyncReasonMissing})
+		} else if sourceItem.LastTouched() > targetItem.LastTouched() {
+			result = append(result, PackageSyncItem{PID: sourceItem.PID, Path: sourceItem.Path, Reason: PackageSyncReasonNewer})
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].PID < result[j].PID })
+	return result
+}
+
+func syncOutdated(sourceItem pkg.ListItem, targetItems []pkg.ListItem) bool {
+	return lo.SomeBy(targetItems, func(targetItem pkg.ListItem) bool {
+		return targetItem.Group == sourceItem.Group && targetItem.Name == sourceItem.Name && pkg.CompareVersion(targetItem.Version, sourceItem.Version) > 0
+	})
+}
+
+// Sync copies packages from the source instance which are missing or outdated on the current one
+func (pm *PackageManager) Sync(sourceInstance *Instance, opts PackageSyncOpts) ([]PackageSyncItem, error) {
+	items, err := pm.SyncPlan(sourceInstance, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		log.Infof("%s > packages already in sync with instance '%s'", pm.instance.IDColor(), sourceInstance.ID())
+		return items, nil
+	}
+	for i, item := range items {
+		log.Infof("%s > syncing package '%s' from instance '%s' (%s)", pm.instance.IDColor(), item.PID, sourceInstance.ID(), stringsx.PercentExplained(i+1, len(items), 0))
+		if err := sourceInstance.PackageManager().copy(item.Path, pm.instance, opts.Install); err != nil {
+			return nil, fmt.Errorf("%s > cannot sync package '%s' from instance '%s': %w", pm.instance.IDColor(), item.PID, sourceInstance.ID(), err)
+		}
+	}
+	log.Infof("%s > synced packages (%d) from instance '%s'", pm.instance.IDColor(), len(items), sourceInstance.ID())
+	return items, nil
+}
+
+type PackagePurgeOpts struct {
+	Patterns          []string
+	ProtectedPatterns []string
+	KeepLast          int
+	OlderThanDays     int
+}
+
+type PackagePurgeItem struct {
+	PID       string `yaml:"pid" json:"pid"`
+	Path      string `yaml:"path" json:"path"`
+	Reason    string `yaml:"reason" json:"reason"`
+	Uninstall bool   `yaml:"uninstall" json:"uninstall"`
+}
+
+func (i PackagePurgeItem) MarshalText() string {
+	if i.Uninstall {
+		return fmt.Sprintf("%s (%s, uninstall)", i.PID, i.Reason)
+	}
+	return fmt.Sprintf("%s (%s)", i.PID, i.Reason)
+}
+
+const (
+	PackagePurgeReasonOutdated = "outdated"
+	PackagePurgeReasonOld      = "old"
+)
+
+func (pm *PackageManager) PurgeOpts() PackagePurgeOpts {
+	return PackagePurgeOpts{
+		ProtectedPatterns: pm.PurgeProtectedPatterns,
+		KeepLast:          pm.PurgeKeepLast,
+		OlderThanDays:     pm.PurgeOlderThanDays,
+	}
+}
+
+// PurgePlan determines packages to be deleted according to the purge policy
+func (pm *PackageManager) PurgePlan(opts PackagePurgeOpts) ([]PackagePurgeItem, error) {
+	list, err := pm.List()
+	if err != nil {
+		return nil, err
+	}
+	return PlanPackagePurge(list.List, opts, time.Now()), nil
+}
+
+// PlanPackagePurge selects packages exceeding the number of latest versions kept per group and name or not touched for a given number of days.
+// The newest version of each package is always kept (even when no number of versions to keep is set) and protected packages are never selected.
+// Only packages installed without any other installed version kept are marked to be uninstalled, as uninstalling a version superseded by another one would roll back the content of the latter.
+func PlanPackagePurge(items []pkg.ListItem, opts PackagePurgeOpts, now time.Time) []PackagePurgeItem {
+	result := []PackagePurgeItem{}
+	if opts.KeepLast <= 0 && opts.OlderThanDays <= 0 {
+		return result
+	}
+	keepLast := max(opts.KeepLast, 1)
+	oldBefore := now.Add(-time.Duration(opts.OlderThanDays) * 24 * time.Hour).UnixMilli()
+	groups := lo.GroupBy(items, func(item pkg.ListItem) string { return item.Group + ":" + item.Name })
+	for _, versions := range groups {
+		sort.SliceStable(versions, func(i, j int) bool {
+			if c := pkg.CompareVersion(versions[i].Version, versions[j].Version); c != 0 {
+				return c > 0
+			}
+			return versions[i].LastTouched() > versions[j].LastTouched()
+		})
+		var purged []PackagePurgeItem
+		var keptInstalled bool
+		for i, item := range versions {
+			if i < keepLast || stringsx.MatchSome(item.PID, opts.ProtectedPatterns) || len(opts.Patterns) > 0 && !stringsx.MatchSome(item.PID, opts.Patterns) {
+				keptInstalled = keptInstalled || item.Installed()
+				continue
+			}
+			if opts.OlderThanDays > 0 {
+				if int64(item.LastTouched()) >= oldBefore {
+					keptInstalled = keptInstalled || item.Installed()
+					continue
+				}
+				purged = append(purged, PackagePurgeItem{PID: item.PID, Path: item.Path, Reason: PackagePurgeReasonOld, Uninstall: item.Installed()})
+			} else {
+				purged = append(purged, PackagePurgeItem{PID: item.PID, Path: item.Path, Reason: PackagePurgeReasonOutdated, Uninstall: item.Installed()})
+			}
+		}
+		effectiveInstalled := keptInstalled
+		for i := range purged {
+			if purged[i].Uninstall {
+				purged[i].Uninstall = !effectiveInstalled
+				effectiveInstalled = true
+			}
+		}
+		result = append(result, purged...)
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].PID < result[j].PID })
+	return result
+}
+
+// Purge uninstalls (where needed) and deletes packages according to the purge policy
+func (pm *PackageManager) Purge(opts PackagePurgeOpts) ([]PackagePurgeItem, error) {
+	items, err := pm.PurgePlan(opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		log.Infof("%s > no packages to purge", pm.instance.IDColor())
+		return items, nil
+	}
+	for i, item := range items {
+		log.Infof("%s > purging package '%s' (%s)", pm.instance.IDColor(), item.PID, stringsx.PercentExplained(i+1, len(items), 0))
+		if item.Uninstall {
+			if err := pm.Uninstall(item.Path); err != nil {
+				return nil, err
+			}
+		}
+		if err := pm.Delete(item.Path); err != nil {
+			return nil, err
+		}
+	}
+	log.Infof("%s > purged packages (%d)", pm.instance.IDColor(), len(items))
+	return items, nil
+}
+
 func (pm *PackageManager) tmpDir() string {
 	if pm.instance.manager.aem.Detached() {
 		return os.TempDir()
@@ -307,6 +609,25 @@ func NewPackageFilters(rootPaths []string) []PackageFilter {
 	return filters
 }
 
+// NewPackageFiltersExact creates filters covering only given nodes and their 'jcr:content' subtrees (suits pages and assets);
+// other descendant nodes (e.g. of components, folders, configurations) are not included
+func NewPackageFiltersExact(paths []string) []PackageFilter {
+	var filters []PackageFilter
+	for _, path := range paths {
+		pattern := regexp.QuoteMeta(path)
+		filters = append(filters, PackageFilter{Root: path, Rules: []PackageFilterRule{
+			{Modifier: PackageFilterInclude, Pattern: pattern},
+			{Modifier: PackageFilterInclude, Pattern: pattern + "/" + content.JCRContentNode + "(/.*)?"},
+		}})
+	}
+	return filters
+}
+
+const (
+	PackageFilterInclude = "include"
+	PackageFilterExclude = "exclude"
+)
+
 type PackageFilterRule struct {
 	Modifier string `json:"modifier"`
 	Pattern  string `json:"pattern"`
@@ -349,18 +670,30 @@ func (pm *PackageManager) UpdateFilters(remotePath string, pid string, filters [
 
 func (pm *PackageManager) Download(remotePath string, localFile string) error {
 	log.Infof("%s > downloading package '%s'", pm.instance.IDColor(), remotePath)
-	if err := httpx.DownloadWithOpts(httpx.DownloadOpts{
-		Client:   pm.instance.http.Client(),
-		URL:      remotePath,
-		File:     localFile,
-		Override: true,
-	}); err != nil {
+	if err := pm.download(remotePath, localFile); err != nil {
 		return fmt.Errorf("%s > cannot download package '%s': %w", pm.instance.IDColor(), remotePath, err)
 	}
 	log.Infof("%s > downloaded package '%s'", pm.instance.IDColor(), remotePath)
 	return nil
 }
 
+func (pm *PackageManager) download(remotePath string, localFile string) error {
+	return pm.transferWithRetry("downloading", remotePath, func() error {
+		return pm.downloadOnce(remotePath, localFile)
+	})
+}
+
+// downloadOnce makes single download attempt (to be used inside other retried transfers)
+func (pm *PackageManager) downloadOnce(remotePath string, localFile string) error {
+	return httpx.DownloadWithOpts(httpx.DownloadOpts{
+		Client:         pm.instance.http.Client(),
+		URL:            remotePath,
+		File:           localFile,
+		Override:       true,
+		BandwidthLimit: pm.TransferBandwidthLimit,
+	})
+}
+
 func (pm *PackageManager) Build(remotePath string) error {
 	log.Infof("%s > building package '%s'", pm.instance.IDColor(), remotePath)
 	response, err := pm.instance.http.Request().Post(ServiceJsonPath + remotePath + "?cmd=build")
@@ -407,10 +740,116 @@ func (pm *PackageManager) UploadWithChanged(localPath string) (bool, error) {
 }
 
 func (pm *PackageManager) Upload(localPath string) (string, error) {
-	if pm.UploadOptimized {
-		return pm.uploadOptimized(localPath)
+	if !pathx.Exists(localPath) {
+		return "", fmt.Errorf("%s > cannot upload package '%s': file does not exist", pm.instance.IDColor(), localPath)
+	}
+	var remotePath string
+	if err := pm.transferWithRetry("uploading", localPath, func() error {
+		var err error
+		if pm.UploadOptimized {
+			remotePath, err = pm.uploadOptimized(localPath)
+		} else {
+			remotePath, err = pm.uploadBuffered(localPath)
+		}
+		if err != nil {
+			return err
+		}
+		return pm.verifyUpload(localPath, remotePath)
+	}); err != nil {
+		return "", err
+	}
+	return remotePath, nil
+}
+
+// transferWithRetry repeats uploads/downloads failed due to transient errors with exponential backoff as large packages often fail over unstable networks.
+// Each attempt restarts the transfer from scratch (partial transfers are not resumed).
+func (pm *PackageManager) transferWithRetry(action string, path string, callback func() error) error {
+	attempts := max(pm.TransferRetryAttempts, 1)
+	delay := pm.TransferRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := callback()
+		if err == nil || attempt >= attempts || !transferRetryable(err) {
+			return err
+		}
+		log.Warnf("%s > %s package '%s' failed (attempt %d/%d), retrying in %s: %s", pm.instance.IDColor(), action, path, attempt, attempts, delay, err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// transferMismatchError indicates that uploaded package differs from the local one
+type transferMismatchError struct {
+	message string
+}
+
+func (e *transferMismatchError) Error() string {
+	return e.message
+}
+
+// transferRetryable accepts only transient errors: network ones, server errors (5xx) and verification mismatches
+func transferRetryable(err error) bool {
+	var statusErr *httpx.StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= http.StatusInternalServerError
+	}
+	var mismatchErr *transferMismatchError
+	if errors.As(err, &mismatchErr) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE)
+}
+
+// transferReader limits bandwidth and shows progress bar (in text mode only) when reading package file
+func (pm *PackageManager) transferReader(file *os.File) (io.Reader, func()) {
+	reader := iox.NewRateLimitedReader(file, pm.TransferBandwidthLimit)
+	if color.NoColor {
+		return reader, func() {}
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		return reader, func() {}
+	}
+	bar := pb.Full.Start64(stat.Size())
+	return bar.NewProxyReader(reader), func() { bar.Finish() }
+}
+
+func (pm *PackageManager) verifyUpload(localPath string, remotePath string) error {
+	if pm.UploadVerify == "" || pm.UploadVerify == pkg.UploadVerifyNone {
+		return nil
+	}
+	localStat, err := os.Stat(localPath)
+	if err != nil {
+		return fmt.Errorf("%s > cannot verify uploaded package '%s': %w", pm.instance.IDColor(), localPath, err)
+	}
+	response, err := pm.instance.http.Request().Head(remotePath)
+	if err != nil {
+		return fmt.Errorf("%s > cannot verify uploaded package '%s': %w", pm.instance.IDColor(), remotePath, err)
+	} else if response.IsError() {
+		return fmt.Errorf("%s > cannot verify uploaded package '%s': %w", pm.instance.IDColor(), remotePath, &httpx.StatusError{StatusCode: response.StatusCode(), Status: response.Status()})
+	}
+	if remoteSize := response.RawResponse.ContentLength; remoteSize >= 0 && remoteSize != localStat.Size() {
+		return &transferMismatchError{fmt.Sprintf("%s > uploaded package '%s' is corrupted: size '%d' differs from local one '%d'", pm.instance.IDColor(), remotePath, remoteSize, localStat.Size())}
 	}
-	return pm.uploadBuffered(localPath)
+	if pm.UploadVerify == pkg.UploadVerifyChecksum {
+		remoteFile := pathx.RandomFileName(pm.tmpDir(), "pkg_verify", ".zip")
+		defer func() { _ = pathx.DeleteIfExists(remoteFile) }()
+		if err := pm.downloadOnce(remotePath, remoteFile); err != nil {
+			return fmt.Errorf("%s > cannot verify uploaded package '%s': %w", pm.instance.IDColor(), remotePath, err)
+		}
+		equal, err := filex.Equals(localPath, remoteFile)
+		if err != nil {
+			return fmt.Errorf("%s > cannot verify uploaded package '%s': %w", pm.instance.IDColor(), remotePath, err)
+		}
+		if !equal {
+			return &transferMismatchError{fmt.Sprintf("%s > uploaded package '%s' is corrupted: checksum differs from local one", pm.instance.IDColor(), remotePath)}
+		}
+	}
+	log.Debugf("%s > verified uploaded package '%s'", pm.instance.IDColor(), remotePath)
+	return nil
 }
 
 // https://medium.com/@owlwalks/sending-big-file-with-minimal-memory-in-golang-8f3fc280d2c
@@ -420,20 +859,9 @@ func (pm *PackageManager) uploadOptimized(localPath string) (string, error) {
 	r, w := io.Pipe()
 	m := multipart.NewWriter(w)
 	go func() {
-		defer func(w *io.PipeWriter) { _ = w.Close() }(w)
-		defer func(m *multipart.Writer) { _ = m.Close() }(m)
-		part, err := m.CreateFormFile("package", filepath.Base(localPath))
-		if err != nil {
-			return
-		}
-		file, err := os.Open(localPath)
-		if err != nil {
-			return
-		}
-		defer func(file *os.File) { _ = file.Close() }(file)
-		if _, err = io.Copy(part, file); err != nil {
-			return
-		}
+		err := pm.uploadWritePart(m, localPath)
+		_ = m.Close()
+		_ = w.CloseWithError(err)
 	}()
 	request, err := http.NewRequest("POST", pm.instance.HTTP().BaseURL()+ServiceJsonPath+"/?cmd=upload&force=true", r)
 	if err != nil {
@@ -451,7 +879,7 @@ func (pm *PackageManager) uploadOptimized(localPath string) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("%s > cannot upload package '%s': %w", pm.instance.IDColor(), localPath, err)
 	} else if response.StatusCode > 399 {
-		return "", fmt.Errorf("%s > cannot upload package '%s': %s", pm.instance.IDColor(), localPath, response.Status)
+		return "", fmt.Errorf("%s > cannot upload package '%s': %w", pm.instance.IDColor(), localPath, &httpx.StatusError{StatusCode: response.StatusCode, Status: response.Status})
 	}
 	var status pkg.CommandResult
 	if err = fmtx.UnmarshalJSON(response.Body, &status); err != nil {
@@ -464,16 +892,39 @@ func (pm *PackageManager) uploadOptimized(localPath string) (string, error) {
 	return status.Path, nil
 }
 
+func (pm *PackageManager) uploadWritePart(m *multipart.Writer, localPath string) error {
+	part, err := m.CreateFormFile("package", filepath.Base(localPath))
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer func(file *os.File) { _ = file.Close() }(file)
+	reader, finish := pm.transferReader(file)
+	defer finish()
+	_, err = io.Copy(part, reader)
+	return err
+}
+
 func (pm *PackageManager) uploadBuffered(localPath string) (string, error) {
 	log.Infof("%s > uploading package '%s'", pm.instance.IDColor(), localPath)
+	file, err := os.Open(localPath)
+	if err != nil {
+		return "", fmt.Errorf("%s > cannot upload package '%s': %w", pm.instance.IDColor(), localPath, err)
+	}
+	defer func(file *os.File) { _ = file.Close() }(file)
+	reader, finish := pm.transferReader(file)
+	defer finish()
 	response, err := pm.instance.http.Request().
-		SetFile("package", localPath).
+		SetFileReader("package", filepath.Base(localPath), reader).
 		SetMultipartFormData(map[string]string{"force": "true"}).
 		Post(ServiceJsonPath + "/?cmd=upload")
 	if err != nil {
 		return "", fmt.Errorf("%s > cannot upload package '%s': %w", pm.instance.IDColor(), localPath, err)
 	} else if response.IsError() {
-		return "", fmt.Errorf("%s > cannot upload package '%s': %s", pm.instance.IDColor(), localPath, response.Status())
+		return "", fmt.Errorf("%s > cannot upload package '%s': %w", pm.instance.IDColor(), localPath, &httpx.StatusError{StatusCode: response.StatusCode(), Status: response.Status()})
 	}
 	var status pkg.CommandResult
 	if err = fmtx.UnmarshalJSON(response.RawBody(), &status); err != nil {
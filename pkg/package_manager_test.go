@@ -0,0 +1,105 @@
+package pkg_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/wttech/aemc/pkg"
+	pkgx "github.com/wttech/aemc/pkg/pkg"
+	"testing"
+	"time"
+)
+
+func TestParseFilterPaths(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	paths := pkg.ParseFilterPaths("# modified pages\n/content/site/en/b\n\n  /content/site/en/a  \n#/content/site/en/c\n/content/site/en/b\n")
+	a.Equal([]string{"/content/site/en/b", "/content/site/en/a", "/content/site/en/b"}, paths)
+	a.Equal([]string{"/content/site/en/a", "/content/site/en/b"}, pkg.NormalizeFilterPaths(paths))
+	a.Empty(pkg.ParseFilterPaths("# nothing\n\n"))
+}
+
+func TestNewPackageFiltersExact(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	filters := pkg.NewPackageFiltersExact([]string{"/content/dam/site/image.png"})
+	a.Equal([]pkg.PackageFilter{{
+		Root: "/content/dam/site/image.png",
+		Rules: []pkg.PackageFilterRule{
+			{Modifier: pkg.PackageFilterInclude, Pattern: `/content/dam/site/image\.png`},
+			{Modifier: pkg.PackageFilterInclude, Pattern: `/content/dam/site/image\.png/jcr:content(/.*)?`},
+		},
+	}}, filters)
+}
+
+func TestPlanPackageSync(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	source := []pkgx.ListItem{
+		{PID: "my:site-all:1.0.0", Group: "my", Name: "site-all", Version: "1.0.0", Path: "/etc/packages/my/site-all-1.0.0.zip"},
+		{PID: "my:site-content:1.1.0", Group: "my", Name: "site-content", Version: "1.1.0", Path: "/etc/packages/my/site-content-1.1.0.zip", LastModified: 200},
+		{PID: "my:site-config:1.9-SNAPSHOT", Group: "my", Name: "site-config", Version: "1.9-SNAPSHOT", Path: "/etc/packages/my/site-config-1.9-SNAPSHOT.zip"},
+		{PID: "my:site-theme:r1.9", Group: "my", Name: "site-theme", Version: "r1.9", Path: "/etc/packages/my/site-theme-r1.9.zip"},
+		{PID: "my:site-apps:1.0.0", Group: "my", Name: "site-apps", Version: "1.0.0", Path: "/etc/packages/my/site-apps-1.0.0.zip", LastModified: 100},
+		{PID: "other:tool:1.0.0", Group: "other", Name: "tool", Version: "1.0.0", Path: "/etc/packages/other/tool-1.0.0.zip"},
+	}
+	target := []pkgx.ListItem{
+		{PID: "my:site-content:1.1.0", Group: "my", Name: "site-content", Version: "1.1.0", LastModified: 100},
+		{PID: "my:site-config:1.10", Group: "my", Name: "site-config", Version: "1.10"},
+		{PID: "my:site-theme:1.10", Group: "my", Name: "site-theme", Version: "1.10"},
+		{PID: "my:site-apps:1.0.0", Group: "my", Name: "site-apps", Version: "1.0.0", LastModified: 100},
+	}
+
+	a.Equal([]pkg.PackageSyncItem{
+		{PID: "my:site-all:1.0.0", Path: "/etc/packages/my/site-all-1.0.0.zip", Reason: pkg.PackageSyncReasonMissing},
+		{PID: "my:site-content:1.1.0", Path: "/etc/packages/my/site-content-1.1.0.zip", Reason: pkg.PackageSyncReasonNewer},
+		{PID: "my:site-theme:r1.9", Path: "/etc/packages/my/site-theme-r1.9.zip", Reason: pkg.PackageSyncReasonMissing},
+		{PID: "other:tool:1.0.0", Path: "/etc/packages/other/tool-1.0.0.zip", Reason: pkg.PackageSyncReasonMissing},
+	}, pkg.PlanPackageSync(source, target, nil))
+
+	a.Equal([]pkg.PackageSyncItem{
+		{PID: "other:tool:1.0.0", Path: "/etc/packages/other/tool-1.0.0.zip", Reason: pkg.PackageSyncReasonMissing},
+	}, pkg.PlanPackageSync(source, target, []string{"other:*"}))
+
+	a.Empty(pkg.PlanPackageSync(source, source, nil))
+}
+
+func TestPlanPackagePurge(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) int { return int(now.Add(-time.Duration(days) * 24 * time.Hour).UnixMilli()) }
+	items := []pkgx.ListItem{
+		{PID: "my:site:1.9.0", Group: "my", Name: "site", Version: "1.9.0", Path: "/etc/packages/my/site-1.9.0.zip", Created: daysAgo(60), LastUnpacked: daysAgo(60)},
+		{PID: "my:site:1.10.0", Group: "my", Name: "site", Version: "1.10.0", Path: "/etc/packages/my/site-1.10.0.zip", Created: daysAgo(40), LastUnpacked: daysAgo(40)},
+		{PID: "my:site:1.11.0", Group: "my", Name: "site", Version: "1.11.0", Path: "/etc/packages/my/site-1.11.0.zip", Created: daysAgo(10), LastUnpacked: daysAgo(10)},
+		{PID: "my:tool:1.0.0", Group: "my", Name: "tool", Version: "1.0.0", Path: "/etc/packages/my/tool-1.0.0.zip", Created: daysAgo(90), LastUnpacked: daysAgo(90)},
+		{PID: "adobe/cq:hotfix:1.0", Group: "adobe/cq", Name: "hotfix", Version: "1.0", Path: "/etc/packages/adobe/cq/hotfix-1.0.zip", Created: daysAgo(90)},
+		{PID: "adobe/cq:hotfix:2.0", Group: "adobe/cq", Name: "hotfix", Version: "2.0", Path: "/etc/packages/adobe/cq/hotfix-2.0.zip", Created: daysAgo(90)},
+	}
+	protected := []string{"adobe/*"}
+
+	a.Empty(pkg.PlanPackagePurge(items, pkg.PackagePurgeOpts{ProtectedPatterns: protected}, now))
+
+	a.Equal([]pkg.PackagePurgeItem{
+		{PID: "my:site:1.10.0", Path: "/etc/packages/my/site-1.10.0.zip", Reason: pkg.PackagePurgeReasonOutdated},
+		{PID: "my:site:1.9.0", Path: "/etc/packages/my/site-1.9.0.zip", Reason: pkg.PackagePurgeReasonOutdated},
+	}, pkg.PlanPackagePurge(items, pkg.PackagePurgeOpts{KeepLast: 1, ProtectedPatterns: protected}, now))
+
+	a.Equal([]pkg.PackagePurgeItem{
+		{PID: "my:site:1.9.0", Path: "/etc/packages/my/site-1.9.0.zip", Reason: pkg.PackagePurgeReasonOld},
+	}, pkg.PlanPackagePurge(items, pkg.PackagePurgeOpts{KeepLast: 1, OlderThanDays: 50, ProtectedPatterns: protected}, now))
+
+	a.Equal([]pkg.PackagePurgeItem{
+		{PID: "my:site:1.9.0", Path: "/etc/packages/my/site-1.9.0.zip", Reason: pkg.PackagePurgeReasonOld},
+	}, pkg.PlanPackagePurge(items, pkg.PackagePurgeOpts{OlderThanDays: 50, ProtectedPatterns: protected}, now))
+
+	a.Empty(pkg.PlanPackagePurge(items, pkg.PackagePurgeOpts{OlderThanDays: 50, Patterns: []string{"my:tool:*"}}, now))
+
+	tools := append(items, pkgx.ListItem{PID: "my:tool:1.1.0", Group: "my", Name: "tool", Version: "1.1.0", Path: "/etc/packages/my/tool-1.1.0.zip", Created: daysAgo(80)})
+	a.Equal([]pkg.PackagePurgeItem{
+		{PID: "my:tool:1.0.0", Path: "/etc/packages/my/tool-1.0.0.zip", Reason: pkg.PackagePurgeReasonOld, Uninstall: true},
+	}, pkg.PlanPackagePurge(tools, pkg.PackagePurgeOpts{OlderThanDays: 50, Patterns: []string{"my:tool:*"}}, now))
+}
@@ -1,11 +1,16 @@
 package pkg
 
 import (
+	"cmp"
 	"fmt"
 	"github.com/dustin/go-humanize"
+	"github.com/hashicorp/go-version"
 	"github.com/samber/lo"
 	"github.com/wttech/aemc/pkg/common/fmtx"
 	"github.com/wttech/aemc/pkg/common/intsx"
+	"regexp"
+	"strconv"
+	"strings"
 )
 
 type List struct {
@@ -64,3 +69,35 @@ type CommandResult struct {
 	Message string `json:"msg"`
 	Path    string `json:"path"`
 }
+
+// CompareVersion compares package versions semantically (falls back to natural comparison for non-semantic ones)
+func CompareVersion(v1 string, v2 string) int {
+	sv1, err1 := version.NewVersion(v1)
+	sv2, err2 := version.NewVersion(v2)
+	if err1 != nil || err2 != nil {
+		return compareVersionNatural(v1, v2)
+	}
+	return sv1.Compare(sv2)
+}
+
+// compareVersionNatural compares numeric segments by value and other ones lexically (e.g. '1.10' > '1.9-SNAPSHOT')
+func compareVersionNatural(v1 string, v2 string) int {
+	s1 := versionSegmentRegex.FindAllString(v1, -1)
+	s2 := versionSegmentRegex.FindAllString(v2, -1)
+	for i := 0; i < len(s1) && i < len(s2); i++ {
+		n1, err1 := strconv.Atoi(s1[i])
+		n2, err2 := strconv.Atoi(s2[i])
+		var result int
+		if err1 == nil && err2 == nil {
+			result = cmp.Compare(n1, n2)
+		} else {
+			result = strings.Compare(s1[i], s2[i])
+		}
+		if result != 0 {
+			return result
+		}
+	}
+	return cmp.Compare(len(s1), len(s2))
+}
+
+var versionSegmentRegex = regexp.MustCompile(`\d+|[^\d.\-_]+`)
@@ -0,0 +1,21 @@
+package pkg_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/wttech/aemc/pkg/pkg"
+	"testing"
+)
+
+func TestCompareVersion(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	a.Equal(0, pkg.CompareVersion("1.0.0", "1.0.0"))
+	a.Equal(1, pkg.CompareVersion("1.10", "1.9"))
+	a.Equal(1, pkg.CompareVersion("1.10", "1.9-SNAPSHOT"))
+	a.Equal(-1, pkg.CompareVersion("1.0.0-SNAPSHOT", "1.0.0"))
+	a.Equal(1, pkg.CompareVersion("release_1.10", "release_1.9"))
+	a.Equal(-1, pkg.CompareVersion("release_1.9", "release_1.10"))
+	a.Equal(1, pkg.CompareVersion("r2.1", "r2"))
+	a.Equal(0, pkg.CompareVersion("r2.1", "r2.1"))
+}
@@ -5,4 +5,21 @@ const (
 	InstallSuccessWithErrors = "<span class=\"Package imported (with errors"
 
 	InstallExtractOnlySnapshot = "snapshot"
+
+	UploadVerifyNone     = "none"
+	UploadVerifySize     = "size"
+	UploadVerifyChecksum = "checksum"
 )
+
+// Workspace filter import modes (https://jackrabbit.apache.org/filevault/filter.html#Import_Mode)
+const (
+	FilterModeReplace          = "replace"
+	FilterModeMerge            = "merge"
+	FilterModeMergeProperties  = "merge_properties"
+	FilterModeUpdate           = "update"
+	FilterModeUpdateProperties = "update_properties"
+)
+
+func FilterModes() []string {
+	return []string{FilterModeReplace, FilterModeMerge, FilterModeMergeProperties, FilterModeUpdate, FilterModeUpdateProperties}
+}
@@ -2,6 +2,7 @@ package pkg
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"github.com/go-resty/resty/v2"
 	"github.com/samber/lo"
@@ -9,18 +10,28 @@ import (
 	"github.com/wttech/aemc/pkg/common/fmtx"
 	"github.com/wttech/aemc/pkg/common/httpx"
 	"github.com/wttech/aemc/pkg/common/mapsx"
+	"github.com/wttech/aemc/pkg/common/stringsx"
 	"github.com/wttech/aemc/pkg/repo"
 	"net/http"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // Repo Facade for communicating with JCR repository.
 type Repo struct {
 	instance *Instance
 
-	PropertyChangeIgnored []string
+	PropertyChangeIgnored   []string
+	ExportPropertiesSkipped []string
+	ExportNodesSkipped      []string
+	BulkParallelism         int
+	BulkBatchSize           int
+	FileUploadSkipping      bool
+	FileUploadDamDirect     bool
+	FileUploadPartTimeout   time.Duration
 }
 
 func NewRepo(i *Instance) *Repo {
@@ -29,7 +40,14 @@ func NewRepo(i *Instance) *Repo {
 	return &Repo{
 		instance: i,
 
-		PropertyChangeIgnored: cv.GetStringSlice("instance.repo.property_change_ignored"),
+		PropertyChangeIgnored:   cv.GetStringSlice("instance.repo.property_change_ignored"),
+		ExportPropertiesSkipped: cv.GetStringSlice("instance.repo.export.properties_skipped"),
+		ExportNodesSkipped:      cv.GetStringSlice("instance.repo.export.nodes_skipped"),
+		BulkParallelism:         cv.GetInt("instance.repo.bulk.parallelism"),
+		BulkBatchSize:           cv.GetInt("instance.repo.bulk.batch_size"),
+		FileUploadSkipping:      cv.GetBool("instance.repo.file.upload.skipping"),
+		FileUploadDamDirect:     cv.GetBool("instance.repo.file.upload.dam_direct"),
+		FileUploadPartTimeout:   cv.GetDuration("instance.repo.file.upload.part_timeout"),
 	}
 }
 
@@ -110,6 +128,267 @@ func (r Repo) Move(sourcePath string, targetPath string, replace bool) error {
 	return nil
 }
 
+// Export reads node tree (depth below zero means unlimited); when Sling refuses to render too big tree at once, nodes are read level by level
+func (r Repo) Export(path string, depth int) (*repo.Node, error) {
+	log.Infof("%s > exporting node '%s'", r.instance.IDColor(), path)
+	selector := lo.Ternary(depth < 0, "infinity", strconv.Itoa(depth))
+	response, err := r.instance.http.Request().Get(fmt.Sprintf("%s.%s.json", path, selector))
+	if err != nil {
+		return nil, fmt.Errorf("%s > cannot export node '%s': %w", r.instance.IDColor(), path, err)
+	}
+	var node *repo.Node
+	if response.StatusCode() == http.StatusMultipleChoices {
+		log.Infof("%s > exporting node '%s' level by level as tree is too big to be read at once", r.instance.IDColor(), path)
+		node, err = r.exportTraversing(path, depth)
+		if err != nil {
+			return nil, err
+		}
+	} else if response.IsError() {
+		return nil, fmt.Errorf("%s > cannot export node '%s': %s", r.instance.IDColor(), path, response.Status())
+	} else {
+		node, err = repo.ParseNodeJSON(response.Body())
+		if err != nil {
+			return nil, fmt.Errorf("%s > cannot export node '%s': %w", r.instance.IDColor(), path, err)
+		}
+	}
+	node.Name = stringsx.AfterLast(path, "/")
+	if binaries := node.Skip(r.ExportPropertiesSkipped, r.ExportNodesSkipped); len(binaries) > 0 {
+		log.Warnf("%s > skipped exporting binary properties of node '%s' as they are not supported (%s)", r.instance.IDColor(), path, strings.Join(binaries, ", "))
+	}
+	log.Infof("%s > exported node '%s' (nodes: %d)", r.instance.IDColor(), path, node.Count())
+	return node, nil
+}
+
+func (r Repo) exportTraversing(path string, depth int) (*repo.Node, error) {
+	response, err := r.instance.http.Request().Get(fmt.Sprintf("%s.%d.json", path, lo.Ternary(depth == 0, 0, 1)))
+	if err != nil {
+		return nil, fmt.Errorf("%s > cannot export node '%s': %w", r.instance.IDColor(), path, err)
+	} else if response.IsError() {
+		return nil, fmt.Errorf("%s > cannot export node '%s': %s", r.instance.IDColor(), path, response.Status())
+	}
+	node, err := repo.ParseNodeJSON(response.Body())
+	if err != nil {
+		return nil, fmt.Errorf("%s > cannot export node '%s': %w", r.instance.IDColor(), path, err)
+	}
+	if depth == 1 {
+		return node, nil
+	}
+	for i, child := range node.Children {
+		if stringsx.MatchSome(child.Name, r.ExportNodesSkipped) {
+			continue
+		}
+		childNode, err := r.exportTraversing(path+"/"+child.Name, lo.Ternary(depth < 0, depth, depth-1))
+		if err != nil {
+			return nil, err
+		}
+		childNode.Name = child.Name
+		node.Children[i] = childNode
+	}
+	return node, nil
+}
+
+// Import creates node tree under path using Sling POST servlet (existing nodes and properties are kept unless replacing)
+func (r Repo) Import(path string, node *repo.Node, replace bool) error {
+	log.Infof("%s > importing node '%s'", r.instance.IDColor(), path)
+	content, err := json.Marshal(node)
+	if err != nil {
+		return fmt.Errorf("%s > cannot import node '%s': %w", r.instance.IDColor(), path, err)
+	}
+	request := r.instance.http.Request()
+	request.SetHeader("Accept", "application/json")
+	request.FormData.Set(":operation", "import")
+	request.FormData.Set(":contentType", "json")
+	request.FormData.Set(":name", stringsx.AfterLast(path, "/"))
+	request.FormData.Set(":content", string(content))
+	request.FormData.Set(":replace", strconv.FormatBool(replace))
+	request.FormData.Set(":replaceProperties", strconv.FormatBool(replace))
+	resp, err := request.Post(lo.Ternary(stringsx.BeforeLast(path, "/") == "", "/", stringsx.BeforeLast(path, "/")))
+	if err = r.handleResponse(fmt.Sprintf("%s > cannot import node '%s'", r.instance.IDColor(), path), resp, err); err != nil {
+		return err
+	}
+	log.Infof("%s > imported node '%s' (nodes: %d)", r.instance.IDColor(), path, node.Count())
+	return nil
+}
+
+// QueryPaths finds paths of nodes matching QueryBuilder predicates (paged) or JCR-SQL2/XPath statement (requires CRXDE)
+func (r Repo) QueryPaths(language string, statement string) ([]string, error) {
+	hits, err := r.Query(repo.Query{Language: language, Statement: statement})
+	if err != nil {
+		return nil, err
+	}
+	return hits.Paths(), nil
+}
+
+// Query finds nodes and reads selected properties of them; JCR-SQL2/XPath statements require CRXDE and are paged on client side
+func (r Repo) Query(query repo.Query) (*repo.QueryHits, error) {
+	if query.Language == "" {
+		query.Language = repo.QueryLanguageSQL2
+	}
+	switch query.Language {
+	case repo.QueryLanguageQueryBuilder:
+		return r.queryBuilder(query)
+	case repo.QueryLanguageSQL2, repo.QueryLanguageXPath:
+		return r.queryCrxde(query)
+	default:
+		return nil, fmt.Errorf("%s > unsupported query language '%s'", r.instance.IDColor(), query.Language)
+	}
+}
+
+func (r Repo) queryBuilder(query repo.Query) (*repo.QueryHits, error) {
+	predicates, err := repo.ParseQueryBuilderPredicates(query.Statement)
+	if err != nil {
+		return nil, err
+	}
+	result := &repo.QueryHits{Query: query, Hits: []repo.QueryHit{}}
+	for offset := query.Offset; ; {
+		pageSize := QueryBuilderPageSize
+		if query.Limit > 0 {
+			pageSize = lo.Min([]int{pageSize, query.Limit - len(result.Hits)})
+		}
+		response, err := r.instance.http.Request().
+			SetQueryParams(predicates).
+			SetQueryParams(repo.QueryBuilderHitsParams(query.Properties)).
+			SetQueryParams(map[string]string{
+				"p.limit":  fmt.Sprintf("%d", pageSize),
+				"p.offset": fmt.Sprintf("%d", offset),
+			}).
+			Get(QueryBuilderPath)
+		if err != nil {
+			return nil, fmt.Errorf("%s > cannot query nodes using '%s': %w", r.instance.IDColor(), query.Statement, err)
+		} else if response.IsError() {
+			return nil, fmt.Errorf("%s > cannot query nodes using '%s': %s", r.instance.IDColor(), query.Statement, response.Status())
+		}
+		var page repo.QueryBuilderResult
+		if err = fmtx.UnmarshalJSON(response.RawBody(), &page); err != nil {
+			return nil, fmt.Errorf("%s > cannot parse query response: %w", r.instance.IDColor(), err)
+		}
+		if !page.Success {
+			return nil, fmt.Errorf("%s > cannot query nodes using '%s': query failed", r.instance.IDColor(), query.Statement)
+		}
+		result.Total = page.Total
+		for _, hit := range page.Hits {
+			result.Hits = append(result.Hits, repo.QueryHit{
+				Path:       fmt.Sprintf("%v", hit["jcr:path"]),
+				Properties: repo.QueryBuilderHitProperties(hit, query.Properties),
+			})
+		}
+		offset += len(page.Hits)
+		if len(page.Hits) == 0 || (!page.More && offset >= page.Total) || (query.Limit > 0 && len(result.Hits) >= query.Limit) {
+			break
+		}
+	}
+	return result, nil
+}
+
+func (r Repo) queryCrxde(query repo.Query) (*repo.QueryHits, error) {
+	paths, err := r.queryCrxdePaths(query.Language, query.Statement)
+	if err != nil {
+		return nil, err
+	}
+	result := &repo.QueryHits{Query: query, Total: len(paths), Hits: []repo.QueryHit{}}
+	paths = lo.Drop(paths, query.Offset)
+	if query.Limit > 0 && len(paths) > query.Limit {
+		paths = paths[:query.Limit]
+	}
+	for _, path := range paths {
+		hit := repo.QueryHit{Path: path}
+		if len(query.Properties) > 0 {
+			props, err := r.readSelected(path, query.Properties)
+			if err != nil {
+				return nil, err
+			}
+			hit.Properties = props
+		}
+		result.Hits = append(result.Hits, hit)
+	}
+	return result, nil
+}
+
+// readSelected reads properties of node and its descendants (e.g. 'jcr:content/jcr:title')
+func (r Repo) readSelected(path string, names []string) (map[string]any, error) {
+	result := map[string]any{}
+	nodeProps := map[string]map[string]any{}
+	for _, name := range names {
+		relPath, propName := "", name
+		if strings.Contains(name, "/") {
+			relPath, propName = stringsx.BeforeLast(name, "/"), stringsx.AfterLast(name, "/")
+		}
+		props, ok := nodeProps[relPath]
+		if !ok {
+			nodePath := lo.Ternary(relPath == "", path, path+"/"+relPath)
+			exists, err := r.Exists(nodePath)
+			if err != nil {
+				return nil, err
+			}
+			if exists {
+				if props, err = r.Read(nodePath); err != nil {
+					return nil, err
+				}
+			}
+			nodeProps[relPath] = props
+		}
+		if value, found := props[propName]; found {
+			result[name] = value
+		}
+	}
+	return result, nil
+}
+
+func (r Repo) queryCrxdePaths(language string, statement string) ([]string, error) {
+	response, err := r.instance.http.Request().
+		SetQueryParams(map[string]string{
+			"_charset_":   "utf-8",
+			"type":        language,
+			"stmt":        statement,
+			"showResults": "true",
+		}).
+		Get(CrxdeQueryPath)
+	if err != nil {
+		return nil, fmt.Errorf("%s > cannot query nodes using '%s': %w", r.instance.IDColor(), statement, err)
+	} else if response.StatusCode() == http.StatusNotFound || response.StatusCode() == http.StatusForbidden {
+		return nil, fmt.Errorf("%s > cannot query nodes using '%s': CRXDE is disabled or blocked (status '%s'); use QueryBuilder predicates instead", r.instance.IDColor(), statement, response.Status())
+	} else if response.IsError() {
+		return nil, fmt.Errorf("%s > cannot query nodes using '%s': %s", r.instance.IDColor(), statement, response.Status())
+	}
+	var result repo.QueryResult
+	if err = fmtx.UnmarshalJSON(response.RawBody(), &result); err != nil {
+		return nil, fmt.Errorf("%s > cannot parse query response (CRXDE may be disabled): %w", r.instance.IDColor(), err)
+	}
+	if !result.Success {
+		return nil, fmt.Errorf("%s > cannot query nodes using '%s': query failed", r.instance.IDColor(), statement)
+	}
+	return lo.Map(result.Results, func(item repo.QueryResultItem, _ int) string { return item.Path }), nil
+}
+
+// QueryExplain determines Oak query plan using query performance tool (JCR-SQL2 and XPath only)
+func (r Repo) QueryExplain(language string, statement string) (*repo.QueryExplanation, error) {
+	if language == "" {
+		language = repo.QueryLanguageSQL2
+	}
+	if language == repo.QueryLanguageQueryBuilder {
+		return nil, fmt.Errorf("%s > cannot explain query as language '%s' is not supported (use '%s' or '%s')", r.instance.IDColor(), language, repo.QueryLanguageSQL2, repo.QueryLanguageXPath)
+	}
+	response, err := r.instance.http.Request().
+		SetFormData(map[string]string{
+			"statement":     statement,
+			"language":      language,
+			"executionTime": "false",
+			"resultCount":   "false",
+		}).
+		Post(QueryExplainPath)
+	if err != nil {
+		return nil, fmt.Errorf("%s > cannot explain query '%s': %w", r.instance.IDColor(), statement, err)
+	} else if response.IsError() {
+		return nil, fmt.Errorf("%s > cannot explain query '%s': %s", r.instance.IDColor(), statement, response.Status())
+	}
+	var result repo.QueryExplainResponse
+	if err = fmtx.UnmarshalJSON(response.RawBody(), &result); err != nil {
+		return nil, fmt.Errorf("%s > cannot parse query explanation: %w", r.instance.IDColor(), err)
+	}
+	explanation := result.Explanation()
+	return &explanation, nil
+}
+
 func (r Repo) requestFormData(operation string, props map[string]any) *resty.Request {
 	request := r.instance.http.Request()
 	request.SetHeader("Accept", "application/json")
@@ -201,3 +480,10 @@ func (nl NodeList) MarshalText() string {
 	})))
 	return bs.String()
 }
+
+const (
+	CrxdeQueryPath       = "/crx/de/query.jsp"
+	QueryBuilderPath     = "/bin/querybuilder.json"
+	QueryBuilderPageSize = 1000
+	QueryExplainPath     = "/libs/granite/operations/content/diagnosistools/queryPerformance.explain.json"
+)
@@ -1,6 +1,10 @@
 package repo
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
 
 type RepoResult struct {
 	Title         string    `json:"title"`
@@ -26,3 +30,87 @@ func (rr RepoResult) IsError() bool {
 func (rr RepoResult) ErrorMessage() string {
 	return fmt.Sprintf("%s [%d]; %s", rr.Title, rr.StatusCode, rr.StatusMessage)
 }
+
+type QueryResult struct {
+	Success bool              `json:"success"`
+	Total   int               `json:"total"`
+	Results []QueryResultItem `json:"results"`
+}
+
+type QueryResultItem struct {
+	Path string `json:"path"`
+}
+
+type QueryBuilderResult struct {
+	Success bool             `json:"success"`
+	Total   int              `json:"total"`
+	More    bool             `json:"more"`
+	Offset  int              `json:"offset"`
+	Hits    []map[string]any `json:"hits"`
+}
+
+// ParseQueryBuilderPredicates converts 'key=value' pairs (separated by new lines or '&') to QueryBuilder parameters
+func ParseQueryBuilderPredicates(statement string) (map[string]string, error) {
+	result := map[string]string{}
+	for _, line := range strings.FieldsFunc(statement, func(r rune) bool { return r == '\n' || r == '&' }) {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("query builder predicate '%s' has different format than expected 'key=value'", line)
+		}
+		result[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("query builder predicates are not specified")
+	}
+	return result, nil
+}
+
+// QueryBuilderHitsParams determines rendering of hits; as selective hits cannot include properties of descendant nodes (e.g. 'jcr:content/jcr:title'), full hits with sufficient node depth are requested then
+func QueryBuilderHitsParams(properties []string) map[string]string {
+	depth := 0
+	for _, name := range properties {
+		depth = max(depth, strings.Count(name, "/"))
+	}
+	if depth == 0 {
+		return map[string]string{
+			"p.hits":       "selective",
+			"p.properties": strings.Join(append([]string{"jcr:path"}, properties...), " "),
+		}
+	}
+	return map[string]string{
+		"p.hits":      "full",
+		"p.nodedepth": strconv.Itoa(depth),
+	}
+}
+
+// QueryBuilderHitProperties picks properties from hit; properties of descendant nodes are read from nested objects
+func QueryBuilderHitProperties(hit map[string]any, names []string) map[string]any {
+	result := map[string]any{}
+	for _, name := range names {
+		var current any = hit
+		for _, segment := range strings.Split(name, "/") {
+			object, ok := current.(map[string]any)
+			if !ok {
+				current = nil
+				break
+			}
+			if current, ok = object[segment]; !ok {
+				break
+			}
+		}
+		if current != nil {
+			result[name] = current
+		}
+	}
+	return result
+}
+
+const (
+	QueryLanguageSQL2         = "JCR-SQL2"
+	QueryLanguageXPath        = "xpath"
+	QueryLanguageQueryBuilder = "querybuilder"
+)
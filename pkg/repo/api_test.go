@@ -0,0 +1,44 @@
+package repo_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/wttech/aemc/pkg/repo"
+	"testing"
+)
+
+func TestParseQueryBuilderPredicates(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	predicates, err := repo.ParseQueryBuilderPredicates("path=/content/site&type=cq:Page\ndaterange.property=jcr:content/cq:lastModified\n")
+	a.NoError(err)
+	a.Equal(map[string]string{
+		"path":               "/content/site",
+		"type":               "cq:Page",
+		"daterange.property": "jcr:content/cq:lastModified",
+	}, predicates)
+
+	_, err = repo.ParseQueryBuilderPredicates("path")
+	a.Error(err)
+	_, err = repo.ParseQueryBuilderPredicates("")
+	a.Error(err)
+}
+
+func TestQueryBuilderHits(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	a.Equal(map[string]string{"p.hits": "selective", "p.properties": "jcr:path jcr:primaryType"}, repo.QueryBuilderHitsParams([]string{"jcr:primaryType"}))
+	a.Equal(map[string]string{"p.hits": "full", "p.nodedepth": "2"}, repo.QueryBuilderHitsParams([]string{"jcr:primaryType", "jcr:content/jcr:title", "jcr:content/image/fileReference"}))
+
+	hit := map[string]any{
+		"jcr:path":        "/content/site/en",
+		"jcr:primaryType": "cq:Page",
+		"jcr:content":     map[string]any{"jcr:title": "English", "image": map[string]any{"fileReference": "/content/dam/a.png"}},
+	}
+	a.Equal(map[string]any{
+		"jcr:primaryType":                 "cq:Page",
+		"jcr:content/jcr:title":           "English",
+		"jcr:content/image/fileReference": "/content/dam/a.png",
+	}, repo.QueryBuilderHitProperties(hit, []string{"jcr:primaryType", "jcr:content/jcr:title", "jcr:content/image/fileReference", "jcr:content/missing", "jcr:path/x"}))
+}
@@ -0,0 +1,107 @@
+package repo
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"github.com/samber/lo"
+	"github.com/wttech/aemc/pkg/common/fmtx"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const (
+	BulkOperationSave       = "save"
+	BulkOperationPropDelete = "prop-delete"
+	BulkOperationMixinAdd   = "mixin-add"
+	BulkOperationDelete     = "delete"
+	BulkOperationMove       = "move"
+)
+
+func BulkOperations() []string {
+	return []string{BulkOperationSave, BulkOperationPropDelete, BulkOperationMixinAdd, BulkOperationDelete, BulkOperationMove}
+}
+
+// Bulk describes operation applied to many nodes at once
+type Bulk struct {
+	Operation  string
+	Properties map[string]any
+	PropNames  []string
+	Mixins     []string
+
+	MovePattern     *regexp.Regexp
+	MoveReplacement string
+	MoveReplace     bool
+
+	Parallelism int
+	BatchSize   int
+	DryRun      bool
+}
+
+// MoveTarget determines path to which node is moved; empty when path does not match pattern
+func (b Bulk) MoveTarget(path string) string {
+	if b.MovePattern == nil || !b.MovePattern.MatchString(path) {
+		return ""
+	}
+	return b.MovePattern.ReplaceAllString(path, b.MoveReplacement)
+}
+
+type BulkResult struct {
+	Operation string     `yaml:"operation" json:"operation"`
+	DryRun    bool       `yaml:"dry_run" json:"dryRun"`
+	Items     []BulkItem `yaml:"items" json:"items"`
+}
+
+type BulkItem struct {
+	Path    string `yaml:"path" json:"path"`
+	Target  string `yaml:"target,omitempty" json:"target,omitempty"`
+	Changed bool   `yaml:"changed" json:"changed"`
+	Error   string `yaml:"error,omitempty" json:"error,omitempty"`
+}
+
+func (r BulkResult) Changed() []BulkItem {
+	return lo.Filter(r.Items, func(item BulkItem, _ int) bool { return item.Changed })
+}
+
+func (r BulkResult) Failed() []BulkItem {
+	return lo.Filter(r.Items, func(item BulkItem, _ int) bool { return item.Error != "" })
+}
+
+func (r BulkResult) MarshalText() string {
+	bs := bytes.NewBufferString("")
+	bs.WriteString(fmtx.TblMap("stats", "stat", "value", map[string]any{
+		"operation": r.Operation,
+		"dry run":   r.DryRun,
+		"total":     len(r.Items),
+		"changed":   len(r.Changed()),
+		"failed":    len(r.Failed()),
+	}))
+	bs.WriteString("\n")
+	bs.WriteString(fmtx.TblRows("items", false, []string{"path", "target", "changed", "error"}, lo.Map(r.Items, func(item BulkItem, _ int) map[string]any {
+		return map[string]any{"path": item.Path, "target": item.Target, "changed": item.Changed, "error": item.Error}
+	})))
+	return bs.String()
+}
+
+// ReadPathsFile reads node paths listed line by line (empty lines and comments starting with '#' are skipped)
+func ReadPathsFile(file string) ([]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open paths file '%s': %w", file, err)
+	}
+	defer f.Close()
+	var result []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		result = append(result, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read paths file '%s': %w", file, err)
+	}
+	return lo.Uniq(result), nil
+}
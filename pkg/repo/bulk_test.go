@@ -0,0 +1,44 @@
+package repo_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/wttech/aemc/pkg/repo"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestBulkMoveTarget(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	bulk := repo.Bulk{Operation: repo.BulkOperationMove, MovePattern: regexp.MustCompile(`^/content/site/old/(.*)$`), MoveReplacement: "/content/site/new/$1"}
+	a.Equal("/content/site/new/en/home", bulk.MoveTarget("/content/site/old/en/home"))
+	a.Equal("", bulk.MoveTarget("/content/other/en"))
+}
+
+func TestBulkResult(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	result := repo.BulkResult{Operation: repo.BulkOperationDelete, Items: []repo.BulkItem{
+		{Path: "/content/a", Changed: true},
+		{Path: "/content/b"},
+		{Path: "/content/c", Error: "cannot delete"},
+	}}
+	a.Len(result.Changed(), 1)
+	a.Len(result.Failed(), 1)
+	a.Contains(result.MarshalText(), "/content/c")
+}
+
+func TestReadPathsFile(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	file := filepath.Join(t.TempDir(), "paths.txt")
+	a.NoError(os.WriteFile(file, []byte("# pages\n/content/a\n\n  /content/b  \n/content/a\n"), 0644))
+	paths, err := repo.ReadPathsFile(file)
+	a.NoError(err)
+	a.Equal([]string{"/content/a", "/content/b"}, paths)
+}
@@ -0,0 +1,85 @@
+package repo
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/samber/lo"
+	"github.com/wttech/aemc/pkg/common/fmtx"
+	"mime"
+	"path/filepath"
+)
+
+const (
+	FileUploadMethodSling     = "sling"
+	FileUploadMethodDamDirect = "dam-direct"
+	FileUploadMethodDamCreate = "dam-create"
+)
+
+// FileUpload describes local file uploaded as binary node (nt:file or DAM asset)
+type FileUpload struct {
+	LocalFile string `yaml:"local_file" json:"localFile"`
+	Path      string `yaml:"path" json:"path"`
+	Size      int64  `yaml:"size" json:"size"`
+	Checksum  string `yaml:"checksum" json:"checksum"`
+	Method    string `yaml:"method,omitempty" json:"method,omitempty"`
+	Changed   bool   `yaml:"changed" json:"changed"`
+}
+
+type FileUploadList struct {
+	Files []FileUpload `yaml:"files" json:"files"`
+}
+
+func (l FileUploadList) Changed() []FileUpload {
+	return lo.Filter(l.Files, func(f FileUpload, _ int) bool { return f.Changed })
+}
+
+func (l FileUploadList) MarshalText() string {
+	bs := bytes.NewBufferString("")
+	bs.WriteString(fmtx.TblMap("stats", "stat", "value", map[string]any{"total": len(l.Files), "uploaded": len(l.Changed())}))
+	bs.WriteString("\n")
+	bs.WriteString(fmtx.TblRows("files", true, []string{"path", "size", "method", "changed"}, lo.Map(l.Files, func(f FileUpload, _ int) map[string]any {
+		return map[string]any{"path": f.Path, "size": f.Size, "method": f.Method, "changed": f.Changed}
+	})))
+	return bs.String()
+}
+
+// FileMimeType determines MIME type by file extension
+func FileMimeType(file string) string {
+	result := mime.TypeByExtension(filepath.Ext(file))
+	if result == "" {
+		return "application/octet-stream"
+	}
+	return result
+}
+
+// DamUploadInitiateResponse is a response of AEM direct binary upload initiation ('{folder}.initiateUpload.json')
+type DamUploadInitiateResponse struct {
+	CompleteURI string          `json:"completeURI"`
+	FolderPath  string          `json:"folderPath"`
+	Files       []DamUploadFile `json:"files"`
+}
+
+type DamUploadFile struct {
+	FileName    string   `json:"fileName"`
+	MimeType    string   `json:"mimeType"`
+	UploadToken string   `json:"uploadToken"`
+	UploadURIs  []string `json:"uploadURIs"`
+	MinPartSize int64    `json:"minPartSize"`
+	MaxPartSize int64    `json:"maxPartSize"`
+}
+
+// PartSize determines size of parts so that all of them fit into available upload URIs
+func (f DamUploadFile) PartSize(fileSize int64) (int64, error) {
+	if len(f.UploadURIs) == 0 {
+		return 0, fmt.Errorf("no upload URIs available for file '%s'", f.FileName)
+	}
+	uriCount := int64(len(f.UploadURIs))
+	result := (fileSize + uriCount - 1) / uriCount
+	if result < f.MinPartSize && uriCount > 1 {
+		result = min(f.MinPartSize, fileSize)
+	}
+	if f.MaxPartSize > 0 && result > f.MaxPartSize {
+		return 0, fmt.Errorf("file '%s' of size '%d' is too big to be uploaded using '%d' parts of max size '%d'", f.FileName, fileSize, uriCount, f.MaxPartSize)
+	}
+	return max(result, 1), nil
+}
@@ -0,0 +1,35 @@
+package repo_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/wttech/aemc/pkg/repo"
+	"testing"
+)
+
+func TestFileMimeType(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	a.Equal("application/pdf", repo.FileMimeType("docs/manual.pdf"))
+	a.Equal("application/octet-stream", repo.FileMimeType("docs/manual"))
+}
+
+func TestDamUploadFilePartSize(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	file := repo.DamUploadFile{FileName: "video.mp4", UploadURIs: []string{"a", "b", "c"}, MinPartSize: 10, MaxPartSize: 100}
+	size, err := file.PartSize(250)
+	a.NoError(err)
+	a.Equal(int64(84), size)
+
+	size, err = file.PartSize(12)
+	a.NoError(err)
+	a.Equal(int64(10), size)
+
+	_, err = file.PartSize(1000)
+	a.Error(err)
+
+	_, err = repo.DamUploadFile{FileName: "empty.txt"}.PartSize(5)
+	a.Error(err)
+}
@@ -0,0 +1,121 @@
+package repo
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/samber/lo"
+	"github.com/wttech/aemc/pkg/common/fmtx"
+	"regexp"
+	"strings"
+)
+
+// Query describes nodes to be found; limit zero means all results
+type Query struct {
+	Language   string   `yaml:"language" json:"language"`
+	Statement  string   `yaml:"statement" json:"statement"`
+	Properties []string `yaml:"properties,omitempty" json:"properties,omitempty"`
+	Offset     int      `yaml:"offset" json:"offset"`
+	Limit      int      `yaml:"limit" json:"limit"`
+}
+
+func QueryLanguages() []string {
+	return []string{QueryLanguageSQL2, QueryLanguageXPath, QueryLanguageQueryBuilder}
+}
+
+type QueryHits struct {
+	Query Query      `yaml:"query" json:"query"`
+	Total int        `yaml:"total" json:"total"`
+	Hits  []QueryHit `yaml:"hits" json:"hits"`
+}
+
+type QueryHit struct {
+	Path       string         `yaml:"path" json:"path"`
+	Properties map[string]any `yaml:"properties,omitempty" json:"properties,omitempty"`
+}
+
+func (h QueryHits) Paths() []string {
+	return lo.Map(h.Hits, func(hit QueryHit, _ int) string { return hit.Path })
+}
+
+func (h QueryHits) MarshalText() string {
+	bs := bytes.NewBufferString("")
+	bs.WriteString(fmtx.TblMap("stats", "stat", "value", map[string]any{"total": h.Total, "hits": len(h.Hits)}))
+	bs.WriteString(fmtx.TblRows("hits", true, append([]string{"path"}, h.Query.Properties...), lo.Map(h.Hits, func(hit QueryHit, _ int) map[string]any {
+		row := map[string]any{"path": hit.Path}
+		for _, name := range h.Query.Properties {
+			row[name] = hit.Properties[name]
+		}
+		return row
+	})))
+	return bs.String()
+}
+
+// QueryExplanation describes how Oak executes query (e.g. which index is used or if repository is traversed)
+type QueryExplanation struct {
+	Statement string   `yaml:"statement" json:"statement"`
+	Language  string   `yaml:"language" json:"language"`
+	Plan      string   `yaml:"plan" json:"plan"`
+	Indexes   []string `yaml:"indexes" json:"indexes"`
+	Traversal bool     `yaml:"traversal" json:"traversal"`
+	Slow      bool     `yaml:"slow" json:"slow"`
+	Logs      []string `yaml:"logs,omitempty" json:"logs,omitempty"`
+}
+
+type QueryExplainResponse struct {
+	Explain struct {
+		Statement       string   `json:"statement"`
+		Language        string   `json:"language"`
+		Plan            string   `json:"plan"`
+		PropertyIndexes []string `json:"propertyIndexes"`
+		Traversal       bool     `json:"traversal"`
+		Slow            bool     `json:"slow"`
+		Logs            []string `json:"logs"`
+	} `json:"explain"`
+}
+
+var (
+	queryPlanIndexRegex = regexp.MustCompile(`/\*\s*(?:property\s+|[\w-]+:)?([\w.:-]+)`)
+	queryPlanPathRegex  = regexp.MustCompile(`/oak:index/([\w.:-]+)`)
+)
+
+func (r QueryExplainResponse) Explanation() QueryExplanation {
+	e := r.Explain
+	return QueryExplanation{
+		Statement: e.Statement,
+		Language:  e.Language,
+		Plan:      e.Plan,
+		Indexes:   lo.Uniq(append(QueryPlanIndexes(e.Plan), e.PropertyIndexes...)),
+		Traversal: e.Traversal || strings.Contains(e.Plan, "/* traverse"),
+		Slow:      e.Slow,
+		Logs:      e.Logs,
+	}
+}
+
+// QueryPlanIndexes extracts names of indexes used in Oak query plan (e.g. '[cq:Page] as [a] /* lucene:cqPageLucene(/oak:index/cqPageLucene) ... */')
+func QueryPlanIndexes(plan string) []string {
+	var result []string
+	for _, groups := range queryPlanPathRegex.FindAllStringSubmatch(plan, -1) {
+		result = append(result, groups[1])
+	}
+	if len(result) == 0 {
+		for _, groups := range queryPlanIndexRegex.FindAllStringSubmatch(plan, -1) {
+			if groups[1] != "traverse" {
+				result = append(result, groups[1])
+			}
+		}
+	}
+	return lo.Uniq(result)
+}
+
+func (e QueryExplanation) MarshalText() string {
+	bs := bytes.NewBufferString("")
+	bs.WriteString(fmtx.TblMap("explanation", "key", "value", map[string]any{
+		"statement": e.Statement,
+		"language":  e.Language,
+		"indexes":   strings.Join(e.Indexes, ", "),
+		"traversal": e.Traversal,
+		"slow":      e.Slow,
+	}))
+	bs.WriteString(fmt.Sprintf("\nplan\n\n%s\n", e.Plan))
+	return bs.String()
+}
@@ -0,0 +1,27 @@
+package repo_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/wttech/aemc/pkg/repo"
+	"testing"
+)
+
+func TestQueryPlanIndexes(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	a.Equal([]string{"cqPageLucene"}, repo.QueryPlanIndexes("[cq:Page] as [a] /* lucene:cqPageLucene(/oak:index/cqPageLucene) +:ancestors:/content/site */"))
+	a.Equal([]string{"slingResourceType"}, repo.QueryPlanIndexes("[nt:base] as [a] /* property slingResourceType = site/components/page */"))
+	a.Empty(repo.QueryPlanIndexes("[nt:base] as [a] /* traverse \"/content//*\" */"))
+}
+
+func TestQueryExplanation(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	response := repo.QueryExplainResponse{}
+	response.Explain.Plan = "[nt:base] as [a] /* traverse \"/content//*\" */"
+	explanation := response.Explanation()
+	a.True(explanation.Traversal)
+	a.Empty(explanation.Indexes)
+}
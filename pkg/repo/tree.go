@@ -0,0 +1,266 @@
+package repo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/samber/lo"
+	"github.com/wttech/aemc/pkg/common/fmtx"
+	"github.com/wttech/aemc/pkg/common/stringsx"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Node is a JCR node tree in the same shape as Sling JSON rendering (properties and child nodes as object fields)
+// but serialized deterministically (properties sorted by name, child nodes kept in repository order)
+type Node struct {
+	Name       string
+	Properties map[string]any
+	Children   []*Node
+}
+
+func NewNode(name string) *Node {
+	return &Node{Name: name, Properties: map[string]any{}}
+}
+
+// ParseNodeJSON reads Sling JSON rendering preserving order of child nodes
+func ParseNodeJSON(data []byte) (*Node, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse node JSON: %w", err)
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '{' {
+		return nil, fmt.Errorf("cannot parse node JSON: object expected")
+	}
+	node, err := decodeNodeJSON(decoder, "")
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse node JSON: %w", err)
+	}
+	return node, nil
+}
+
+func decodeNodeJSON(decoder *json.Decoder, name string) (*Node, error) {
+	node := NewNode(name)
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		key := token.(string)
+		token, err = decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		if delim, ok := token.(json.Delim); ok && delim == '{' {
+			child, err := decodeNodeJSON(decoder, key)
+			if err != nil {
+				return nil, err
+			}
+			node.Children = append(node.Children, child)
+		} else {
+			value, err := decodeValueJSON(decoder, token)
+			if err != nil {
+				return nil, err
+			}
+			node.Properties[key] = value
+		}
+	}
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+func decodeValueJSON(decoder *json.Decoder, token json.Token) (any, error) {
+	if number, ok := token.(json.Number); ok {
+		if value, err := number.Int64(); err == nil {
+			return value, nil
+		}
+		return number.Float64()
+	}
+	delim, ok := token.(json.Delim)
+	if !ok {
+		return token, nil
+	}
+	if delim != '[' {
+		return nil, fmt.Errorf("unexpected token '%s'", delim)
+	}
+	result := []any{}
+	for decoder.More() {
+		item, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		value, err := decodeValueJSON(decoder, item)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, value)
+	}
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ReadNodeFile reads node tree from JSON or YAML file
+func ReadNodeFile(file string) (*Node, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read node file '%s': %w", file, err)
+	}
+	var node *Node
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yml", ".yaml":
+		node = NewNode("")
+		err = yaml.Unmarshal(data, node)
+	default:
+		node, err = ParseNodeJSON(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse node file '%s': %w", file, err)
+	}
+	return node, nil
+}
+
+func (n *Node) Child(name string) (*Node, bool) {
+	return lo.Find(n.Children, func(child *Node) bool { return child.Name == name })
+}
+
+// Skip removes properties and child nodes matching name patterns; Sling JSON metadata (e.g. binary lengths prefixed with ':') is always removed.
+// Returns paths (relative to node) of binary properties as they are not supported and are not exported.
+func (n *Node) Skip(propertiesSkipped []string, nodesSkipped []string) []string {
+	var binaries []string
+	for _, name := range n.propertyNames() {
+		if strings.HasPrefix(name, ":") {
+			binaries = append(binaries, strings.TrimPrefix(name, ":"))
+			delete(n.Properties, name)
+		} else if stringsx.MatchSome(name, propertiesSkipped) {
+			delete(n.Properties, name)
+		}
+	}
+	n.Children = lo.Filter(n.Children, func(child *Node, _ int) bool { return !stringsx.MatchSome(child.Name, nodesSkipped) })
+	for _, child := range n.Children {
+		for _, binary := range child.Skip(propertiesSkipped, nodesSkipped) {
+			binaries = append(binaries, child.Name+"/"+binary)
+		}
+	}
+	return binaries
+}
+
+// Count returns number of nodes in tree
+func (n *Node) Count() int {
+	result := 1
+	for _, child := range n.Children {
+		result += child.Count()
+	}
+	return result
+}
+
+func (n *Node) propertyNames() []string {
+	names := lo.Keys(n.Properties)
+	sort.Strings(names)
+	return names
+}
+
+func (n Node) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString("{")
+	first := true
+	writeKey := func(key string) {
+		if !first {
+			b.WriteString(",")
+		}
+		first = false
+		keyJSON, _ := json.Marshal(key)
+		b.Write(keyJSON)
+		b.WriteString(":")
+	}
+	for _, name := range n.propertyNames() {
+		valueJSON, err := json.Marshal(n.Properties[name])
+		if err != nil {
+			return nil, err
+		}
+		writeKey(name)
+		b.Write(valueJSON)
+	}
+	for _, child := range n.Children {
+		childJSON, err := child.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		writeKey(child.Name)
+		b.Write(childJSON)
+	}
+	b.WriteString("}")
+	return b.Bytes(), nil
+}
+
+func (n *Node) UnmarshalJSON(data []byte) error {
+	node, err := ParseNodeJSON(data)
+	if err != nil {
+		return err
+	}
+	n.Properties = node.Properties
+	n.Children = node.Children
+	return nil
+}
+
+func (n Node) MarshalYAML() (any, error) {
+	result := &yaml.Node{Kind: yaml.MappingNode}
+	for _, name := range n.propertyNames() {
+		value := &yaml.Node{}
+		if err := value.Encode(n.Properties[name]); err != nil {
+			return nil, err
+		}
+		result.Content = append(result.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}, value)
+	}
+	for _, child := range n.Children {
+		value, err := child.MarshalYAML()
+		if err != nil {
+			return nil, err
+		}
+		result.Content = append(result.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: child.Name}, value.(*yaml.Node))
+	}
+	return result, nil
+}
+
+func (n Node) MarshalText() string {
+	text, err := fmtx.MarshalYML(n)
+	if err != nil {
+		return fmt.Sprintf("node '%s' cannot be rendered: %s\n", n.Name, err)
+	}
+	return text
+}
+
+func (n *Node) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind != yaml.MappingNode {
+		return fmt.Errorf("node '%s' should be a mapping", n.Name)
+	}
+	if n.Properties == nil {
+		n.Properties = map[string]any{}
+	}
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		key := value.Content[i].Value
+		item := value.Content[i+1]
+		if item.Kind == yaml.MappingNode {
+			child := NewNode(key)
+			if err := item.Decode(child); err != nil {
+				return err
+			}
+			n.Children = append(n.Children, child)
+		} else {
+			var propValue any
+			if err := item.Decode(&propValue); err != nil {
+				return err
+			}
+			n.Properties[key] = propValue
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package repo_test
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/wttech/aemc/pkg/common/fmtx"
+	"github.com/wttech/aemc/pkg/repo"
+	"gopkg.in/yaml.v3"
+	"testing"
+)
+
+const nodeJSON = `{"jcr:primaryType":"cq:Page","zeta":{"jcr:primaryType":"nt:unstructured","count":3},"jcr:content":{"jcr:primaryType":"cq:PageContent","jcr:title":"Home","jcr:created":"Mon Jan 01 2024","tags":["a","b"],"image":{"jcr:primaryType":"nt:unstructured",":jcr:data":123}},"alpha":{"jcr:primaryType":"cq:Page"}}`
+
+func TestParseNodeJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	node, err := repo.ParseNodeJSON([]byte(nodeJSON))
+	a.NoError(err)
+	a.Equal(5, node.Count())
+	a.Equal([]string{"zeta", "jcr:content", "alpha"}, []string{node.Children[0].Name, node.Children[1].Name, node.Children[2].Name})
+
+	a.Equal([]string{"jcr:content/image/jcr:data"}, node.Skip([]string{"jcr:created"}, []string{"alpha"}))
+	data, err := json.Marshal(node)
+	a.NoError(err)
+	a.Equal(`{"jcr:primaryType":"cq:Page","zeta":{"count":3,"jcr:primaryType":"nt:unstructured"},"jcr:content":{"jcr:primaryType":"cq:PageContent","jcr:title":"Home","tags":["a","b"],"image":{"jcr:primaryType":"nt:unstructured"}}}`, string(data))
+}
+
+func TestNodeYAML(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	node, err := repo.ParseNodeJSON([]byte(nodeJSON))
+	a.NoError(err)
+	text, err := fmtx.MarshalYML(node)
+	a.NoError(err)
+	a.Equal(`jcr:primaryType: cq:Page
+zeta:
+    count: 3
+    jcr:primaryType: nt:unstructured
+jcr:content:
+    jcr:created: Mon Jan 01 2024
+    jcr:primaryType: cq:PageContent
+    jcr:title: Home
+    tags:
+        - a
+        - b
+    image:
+        :jcr:data: 123
+        jcr:primaryType: nt:unstructured
+alpha:
+    jcr:primaryType: cq:Page
+`, text)
+
+	parsed := repo.NewNode("")
+	a.NoError(yaml.Unmarshal([]byte(text), parsed))
+	a.Equal(5, parsed.Count())
+	a.Equal("alpha", parsed.Children[2].Name)
+	a.Equal("Home", parsed.Children[1].Properties["jcr:title"])
+}
@@ -0,0 +1,91 @@
+package pkg
+
+import (
+	"fmt"
+	"github.com/samber/lo"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cast"
+	"github.com/wttech/aemc/pkg/common/lox"
+	"github.com/wttech/aemc/pkg/repo"
+)
+
+// Bulk applies operation to nodes in batches; failures of particular nodes are reported in result instead of stopping whole operation
+func (r Repo) Bulk(paths []string, bulk repo.Bulk) (*repo.BulkResult, error) {
+	if !lo.Contains(repo.BulkOperations(), bulk.Operation) {
+		return nil, fmt.Errorf("%s > bulk operation '%s' is not supported; use one of: %v", r.instance.IDColor(), bulk.Operation, repo.BulkOperations())
+	}
+	if bulk.Operation == repo.BulkOperationMove && bulk.MovePattern == nil {
+		return nil, fmt.Errorf("%s > bulk operation '%s' requires path pattern", r.instance.IDColor(), bulk.Operation)
+	}
+	result := &repo.BulkResult{Operation: bulk.Operation, DryRun: bulk.DryRun}
+	batches := lo.Chunk(paths, lo.Max([]int{1, bulk.BatchSize}))
+	for i, batch := range batches {
+		items, err := lox.ParallelMapLimit(lo.Max([]int{1, bulk.Parallelism}), batch, func(path string) (repo.BulkItem, error) {
+			return r.bulkNode(path, bulk), nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		result.Items = append(result.Items, items...)
+		log.Infof("%s > bulk operation '%s' processed batch %d/%d (%d/%d nodes)", r.instance.IDColor(), bulk.Operation, i+1, len(batches), len(result.Items), len(paths))
+	}
+	return result, nil
+}
+
+func (r Repo) bulkNode(path string, bulk repo.Bulk) repo.BulkItem {
+	item := repo.BulkItem{Path: path}
+	changed, err := r.bulkNodeChanged(r.Node(path), bulk, &item)
+	if err != nil {
+		log.Warnf("%s > bulk operation '%s' failed for node '%s': %s", r.instance.IDColor(), bulk.Operation, path, err)
+		item.Error = err.Error()
+	}
+	item.Changed = changed
+	return item
+}
+
+func (r Repo) bulkNodeChanged(node RepoNode, bulk repo.Bulk, item *repo.BulkItem) (bool, error) {
+	state, err := node.State()
+	if err != nil {
+		return false, err
+	}
+	switch bulk.Operation {
+	case repo.BulkOperationSave:
+		if bulk.DryRun {
+			return !state.Exists || !r.PropsEqual(state.Properties, bulk.Properties), nil
+		}
+		return node.SaveWithChanged(bulk.Properties)
+	case repo.BulkOperationPropDelete:
+		names := lo.Filter(bulk.PropNames, func(name string, _ int) bool { _, ok := state.Properties[name]; return ok })
+		if !state.Exists || len(names) == 0 {
+			return false, nil
+		}
+		if bulk.DryRun {
+			return true, nil
+		}
+		return node.SaveWithChanged(lo.SliceToMap(names, func(name string) (string, any) { return name, nil }))
+	case repo.BulkOperationMixinAdd:
+		mixins := cast.ToStringSlice(state.Properties["jcr:mixinTypes"])
+		if !state.Exists || len(lo.Without(bulk.Mixins, mixins...)) == 0 {
+			return false, nil
+		}
+		if bulk.DryRun {
+			return true, nil
+		}
+		return node.SaveWithChanged(map[string]any{"jcr:mixinTypes": lo.Uniq(append(mixins, bulk.Mixins...))})
+	case repo.BulkOperationDelete:
+		if bulk.DryRun {
+			return state.Exists, nil
+		}
+		return node.DeleteWithChanged()
+	case repo.BulkOperationMove:
+		item.Target = bulk.MoveTarget(node.Path())
+		if item.Target == "" || item.Target == node.Path() {
+			return false, nil
+		}
+		if bulk.DryRun {
+			return state.Exists, nil
+		}
+		return node.MoveWithChanged(item.Target, bulk.MoveReplace)
+	}
+	return false, nil
+}
@@ -0,0 +1,264 @@
+package pkg
+
+import (
+	"fmt"
+	"github.com/samber/lo"
+	log "github.com/sirupsen/logrus"
+	"github.com/wttech/aemc/pkg/common/filex"
+	"github.com/wttech/aemc/pkg/common/fmtx"
+	"github.com/wttech/aemc/pkg/common/httpx"
+	"github.com/wttech/aemc/pkg/common/osx"
+	"github.com/wttech/aemc/pkg/common/pathx"
+	"github.com/wttech/aemc/pkg/repo"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DamRootPath = "/content/dam"
+)
+
+// UploadFile creates or updates binary node (nt:file or DAM asset); upload is skipped when file is unchanged since last upload
+func (r Repo) UploadFile(localFile string, path string, force bool) (*repo.FileUpload, error) {
+	stat, err := os.Stat(localFile)
+	if err != nil {
+		return nil, fmt.Errorf("%s > cannot upload file '%s': %w", r.instance.IDColor(), localFile, err)
+	}
+	if stat.IsDir() {
+		return nil, fmt.Errorf("%s > cannot upload file '%s' as it is a directory", r.instance.IDColor(), localFile)
+	}
+	checksum, err := filex.ChecksumFile(localFile)
+	if err != nil {
+		return nil, fmt.Errorf("%s > cannot upload file '%s': %w", r.instance.IDColor(), localFile, err)
+	}
+	result := &repo.FileUpload{LocalFile: localFile, Path: path, Size: stat.Size(), Checksum: checksum}
+	exists, err := r.Exists(path)
+	if err != nil {
+		return nil, err
+	}
+	lock := r.fileUploadLock(path, checksum)
+	if !force && exists && r.FileUploadSkipping && lock.IsLocked() {
+		lockData, err := lock.Locked()
+		if err != nil {
+			return nil, err
+		}
+		if lockData.Checksum == checksum {
+			log.Infof("%s > skipped uploading file '%s' to node '%s'", r.instance.IDColor(), localFile, path)
+			return result, nil
+		}
+	}
+	if err := r.ensureFolders(path); err != nil {
+		return nil, err
+	}
+	dam := strings.HasPrefix(path, DamRootPath+"/")
+	uploaded := false
+	if r.FileUploadDamDirect && dam {
+		uploaded, err = r.uploadDamDirect(localFile, path, stat.Size(), exists)
+		if err != nil {
+			return nil, err
+		}
+		result.Method = repo.FileUploadMethodDamDirect
+	}
+	if !uploaded && dam {
+		if err := r.uploadDamCreate(localFile, path); err != nil {
+			return nil, err
+		}
+		result.Method = repo.FileUploadMethodDamCreate
+	} else if !uploaded {
+		if err := r.uploadSling(localFile, path); err != nil {
+			return nil, err
+		}
+		result.Method = repo.FileUploadMethodSling
+	}
+	if err := lock.Lock(); err != nil {
+		return nil, err
+	}
+	result.Changed = true
+	return result, nil
+}
+
+// UploadDir uploads all files from local directory keeping their relative paths
+func (r Repo) UploadDir(localDir string, path string, force bool) (*repo.FileUploadList, error) {
+	if !pathx.Exists(localDir) {
+		return nil, fmt.Errorf("%s > cannot upload dir '%s' as it does not exist", r.instance.IDColor(), localDir)
+	}
+	result := &repo.FileUploadList{}
+	if err := filepath.WalkDir(localDir, func(localFile string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		relPath, err := filepath.Rel(localDir, localFile)
+		if err != nil {
+			return err
+		}
+		upload, err := r.UploadFile(localFile, strings.TrimSuffix(path, "/")+"/"+filepath.ToSlash(relPath), force)
+		if err != nil {
+			return err
+		}
+		result.Files = append(result.Files, *upload)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("%s > cannot upload dir '%s': %w", r.instance.IDColor(), localDir, err)
+	}
+	return result, nil
+}
+
+// ensureFolders creates missing parent nodes as folders as otherwise Sling creates them as unstructured nodes
+func (r Repo) ensureFolders(path string) error {
+	var missing []RepoNode
+	for _, parent := range r.Node(path).Parents() {
+		exists, err := parent.Exists()
+		if err != nil {
+			return err
+		}
+		if exists {
+			break
+		}
+		missing = append(missing, parent)
+	}
+	for _, folder := range lo.Reverse(missing) {
+		primaryType := lo.Ternary(strings.HasPrefix(folder.Path(), DamRootPath), "sling:OrderedFolder", "sling:Folder")
+		if err := r.Save(folder.Path(), map[string]any{"jcr:primaryType": primaryType}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r Repo) uploadSling(localFile string, path string) error {
+	log.Infof("%s > uploading file '%s' to node '%s'", r.instance.IDColor(), localFile, path)
+	file, err := os.Open(localFile)
+	if err != nil {
+		return fmt.Errorf("%s > cannot upload file '%s': %w", r.instance.IDColor(), localFile, err)
+	}
+	defer file.Close()
+	node := r.Node(path)
+	response, err := r.instance.http.Request().
+		SetHeader("Accept", "application/json").
+		SetMultipartField(node.Name(), node.Name(), repo.FileMimeType(localFile), file).
+		SetMultipartFormData(map[string]string{node.Name() + "@TypeHint": "nt:file"}).
+		Post(node.Parent().Path())
+	if err := r.handleResponse(fmt.Sprintf("%s > cannot upload file '%s' to node '%s'", r.instance.IDColor(), localFile, path), response, err); err != nil {
+		return err
+	}
+	log.Infof("%s > uploaded file '%s' to node '%s'", r.instance.IDColor(), localFile, path)
+	return nil
+}
+
+// uploadDamCreate uploads asset using Asset Manager so that it is created as 'dam:Asset' (with renditions and metadata processed) instead of plain 'nt:file'
+func (r Repo) uploadDamCreate(localFile string, path string) error {
+	log.Infof("%s > uploading asset '%s' to node '%s'", r.instance.IDColor(), localFile, path)
+	file, err := os.Open(localFile)
+	if err != nil {
+		return fmt.Errorf("%s > cannot upload asset '%s': %w", r.instance.IDColor(), localFile, err)
+	}
+	defer file.Close()
+	node := r.Node(path)
+	response, err := r.instance.http.Request().
+		SetMultipartField("file", node.Name(), repo.FileMimeType(localFile), file).
+		SetMultipartFormData(map[string]string{"fileName": node.Name()}).
+		Post(node.Parent().Path() + ".createasset.html")
+	if err != nil {
+		return fmt.Errorf("%s > cannot upload asset '%s' to node '%s': %w", r.instance.IDColor(), localFile, path, err)
+	} else if response.IsError() {
+		return fmt.Errorf("%s > cannot upload asset '%s' to node '%s': %w", r.instance.IDColor(), localFile, path, &httpx.StatusError{StatusCode: response.StatusCode(), Status: response.Status()})
+	}
+	log.Infof("%s > uploaded asset '%s' to node '%s'", r.instance.IDColor(), localFile, path)
+	return nil
+}
+
+// uploadDamDirect uploads asset using AEM direct binary upload; returns false when protocol is not available on instance
+// https://experienceleague.adobe.com/docs/experience-manager-cloud-service/content/assets/admin/developer-reference-material-apis.html#upload-binary
+func (r Repo) uploadDamDirect(localFile string, path string, size int64, replace bool) (bool, error) {
+	node := r.Node(path)
+	response, err := r.instance.http.Request().
+		SetFormData(map[string]string{"fileName": node.Name(), "fileSize": strconv.FormatInt(size, 10)}).
+		Post(node.Parent().Path() + ".initiateUpload.json")
+	if err != nil {
+		return false, fmt.Errorf("%s > cannot initiate direct upload of asset '%s': %w", r.instance.IDColor(), path, err)
+	}
+	if response.IsError() {
+		log.Debugf("%s > direct binary upload not available (%s), falling back to Asset Manager upload of asset '%s'", r.instance.IDColor(), response.Status(), path)
+		return false, nil
+	}
+	var initiated repo.DamUploadInitiateResponse
+	if err := fmtx.UnmarshalJSON(response.RawBody(), &initiated); err != nil {
+		log.Debugf("%s > direct binary upload not available (%s), falling back to Asset Manager upload of asset '%s'", r.instance.IDColor(), err, path)
+		return false, nil
+	}
+	if len(initiated.Files) == 0 || len(initiated.Files[0].UploadURIs) == 0 {
+		log.Debugf("%s > direct binary upload not available (no upload URIs), falling back to Asset Manager upload of asset '%s'", r.instance.IDColor(), path)
+		return false, nil
+	}
+	upload := initiated.Files[0]
+	log.Infof("%s > uploading asset '%s' to node '%s' directly", r.instance.IDColor(), localFile, path)
+	if err := r.uploadDamDirectParts(localFile, size, upload); err != nil {
+		return false, fmt.Errorf("%s > cannot upload asset '%s' directly: %w", r.instance.IDColor(), path, err)
+	}
+	response, err = r.instance.http.Request().
+		SetFormData(map[string]string{
+			"fileName":    upload.FileName,
+			"mimeType":    lo.CoalesceOrEmpty(upload.MimeType, repo.FileMimeType(localFile)),
+			"uploadToken": upload.UploadToken,
+			"replace":     strconv.FormatBool(replace),
+		}).
+		Post(initiated.CompleteURI)
+	if err != nil {
+		return false, fmt.Errorf("%s > cannot complete direct upload of asset '%s': %w", r.instance.IDColor(), path, err)
+	} else if response.IsError() {
+		return false, fmt.Errorf("%s > cannot complete direct upload of asset '%s': %w", r.instance.IDColor(), path, &httpx.StatusError{StatusCode: response.StatusCode(), Status: response.Status()})
+	}
+	log.Infof("%s > uploaded asset '%s' to node '%s' directly", r.instance.IDColor(), localFile, path)
+	return true, nil
+}
+
+func (r Repo) uploadDamDirectParts(localFile string, size int64, upload repo.DamUploadFile) error {
+	partSize, err := upload.PartSize(size)
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(localFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	client := &http.Client{Timeout: r.FileUploadPartTimeout}
+	for i, uri := range upload.UploadURIs {
+		offset := int64(i) * partSize
+		if offset >= size {
+			break
+		}
+		length := min(partSize, size-offset)
+		request, err := http.NewRequest(http.MethodPut, uri, io.NewSectionReader(file, offset, length))
+		if err != nil {
+			return err
+		}
+		request.ContentLength = length
+		response, err := client.Do(request)
+		if err != nil {
+			return fmt.Errorf("cannot upload part %d/%d: %w", i+1, len(upload.UploadURIs), err)
+		}
+		_ = response.Body.Close()
+		if response.StatusCode > 299 {
+			return fmt.Errorf("cannot upload part %d/%d: %w", i+1, len(upload.UploadURIs), &httpx.StatusError{StatusCode: response.StatusCode, Status: response.Status})
+		}
+	}
+	return nil
+}
+
+func (r Repo) fileUploadLock(path string, checksum string) osx.Lock[repoFileUploadLock] {
+	return osx.NewLock(fmt.Sprintf("%s/repo/file/upload%s.yml", r.instance.LockDir(), path), func() (repoFileUploadLock, error) {
+		return repoFileUploadLock{Uploaded: time.Now(), Checksum: checksum}, nil
+	})
+}
+
+type repoFileUploadLock struct {
+	Uploaded time.Time `yaml:"uploaded"`
+	Checksum string    `yaml:"checksum"`
+}
@@ -0,0 +1,260 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/wttech/aemc/pkg/common/filex"
+	"github.com/wttech/aemc/pkg/common/pathx"
+	"golang.org/x/crypto/scrypt"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvProvider reads secrets from environment variables (e.g. 'AEM_SECRET_MAILER_PASSWORD' when prefix is 'AEM_SECRET_')
+type EnvProvider struct {
+	Prefix string
+}
+
+func (p *EnvProvider) Name() string {
+	return "env"
+}
+
+func (p *EnvProvider) Get(name string) (string, bool, error) {
+	value, ok := os.LookupEnv(p.Prefix + name)
+	return value, ok, nil
+}
+
+// FileProvider reads secrets from files named after secrets (compatible with Felix interpolation plugin secrets dir)
+type FileProvider struct {
+	Dir string
+}
+
+func (p *FileProvider) Name() string {
+	return "file"
+}
+
+func (p *FileProvider) Get(name string) (string, bool, error) {
+	file := filepath.Join(p.Dir, name)
+	if !pathx.Exists(file) {
+		return "", false, nil
+	}
+	value, err := filex.ReadString(file)
+	if err != nil {
+		return "", false, err
+	}
+	return strings.TrimRight(value, "\r\n"), true, nil
+}
+
+// VaultProvider reads secrets from HashiCorp Vault KV (version 2) secrets engine, each secret being a key under a single path; server certificate is verified using system CAs or the given CA file
+type VaultProvider struct {
+	Address  string
+	Mount    string
+	Path     string
+	TokenVar string
+	CAFile   string
+
+	data map[string]string
+}
+
+type vaultResponse struct {
+	Data struct {
+		Data map[string]any `json:"data"`
+	} `json:"data"`
+}
+
+func (p *VaultProvider) Name() string {
+	return "vault"
+}
+
+func (p *VaultProvider) Get(name string) (string, bool, error) {
+	if p.data == nil {
+		data, err := p.read()
+		if err != nil {
+			return "", false, err
+		}
+		p.data = data
+	}
+	value, ok := p.data[name]
+	return value, ok, nil
+}
+
+func (p *VaultProvider) read() (map[string]string, error) {
+	token := os.Getenv(p.TokenVar)
+	if token == "" {
+		return nil, fmt.Errorf("Vault token is not set in environment variable '%s'", p.TokenVar)
+	}
+	url := fmt.Sprintf("%s/v1/%s/data/%s", strings.TrimSuffix(p.Address, "/"), p.Mount, strings.TrimPrefix(p.Path, "/"))
+	client := resty.New()
+	client.DisableWarn = true
+	if p.CAFile != "" {
+		if !pathx.Exists(p.CAFile) {
+			return nil, fmt.Errorf("Vault CA file '%s' does not exist", p.CAFile)
+		}
+		client.SetRootCertificate(p.CAFile)
+	}
+	resp, err := client.R().SetHeader("X-Vault-Token", token).Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read Vault secrets from '%s': %w", url, err)
+	} else if resp.IsError() {
+		return nil, fmt.Errorf("cannot read Vault secrets from '%s': %s", url, resp.Status())
+	}
+	var body vaultResponse
+	if err := json.Unmarshal(resp.Body(), &body); err != nil {
+		return nil, fmt.Errorf("cannot parse Vault secrets from '%s': %w", url, err)
+	}
+	result := make(map[string]string, len(body.Data.Data))
+	for k, v := range body.Data.Data {
+		result[k] = fmt.Sprintf("%v", v)
+	}
+	return result, nil
+}
+
+// EncryptedFileProvider reads secrets from local YAML file encrypted with AES-GCM using key derived from passphrase taken from environment variable
+type EncryptedFileProvider struct {
+	File   string
+	KeyVar string
+
+	data map[string]string
+}
+
+func (p *EncryptedFileProvider) Name() string {
+	return "encrypted_file"
+}
+
+func (p *EncryptedFileProvider) Get(name string) (string, bool, error) {
+	if p.data == nil {
+		data, err := p.Read()
+		if err != nil {
+			return "", false, err
+		}
+		p.data = data
+	}
+	value, ok := p.data[name]
+	return value, ok, nil
+}
+
+func (p *EncryptedFileProvider) key() (string, error) {
+	key := os.Getenv(p.KeyVar)
+	if key == "" {
+		return "", fmt.Errorf("secrets file key is not set in environment variable '%s'", p.KeyVar)
+	}
+	return key, nil
+}
+
+// Read decrypts all secrets from file; returns empty map when file does not exist yet
+func (p *EncryptedFileProvider) Read() (map[string]string, error) {
+	result := map[string]string{}
+	if !pathx.Exists(p.File) {
+		return result, nil
+	}
+	key, err := p.key()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := filex.ReadString(p.File)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := Decrypt(key, strings.TrimSpace(encrypted))
+	if err != nil {
+		return nil, fmt.Errorf("cannot decrypt secrets file '%s': %w", p.File, err)
+	}
+	if err := yaml.Unmarshal(plain, &result); err != nil {
+		return nil, fmt.Errorf("cannot parse secrets file '%s': %w", p.File, err)
+	}
+	return result, nil
+}
+
+// Set stores secret in file (re-encrypting whole content); returns false if secret value was already up-to-date
+func (p *EncryptedFileProvider) Set(name string, value string) (bool, error) {
+	data, err := p.Read()
+	if err != nil {
+		return false, err
+	}
+	if current, ok := data[name]; ok && current == value {
+		return false, nil
+	}
+	data[name] = value
+	key, err := p.key()
+	if err != nil {
+		return false, err
+	}
+	plain, err := yaml.Marshal(data)
+	if err != nil {
+		return false, fmt.Errorf("cannot serialize secrets file '%s': %w", p.File, err)
+	}
+	encrypted, err := Encrypt(key, plain)
+	if err != nil {
+		return false, fmt.Errorf("cannot encrypt secrets file '%s': %w", p.File, err)
+	}
+	if err := filex.WriteString(p.File, encrypted+"\n"); err != nil {
+		return false, err
+	}
+	p.data = data
+	return true, nil
+}
+
+const (
+	keySaltSize = 16
+	keySize     = 32
+)
+
+// newGCM derives AES key from passphrase using scrypt so that guessing weak passphrases is expensive
+func newGCM(key string, salt []byte) (cipher.AEAD, error) {
+	derived, err := scrypt.Key([]byte(key), salt, 1<<15, 8, 1, keySize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(derived)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Encrypt seals data using AES-GCM with key derived from passphrase and random salt; result is base64 encoded and prefixed with salt and nonce
+func Encrypt(key string, data []byte) (string, error) {
+	salt := make([]byte, keySaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	gcm, err := newGCM(key, salt)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := append(salt, nonce...)
+	return base64.StdEncoding.EncodeToString(gcm.Seal(sealed, nonce, data, nil)), nil
+}
+
+// Decrypt opens data sealed by Encrypt
+func Decrypt(key string, encrypted string) ([]byte, error) {
+	sealed, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < keySaltSize {
+		return nil, fmt.Errorf("encrypted data is too short")
+	}
+	gcm, err := newGCM(key, sealed[:keySaltSize])
+	if err != nil {
+		return nil, err
+	}
+	sealed = sealed[keySaltSize:]
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("encrypted data is too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
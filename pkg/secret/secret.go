@@ -0,0 +1,167 @@
+// Package secret resolves '$[secret:NAME]' placeholders using pluggable providers
+package secret
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/wttech/aemc/pkg/cfg"
+)
+
+var placeholderRegex = regexp.MustCompile(`\$\[secret:([A-Za-z0-9_.\-]+)]`)
+
+// Provider looks up secret values by name; returns false when secret is not known by provider
+type Provider interface {
+	Name() string
+	Get(name string) (string, bool, error)
+}
+
+// Resolver replaces secret placeholders with values taken from the first provider knowing the secret
+type Resolver struct {
+	Providers []Provider
+}
+
+func NewResolver(providers ...Provider) *Resolver {
+	return &Resolver{Providers: providers}
+}
+
+// NewResolverByConfig creates resolver using providers configured in 'secret' config section
+func NewResolverByConfig(config *cfg.Config) *Resolver {
+	cv := config.Values()
+
+	var providers []Provider
+	if cv.GetBool("secret.env.enabled") {
+		providers = append(providers, &EnvProvider{Prefix: cv.GetString("secret.env.prefix")})
+	}
+	if dir := cv.GetString("secret.file.dir"); dir != "" {
+		providers = append(providers, &FileProvider{Dir: dir})
+	}
+	if file := cv.GetString("secret.encrypted_file.path"); file != "" {
+		providers = append(providers, &EncryptedFileProvider{File: file, KeyVar: cv.GetString("secret.encrypted_file.key_var")})
+	}
+	if address := cv.GetString("secret.vault.address"); address != "" {
+		providers = append(providers, &VaultProvider{
+			Address:  address,
+			Mount:    cv.GetString("secret.vault.mount"),
+			Path:     cv.GetString("secret.vault.path"),
+			TokenVar: cv.GetString("secret.vault.token_var"),
+			CAFile:   cv.GetString("secret.vault.ca_file"),
+		})
+	}
+	return NewResolver(providers...)
+}
+
+// Names returns names of secrets referenced by placeholders in text
+func Names(text string) []string {
+	return lo.Uniq(lo.Map(placeholderRegex.FindAllStringSubmatch(text, -1), func(m []string, _ int) string { return m[1] }))
+}
+
+// Has checks if text contains at least one secret placeholder
+func Has(text string) bool {
+	return placeholderRegex.MatchString(text)
+}
+
+// Placeholder returns placeholder referencing secret with a given name
+func Placeholder(name string) string {
+	return fmt.Sprintf("$[secret:%s]", name)
+}
+
+// Find returns the first provider knowing secret or nil when none knows it
+func (r *Resolver) Find(name string) (Provider, error) {
+	for _, provider := range r.Providers {
+		_, ok, err := provider.Get(name)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read secret '%s' using provider '%s': %w", name, provider.Name(), err)
+		}
+		if ok {
+			return provider, nil
+		}
+	}
+	return nil, nil
+}
+
+// Get returns secret value from the first provider knowing it
+func (r *Resolver) Get(name string) (string, error) {
+	for _, provider := range r.Providers {
+		value, ok, err := provider.Get(name)
+		if err != nil {
+			return "", fmt.Errorf("cannot read secret '%s' using provider '%s': %w", name, provider.Name(), err)
+		}
+		if ok {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("cannot find secret '%s' using providers '%s'", name, strings.Join(r.ProviderNames(), ","))
+}
+
+func (r *Resolver) ProviderNames() []string {
+	return lo.Map(r.Providers, func(p Provider, _ int) string { return p.Name() })
+}
+
+// Resolve replaces all secret placeholders in text
+func (r *Resolver) Resolve(text string) (string, error) {
+	var err error
+	result := placeholderRegex.ReplaceAllStringFunc(text, func(placeholder string) string {
+		if err != nil {
+			return placeholder
+		}
+		value, getErr := r.Get(placeholderRegex.FindStringSubmatch(placeholder)[1])
+		if getErr != nil {
+			err = getErr
+			return placeholder
+		}
+		return value
+	})
+	if err != nil {
+		return "", err
+	}
+	return result, nil
+}
+
+// ResolveProps replaces secret placeholders in string (and string list) values; returns names of props containing secrets
+func (r *Resolver) ResolveProps(props map[string]any) (map[string]any, []string, error) {
+	result := make(map[string]any, len(props))
+	var secretProps []string
+	for name, value := range props {
+		switch typed := value.(type) {
+		case string:
+			if !Has(typed) {
+				result[name] = typed
+				continue
+			}
+			resolved, err := r.Resolve(typed)
+			if err != nil {
+				return nil, nil, fmt.Errorf("cannot resolve secret in property '%s': %w", name, err)
+			}
+			result[name] = resolved
+			secretProps = append(secretProps, name)
+		case []any:
+			values := make([]any, len(typed))
+			secret := false
+			for i, item := range typed {
+				itemText, ok := item.(string)
+				if !ok || !Has(itemText) {
+					values[i] = item
+					continue
+				}
+				resolved, err := r.Resolve(itemText)
+				if err != nil {
+					return nil, nil, fmt.Errorf("cannot resolve secret in property '%s': %w", name, err)
+				}
+				values[i] = resolved
+				secret = true
+			}
+			result[name] = values
+			if secret {
+				secretProps = append(secretProps, name)
+			}
+		default:
+			result[name] = value
+		}
+	}
+	sort.Strings(secretProps)
+	return result, secretProps, nil
+}